@@ -34,8 +34,12 @@ type CurrentConfiguration struct {
 	TickerActive    bool
 	VectorsDisabled bool
 	VectorBot       bool
+	VectorCapMB     int // evict the least recently used stored models beyond this size; 0 is no cap
+	VectorCapModels int // evict the least recently used stored models beyond this count; 0 is no cap
 	VectorChtHt     string
 	VectorChtWd     string
+	VectorDelete    []string // fingerprints of stored models to drop at launch
+	VectorList      bool
 	VectorMaxlines  int
 	VectorModel     string
 	VectorNeighb    int
@@ -0,0 +1,224 @@
+//    HipparchiaGoServer
+//    Copyright: E Gunderson 2022-24
+//    License: GNU GENERAL PUBLIC LICENSE 3
+//        (see LICENSE in the top level directory of the distribution)
+
+package str
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// the documentary corpora (in, dp, ch) store per-document information inside the lines themselves:
+// 	<hmu_metadata_provenance value="Attica" /><hmu_metadata_date value="c. 350-300 a." />...
+// DbDocument is the parsed and indexed version of that information; see mps.MapNewDocCorpus()
+
+var (
+	DocYear     = regexp.MustCompile(`(\d{1,4})(\s*/\s*(\d{1,4}))?`)
+	DocCentury  = regexp.MustCompile(`(^|[^a-z])([ivx]+)(\s*[/-]\s*([ivx]+))?($|[^a-z])`)
+	DocBCE      = regexp.MustCompile(`(\sa\.|\sa$|bce|b\.c|\sbc|a\.\s?chr|v\.\s?chr)`)
+	DocDay      = regexp.MustCompile(`\d{1,2}\s+(jan|feb|mar|apr|may|jun|jul|aug|sep|oct|nov|dec)[a-z]*\.?`)
+	DocCentMark = regexp.MustCompile(`(s\.|saec|cent|jh|\sc\.$)`)
+	DocPeriods  = map[string][2]int{
+		"arch":  {-700, -480},
+		"class": {-480, -323},
+		"hell":  {-323, -31},
+		"rom":   {-31, 300},
+		"imp":   {-31, 300},
+		"byz":   {300, 700},
+		"chr":   {300, 700},
+	}
+)
+
+type DbDocument struct {
+	WkUID     string
+	FirstLine int // the index of the line where the metadata was found
+	Date      string
+	Prov      string
+	City      string
+	Region    string
+	Earliest  int
+	Latest    int
+	Dated     bool // false if Earliest and Latest could not be parsed out of Date
+}
+
+// Places - all of the findspot information for this document
+func (d *DbDocument) Places() []string {
+	var pp []string
+	for _, p := range []string{d.Region, d.City, d.Prov} {
+		if p != "" {
+			pp = append(pp, p)
+		}
+	}
+	return pp
+}
+
+// Findspot - the findspot as a single string: "Attica, Athens"
+func (d *DbDocument) Findspot() string {
+	return strings.Join(d.Places(), ", ")
+}
+
+// HasPlace - is this document from the named region, city, or provenance?
+func (d *DbDocument) HasPlace(p string) bool {
+	for _, q := range d.Places() {
+		if q == p {
+			return true
+		}
+	}
+	return false
+}
+
+// Overlaps - does the document's date range intersect the range e to l?
+func (d *DbDocument) Overlaps(e int, l int) bool {
+	if !d.Dated {
+		return false
+	}
+	return d.Earliest <= l && d.Latest >= e
+}
+
+// Midpoint - a single number to sort by
+func (d *DbDocument) Midpoint() int {
+	return (d.Earliest + d.Latest) / 2
+}
+
+// Merge - fold a later metadata line from the same work into the document
+func (d *DbDocument) Merge(other DbDocument) {
+	fill := func(a *string, b string) {
+		if *a == "" {
+			*a = b
+		}
+	}
+	fill(&d.Date, other.Date)
+	fill(&d.Prov, other.Prov)
+	fill(&d.City, other.City)
+	fill(&d.Region, other.Region)
+
+	if other.Dated {
+		if !d.Dated {
+			d.Earliest = other.Earliest
+			d.Latest = other.Latest
+			d.Dated = true
+		} else {
+			d.Earliest = min(d.Earliest, other.Earliest)
+			d.Latest = max(d.Latest, other.Latest)
+		}
+	}
+}
+
+// ConvertDate - set Earliest and Latest by parsing Date
+func (d *DbDocument) ConvertDate() {
+	e, l, ok := ParseDocumentDate(d.Date)
+	d.Earliest = e
+	d.Latest = l
+	d.Dated = ok
+}
+
+// ParseDocumentDate - turn "c. 350-300 a.", "s. IV a.", "AD 117-138", "Hell.", etc. into a pair of years
+func ParseDocumentDate(ds string) (int, int, bool) {
+	// these strings are very irregular; this gets the common shapes and gives up on the rest:
+	//	"ca. 410-404 a." --> -410, -404
+	//	"326/5 a." --> -326, -325
+	//	"s. IV a." --> -400, -301
+	//	"II/III p." --> 101, 300
+	//	"Hell." --> -323, -31
+
+	ds = strings.ToLower(strings.TrimSpace(ds))
+	ds = MDFormat.ReplaceAllString(ds, "$1")
+	ds = DocDay.ReplaceAllString(ds, "")
+	if ds == "" {
+		return 0, 0, false
+	}
+
+	sign := 1
+	if DocBCE.MatchString(ds) {
+		sign = -1
+	}
+
+	var yy []int
+
+	// [a] arabic numerals
+	for _, m := range DocYear.FindAllStringSubmatch(ds, -1) {
+		a, _ := strconv.Atoi(m[1])
+		yy = append(yy, a)
+		if m[3] != "" {
+			// "326/5" is "326/325"
+			b := m[3]
+			if len(b) < len(m[1]) {
+				b = m[1][0:len(m[1])-len(b)] + b
+			}
+			bi, _ := strconv.Atoi(b)
+			yy = append(yy, bi)
+		}
+	}
+
+	isnumeric := len(yy) > 0 && !DocCentMark.MatchString(ds)
+
+	if isnumeric {
+		ee, ll := yy[0]*sign, yy[0]*sign
+		for _, y := range yy {
+			ee = min(ee, y*sign)
+			ll = max(ll, y*sign)
+		}
+		return ee, ll, true
+	}
+
+	// [b] centuries, either as roman numerals or as "4th cent."
+	var cc []int
+	if len(yy) > 0 {
+		cc = yy
+	} else {
+		// the era has already been read: drop it so that the "v" of "v. Chr." is not taken for a "V"
+		for _, m := range DocCentury.FindAllStringSubmatch(DocBCE.ReplaceAllString(ds, " "), -1) {
+			if c := romantoint(m[2]); c > 0 {
+				cc = append(cc, c)
+			}
+			if c := romantoint(m[4]); c > 0 {
+				cc = append(cc, c)
+			}
+		}
+	}
+
+	if len(cc) > 0 {
+		ee, ll := 9999, -9999
+		for _, c := range cc {
+			var s, f int
+			if sign < 0 {
+				s = -100 * c
+				f = -100*(c-1) - 1
+			} else {
+				s = 100*(c-1) + 1
+				f = 100 * c
+			}
+			ee = min(ee, s)
+			ll = max(ll, f)
+		}
+		return ee, ll, true
+	}
+
+	// [c] named periods
+	for k, v := range DocPeriods {
+		if strings.HasPrefix(ds, k) {
+			return v[0], v[1], true
+		}
+	}
+
+	return 0, 0, false
+}
+
+// romantoint - "iv" into 4; anything odd is 0
+func romantoint(r string) int {
+	vals := map[rune]int{'i': 1, 'v': 5, 'x': 10}
+	rr := []rune(r)
+	tot := 0
+	for i := 0; i < len(rr); i++ {
+		v := vals[rr[i]]
+		if i+1 < len(rr) && vals[rr[i+1]] > v {
+			tot -= v
+		} else {
+			tot += v
+		}
+	}
+	return tot
+}
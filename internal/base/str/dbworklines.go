@@ -157,6 +157,27 @@ func (dbw *DbWorkline) GatherMetadata() {
 	dbw.embnotes = md
 }
 
+// GatherDocument - parse the raw date and findspot metadata of a documentary text into a DbDocument
+func (dbw *DbWorkline) GatherDocument() DbDocument {
+	doc := DbDocument{WkUID: dbw.WkUID, FirstLine: dbw.TbIndex}
+	mm := Metadata.FindAllStringSubmatch(dbw.MarkedUp, -1)
+	for _, m := range mm {
+		v := strings.TrimSpace(MDFormat.ReplaceAllString(m[2], "$1"))
+		switch m[1] {
+		case "date":
+			doc.Date = v
+		case "provenance":
+			doc.Prov = v
+		case "city":
+			doc.City = v
+		case "region":
+			doc.Region = v
+		}
+	}
+	doc.ConvertDate()
+	return doc
+}
+
 // PurgeMetadata - delete the line Metadata
 func (dbw *DbWorkline) PurgeMetadata() {
 	if Metadata.MatchString(dbw.MarkedUp) {
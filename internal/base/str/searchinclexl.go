@@ -6,6 +6,7 @@
 package str
 
 import (
+	"maps"
 	"slices"
 )
 
@@ -15,6 +16,7 @@ type SearchIncExl struct {
 	WkGenres    []string
 	AuLocations []string
 	WkLocations []string
+	DcLocations []string // document findspots: see DbDocument.Places()
 	Authors     []string
 	Works       []string
 	Passages    []string // "lt0474_FROM_36136_TO_36151"
@@ -29,7 +31,7 @@ type SearchIncExl struct {
 }
 
 func (i *SearchIncExl) IsEmpty() bool {
-	l := len(i.AuGenres) + len(i.WkGenres) + len(i.AuLocations) + len(i.WkLocations) + len(i.Authors)
+	l := len(i.AuGenres) + len(i.WkGenres) + len(i.AuLocations) + len(i.WkLocations) + len(i.DcLocations) + len(i.Authors)
 	l += len(i.Works) + len(i.Passages)
 	if l > 0 {
 		return false
@@ -39,7 +41,7 @@ func (i *SearchIncExl) IsEmpty() bool {
 }
 
 func (i *SearchIncExl) CountItems() int {
-	l := len(i.AuGenres) + len(i.WkGenres) + len(i.AuLocations) + len(i.WkLocations) + len(i.Authors)
+	l := len(i.AuGenres) + len(i.WkGenres) + len(i.AuLocations) + len(i.WkLocations) + len(i.DcLocations) + len(i.Authors)
 	l += len(i.Works) + len(i.Passages)
 	return l
 }
@@ -53,3 +55,23 @@ func (i *SearchIncExl) BuildPsgByName() {
 	slices.Sort(nn)
 	i.ListedPBN = nn
 }
+
+// Clone - a copy of the SearchIncExl that shares nothing with the original
+func (i *SearchIncExl) Clone() SearchIncExl {
+	return SearchIncExl{
+		AuGenres:         slices.Clone(i.AuGenres),
+		WkGenres:         slices.Clone(i.WkGenres),
+		AuLocations:      slices.Clone(i.AuLocations),
+		WkLocations:      slices.Clone(i.WkLocations),
+		DcLocations:      slices.Clone(i.DcLocations),
+		Authors:          slices.Clone(i.Authors),
+		Works:            slices.Clone(i.Works),
+		Passages:         slices.Clone(i.Passages),
+		MappedPsgByName:  maps.Clone(i.MappedPsgByName),
+		MappedAuthByName: maps.Clone(i.MappedAuthByName),
+		MappedWkByName:   maps.Clone(i.MappedWkByName),
+		ListedPBN:        slices.Clone(i.ListedPBN),
+		ListedABN:        slices.Clone(i.ListedABN),
+		ListedWBN:        slices.Clone(i.ListedWBN),
+	}
+}
@@ -51,6 +51,8 @@ type SearchStruct struct {
 	OriginalLimit int
 	SkgSlice      []string // either just Seeking or a decomposed version of a Lemma's possibilities
 	PrxSlice      []string
+	WordList      []string // the items of an uploaded word list: these replace Seeking/LemmaOne when building SkgSlice
+	WordListHW    bool     // the WordList is a list of headwords and not of forms
 	SearchIn      SearchIncExl
 	SearchEx      SearchIncExl
 	Queries       []PrerolledQuery
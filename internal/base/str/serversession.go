@@ -13,16 +13,36 @@ type ServerSession struct {
 	ID           string
 	Inclusions   SearchIncExl
 	Exclusions   SearchIncExl
+	StoredIncl   SearchIncExl // a second selection to compare against the first: see RtSelectionStore()
+	StoredExcl   SearchIncExl
 	ActiveCorp   map[string]bool
 	VariaOK      bool   `json:"varia"`
 	IncertaOK    bool   `json:"incerta"`
 	SpuriaOK     bool   `json:"spuria"`
+	DocDates     bool   `json:"docdates"`
+	LemmaForms   bool   `json:"lemmaforms"`
+	Collocates   bool   `json:"collocates"`
+	KWIC         bool   `json:"kwic"`
+	KWICSort     string `json:"kwicsort"`
 	RawInput     bool   `json:"rawinputstyle"`
 	OneHit       bool   `json:"onehit"`
 	HeadwordIdx  bool   `json:"headwordindexing"`
 	FrqIdx       bool   `json:"indexbyfrequency"`
 	VocByCount   bool   `json:"vocbycount"`
 	VocScansion  bool   `json:"vocscansion"`
+	NgramSize    int    `json:"ngramsize"`
+	NgramFreq    int    `json:"ngramfreq"`
+	NgramLemma   bool   `json:"ngramlemma"`
+	NgramStops   bool   `json:"ngramstops"`
+	NgramLines   bool   `json:"ngramlines"`
+	NgramSents   bool   `json:"ngramsentences"`
+	ReuseSize    int    `json:"reusesize"`
+	ReuseLemma   bool   `json:"reuselemma"`
+	StyloSample  int    `json:"stylosample"`
+	StyloMFW     int    `json:"stylomfw"`
+	StyloGraph   string `json:"stylograph"`
+	KeyRef       string `json:"keyref"`
+	KeyLemma     bool   `json:"keylemma"`
 	NearOrNot    string `json:"nearornot"`
 	SearchScope  string `json:"searchscope"`
 	SortHitsBy   string `json:"sortorder"`
@@ -40,9 +60,12 @@ type ServerSession struct {
 	VecModeler   string
 	VecNeighbCt  int
 	VecNNSearch  bool
+	VecExpand    bool `json:"vecexpand"`
 	VecTextPrep  string
 	VecLDASearch bool
 	LDAgraph     bool
 	LDAtopics    int
 	LDA2D        bool
+	LDAModeler   string
+	LDAUnit      string
 }
@@ -13,7 +13,9 @@ import (
 	"github.com/e-gun/HipparchiaGoServer/internal/vv"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"maps"
 	"strings"
+	"sync"
 )
 
 // hipparchiaDB=# \d wordcounts_a
@@ -363,3 +365,285 @@ func FetchHeadwordCounts(headwordset map[string]bool) map[string]int {
 
 	return returnmap
 }
+
+// FetchHeadwordCorpusTotal - the sum of all of the headword counts: the size of the corpus as seen by the headwords
+func FetchHeadwordCorpusTotal() int {
+	const (
+		QT = `SELECT SUM(total_count) FROM dictionary_headword_wordcounts`
+	)
+
+	dbconn := GetDBConnection()
+	defer dbconn.Release()
+
+	var total int64
+	err := dbconn.QueryRow(context.Background(), QT).Scan(&total)
+	Msg.EC(err)
+
+	return int(total)
+}
+
+// corpuscountcolumns - "gr_count + lt_count" for the corpora in question; "total_count" if there are none
+func corpuscountcolumns(corpora []string) string {
+	valid := []string{"gr", "lt", "dp", "in", "ch"}
+
+	var cols []string
+	for _, c := range valid {
+		for _, k := range corpora {
+			if k == c {
+				cols = append(cols, c+"_count")
+			}
+		}
+	}
+
+	if len(cols) == 0 {
+		return "total_count"
+	}
+	return strings.Join(cols, " + ")
+}
+
+// FetchCorpusHeadwordCounts - map a list of headwords to their counts in the given corpora ("gr", "lt", ...)
+func FetchCorpusHeadwordCounts(headwords []string, corpora []string) map[string]int {
+	const (
+		TT = `CREATE TEMPORARY TABLE ttw_%s AS SELECT words AS w FROM unnest(ARRAY[%s]) words`
+		QT = `SELECT entry_name, %s FROM dictionary_headword_wordcounts WHERE EXISTS 
+				(SELECT 1 FROM ttw_%s temptable WHERE temptable.w = dictionary_headword_wordcounts.entry_name)`
+	)
+
+	countmap := make(map[string]int)
+	if len(headwords) == 0 {
+		return countmap
+	}
+
+	dbconn := GetDBConnection()
+	defer dbconn.Release()
+
+	var w string
+	var c int
+	foreach := []any{&w, &c}
+	rwfnc := func() error {
+		countmap[w] = c
+		return nil
+	}
+
+	u := strings.Replace(uuid.New().String(), "-", "", -1)
+	a := fmt.Sprintf("'%s'", strings.Join(headwords, "', '"))
+
+	_, err := dbconn.Exec(context.Background(), fmt.Sprintf(TT, u, a))
+	Msg.EC(err)
+
+	foundrows, e := dbconn.Query(context.Background(), fmt.Sprintf(QT, corpuscountcolumns(corpora), u))
+	Msg.EC(e)
+
+	_, ee := pgx.ForEachRow(foundrows, foreach, rwfnc)
+	Msg.EC(ee)
+
+	return countmap
+}
+
+// FetchCorpusFormCounts - map a list of word forms to their counts in the given corpora; the "wordcounts_" tables are by initial
+func FetchCorpusFormCounts(words []string, corpora []string) map[string]int {
+	const (
+		TT = `CREATE TEMPORARY TABLE ttw_%s AS SELECT values AS wordforms FROM unnest(ARRAY[%s]) values`
+		QT = `SELECT entry_name, %s FROM wordcounts_%s WHERE EXISTS 
+		(SELECT 1 FROM ttw_%s temptable WHERE temptable.wordforms = wordcounts_%s.entry_name)`
+	)
+
+	countmap := make(map[string]int)
+	if len(words) == 0 {
+		return countmap
+	}
+
+	// pgsql single quote escape: the apostrophe would need to be doubled, but the forms are stored without it anyway
+	byfirstlett := make(map[string][]string)
+	for _, w := range words {
+		init := gen.StripaccentsRUNE([]rune(w))
+		if len(init) == 0 {
+			continue
+		}
+		i := string(init[0])
+		if !strings.Contains(vv.WORDCOUNTINITIALS, i) {
+			i = "0"
+		}
+		byfirstlett[i] = append(byfirstlett[i], strings.Replace(w, "'", "", -1))
+	}
+
+	dbconn := GetDBConnection()
+	defer dbconn.Release()
+
+	var w string
+	var c int
+	foreach := []any{&w, &c}
+	rwfnc := func() error {
+		countmap[w] = c
+		return nil
+	}
+
+	cols := corpuscountcolumns(corpora)
+	for l := range byfirstlett {
+		u := strings.Replace(uuid.New().String(), "-", "", -1)
+		a := fmt.Sprintf("'%s'", strings.Join(byfirstlett[l], "', '"))
+
+		_, err := dbconn.Exec(context.Background(), fmt.Sprintf(TT, u, a))
+		Msg.EC(err)
+
+		foundrows, e := dbconn.Query(context.Background(), fmt.Sprintf(QT, cols, l, u, l))
+		Msg.EC(e)
+
+		_, ee := pgx.ForEachRow(foundrows, foreach, rwfnc)
+		Msg.EC(ee)
+	}
+
+	return countmap
+}
+
+// corpusvocabvault - the corpus vocabularies kept in memory: each is the same for every request that asks for it;
+// the oldest is dropped first
+type corpusvocabvault struct {
+	mutex sync.RWMutex
+	order []string
+	vocab map[string]map[string]int
+}
+
+var (
+	corpusvocabularies = corpusvocabvault{vocab: make(map[string]map[string]int)}
+)
+
+func (cv *corpusvocabvault) get(k string) (map[string]int, bool) {
+	cv.mutex.RLock()
+	defer cv.mutex.RUnlock()
+	v, ok := cv.vocab[k]
+	return v, ok
+}
+
+func (cv *corpusvocabvault) put(k string, v map[string]int) {
+	cv.mutex.Lock()
+	defer cv.mutex.Unlock()
+	if _, ok := cv.vocab[k]; !ok {
+		cv.order = append(cv.order, k)
+	}
+	cv.vocab[k] = v
+	for len(cv.order) > vv.KEYNESSVOCABCACHE {
+		delete(cv.vocab, cv.order[0])
+		cv.order = cv.order[1:]
+	}
+}
+
+// FetchCorpusVocabulary - every headword or word form seen at least "floor" times in the given corpora and its count;
+// the caller gets its own copy of the map
+func FetchCorpusVocabulary(corpora []string, headwords bool, floor int) map[string]int {
+	const (
+		HQT = `SELECT entry_name, %s AS c FROM dictionary_headword_wordcounts WHERE %s >= $1`
+		FQT = `SELECT entry_name, %s AS c FROM wordcounts_%s WHERE %s >= $1`
+	)
+
+	// corpuscountcolumns() only ever yields known column names: the floor is the only value that needs binding
+	cols := "(" + corpuscountcolumns(corpora) + ")"
+
+	key := fmt.Sprintf("%s_%t_%d", cols, headwords, floor)
+	if v, ok := corpusvocabularies.get(key); ok {
+		return maps.Clone(v)
+	}
+
+	countmap := make(map[string]int)
+
+	dbconn := GetDBConnection()
+	defer dbconn.Release()
+
+	var w string
+	var c int
+	foreach := []any{&w, &c}
+	rwfnc := func() error {
+		countmap[w] = c
+		return nil
+	}
+
+	var qq []string
+	if headwords {
+		qq = append(qq, fmt.Sprintf(HQT, cols, cols))
+	} else {
+		for _, l := range strings.Split(vv.WORDCOUNTINITIALS+"0", "") {
+			qq = append(qq, fmt.Sprintf(FQT, cols, l, cols))
+		}
+	}
+
+	for _, q := range qq {
+		foundrows, e := dbconn.Query(context.Background(), q, floor)
+		Msg.EC(e)
+
+		_, ee := pgx.ForEachRow(foundrows, foreach, rwfnc)
+		Msg.EC(ee)
+	}
+
+	corpusvocabularies.put(key, countmap)
+	return maps.Clone(countmap)
+}
+
+// FetchCorpusSize - the number of words in the given corpora as seen by the headwords or by the word forms
+func FetchCorpusSize(corpora []string, headwords bool) int {
+	const (
+		HQT = `SELECT COALESCE(SUM(%s), 0) FROM dictionary_headword_wordcounts`
+		FQT = `SELECT COALESCE(SUM(%s), 0) FROM wordcounts_%s`
+	)
+
+	dbconn := GetDBConnection()
+	defer dbconn.Release()
+
+	cols := corpuscountcolumns(corpora)
+
+	if headwords {
+		var total int64
+		err := dbconn.QueryRow(context.Background(), fmt.Sprintf(HQT, cols)).Scan(&total)
+		Msg.EC(err)
+		return int(total)
+	}
+
+	var total int64
+	for _, l := range strings.Split(vv.WORDCOUNTINITIALS+"0", "") {
+		var t int64
+		err := dbconn.QueryRow(context.Background(), fmt.Sprintf(FQT, cols, l)).Scan(&t)
+		Msg.EC(err)
+		total += t
+	}
+
+	return int(total)
+}
+
+// FetchHeadwordCorpusRanks - map a list of headwords to their frequency rank in the given corpora: 1 is the most common
+func FetchHeadwordCorpusRanks(headwords []string, corpora []string) map[string]int {
+	const (
+		TT = `CREATE TEMPORARY TABLE ttw_%s AS SELECT words AS w FROM unnest(ARRAY[%s]) words`
+		QT = `SELECT entry_name, r FROM 
+				(SELECT entry_name, RANK() OVER (ORDER BY (%s) DESC) AS r FROM dictionary_headword_wordcounts) ranked 
+			WHERE EXISTS (SELECT 1 FROM ttw_%s temptable WHERE temptable.w = ranked.entry_name)`
+	)
+
+	rankmap := make(map[string]int)
+	if len(headwords) == 0 {
+		return rankmap
+	}
+
+	dbconn := GetDBConnection()
+	defer dbconn.Release()
+
+	var w string
+	var r int64
+	foreach := []any{&w, &r}
+	rwfnc := func() error {
+		rankmap[w] = int(r)
+		return nil
+	}
+
+	u := strings.Replace(uuid.New().String(), "-", "", -1)
+	a := fmt.Sprintf("'%s'", strings.Join(headwords, "', '"))
+
+	_, err := dbconn.Exec(context.Background(), fmt.Sprintf(TT, u, a))
+	Msg.EC(err)
+
+	foundrows, e := dbconn.Query(context.Background(), fmt.Sprintf(QT, corpuscountcolumns(corpora), u))
+	Msg.EC(e)
+
+	_, ee := pgx.ForEachRow(foundrows, foreach, rwfnc)
+	Msg.EC(ee)
+
+	return rankmap
+}
@@ -254,7 +254,7 @@ func selftestsuite() {
 
 	// glove seizes scads of memory and never releases it; need to fix wego, though, it seems
 	vmod := []string{"w2v", "lexvec", "glove"}
-	vtxp := []string{"winner", "unparsed", "yoked", "montecarlo"}
+	vtxp := []string{"winner", "unparsed", "yoked", "montecarlo", "contextual"}
 	vauu := []string{"gr0011"} // sophocles
 
 	// fnc for [iv.3]
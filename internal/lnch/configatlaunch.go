@@ -135,6 +135,8 @@ func ConfigAtLaunch() {
 			"maxtotscrh": Config.MaxSrchTot,
 			"port":       Config.HostPort,
 			"projurl":    vv.PROJURL,
+			"vcapmb":     Config.VectorCapMB,
+			"vcapmodels": Config.VectorCapModels,
 			"vmodel":     Config.VectorModel,
 			"workers":    Config.WorkerCount,
 			"knownfnts":  strings.Join(kff, "C0, C3"),
@@ -236,6 +238,18 @@ func ConfigAtLaunch() {
 			Config.TickerActive = true
 		case "-ui":
 			Config.BadChars = args[i+1]
+		case "-vd":
+			Config.VectorDelete = append(Config.VectorDelete, args[i+1])
+		case "-vl":
+			Config.VectorList = true
+		case "-vm":
+			vm, err := strconv.Atoi(args[i+1])
+			Msg.EC(err)
+			Config.VectorCapModels = vm
+		case "-vs":
+			vs, err := strconv.Atoi(args[i+1])
+			Msg.EC(err)
+			Config.VectorCapMB = vs
 		case "-wc":
 			wc, err := strconv.Atoi(args[i+1])
 			Msg.EC(err)
@@ -296,8 +310,12 @@ func BuildDefaultConfig() *str.CurrentConfiguration {
 	c.SelfTest = 0
 	c.TickerActive = vv.TICKERISACTIVE
 	c.VectorBot = false
+	c.VectorCapMB = vv.VECTORCAPMB
+	c.VectorCapModels = vv.VECTORCAPMODELS
 	c.VectorChtHt = vv.DEFAULTCHRTHEIGHT
 	c.VectorChtWd = vv.DEFAULTCHRTWIDTH
+	c.VectorDelete = []string{}
+	c.VectorList = false
 	c.VectorMaxlines = vv.VECTORMAXLINES
 	c.VectorModel = vv.VECTORMODELDEFAULT
 	c.VectorNeighb = vv.VECTORNEIGHBORS
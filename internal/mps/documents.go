@@ -0,0 +1,127 @@
+//    HipparchiaGoServer
+//    Copyright: E Gunderson 2022-24
+//    License: GNU GENERAL PUBLIC LICENSE 3
+//        (see LICENSE in the top level directory of the distribution)
+
+package mps
+
+import (
+	"context"
+	"fmt"
+	"github.com/e-gun/HipparchiaGoServer/internal/base/gen"
+	"github.com/e-gun/HipparchiaGoServer/internal/base/str"
+	"github.com/e-gun/HipparchiaGoServer/internal/db"
+	"github.com/e-gun/HipparchiaGoServer/internal/lnch"
+	"github.com/e-gun/HipparchiaGoServer/internal/vv"
+	"github.com/jackc/pgx/v5"
+	"slices"
+)
+
+// ActiveDocumentMapper - index the document dates and findspots of the *active* documentary corpora; keyed to the workUID
+func ActiveDocumentMapper() map[string]*str.DbDocument {
+	// only the in, dp, and ch corpora have documents; see the comments at top of ActiveWorkMapper()
+	docmap := make(map[string]*str.DbDocument)
+	for k, b := range lnch.Config.DefCorp {
+		if b {
+			docmap = MapNewDocCorpus(k, docmap)
+		}
+	}
+	return docmap
+}
+
+// MapNewDocCorpus - add a corpus to a document map; requires that AllWorks already hold the corpus
+func MapNewDocCorpus(corpus string, docmap map[string]*str.DbDocument) map[string]*str.DbDocument {
+	const (
+		MSG = "MapNewDocCorpus() indexed %d documents from '%s'"
+	)
+
+	if !slices.Contains([]string{vv.INSCRIPTCORP, vv.PAPYRUSCORP, vv.CHRISTINSC}, corpus) {
+		return docmap
+	}
+
+	toadd := slicedoccorpus(corpus)
+	for i := 0; i < len(toadd); i++ {
+		d := toadd[i]
+		docmap[d.WkUID] = &d
+	}
+
+	Msg.PEEK(fmt.Sprintf(MSG, len(toadd), corpus))
+	return docmap
+}
+
+// slicedoccorpus - read the hmu_metadata out of every author table in a documentary corpus
+func slicedoccorpus(corpus string) []str.DbDocument {
+	// hipparchiaDB=# SELECT index, marked_up_line FROM in0c17 WHERE marked_up_line ~ 'hmu_metadata' LIMIT 1;
+	// index |	marked_up_line
+	//-------+-----------------
+	//     1 | <hmu_metadata_provenance value="Thasos" /><hmu_metadata_date value="s. IV a." /><hmu_metadata_documentnumber value="1" />...
+
+	const (
+		QT = `SELECT wkuniversalid, index, marked_up_line FROM %s WHERE marked_up_line ~ '<hmu_metadata_(date|provenance|city|region)' ORDER BY index`
+	)
+
+	var tables []string
+	for _, w := range AllWorks {
+		if w.UID[0:2] == corpus {
+			tables = append(tables, w.AuID())
+		}
+	}
+	tables = gen.Unique(tables)
+
+	dbconn := db.GetDBConnection()
+	defer dbconn.Release()
+
+	docs := make(map[string]str.DbDocument)
+	var order []string
+
+	var l str.DbWorkline
+	foreach := []any{&l.WkUID, &l.TbIndex, &l.MarkedUp}
+
+	rwfnc := func() error {
+		d := l.GatherDocument()
+		if _, ok := docs[d.WkUID]; !ok {
+			docs[d.WkUID] = d
+			order = append(order, d.WkUID)
+		} else {
+			// some documents carry more than one metadata line: widen the first one
+			first := docs[d.WkUID]
+			first.Merge(d)
+			docs[d.WkUID] = first
+		}
+		return nil
+	}
+
+	for _, t := range tables {
+		foundrows, err := dbconn.Query(context.Background(), fmt.Sprintf(QT, t))
+		Msg.EC(err)
+		_, e := pgx.ForEachRow(foundrows, foreach, rwfnc)
+		Msg.EC(e)
+	}
+
+	docslice := make([]str.DbDocument, len(order))
+	for i, k := range order {
+		d := docs[k]
+		if !d.Dated {
+			// fall back on the date assigned to the work
+			if w, ok := AllWorks[k]; ok && w.ConvDate != vv.INCERTADATE && w.ConvDate != vv.VARIADATE {
+				d.Earliest = w.ConvDate
+				d.Latest = w.ConvDate
+				d.Dated = true
+			}
+		}
+		docslice[i] = d
+	}
+
+	return docslice
+}
+
+// Builddoclocationmap - populate global variable used by hinter
+func Builddoclocationmap() map[string]bool {
+	locations := make(map[string]bool)
+	for _, d := range AllDocs {
+		for _, p := range d.Places() {
+			locations[p] = true
+		}
+	}
+	return locations
+}
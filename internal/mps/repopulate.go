@@ -11,7 +11,8 @@ import (
 
 var (
 	AllWorks    = make(map[string]*str.DbWork)
-	AllAuthors  = make(map[string]*str.DbAuthor) // populated by authormap.go
+	AllAuthors  = make(map[string]*str.DbAuthor)   // populated by authormap.go
+	AllDocs     = make(map[string]*str.DbDocument) // populated by documents.go
 	AllLemm     = make(map[string]*str.DbLemma)
 	NestedLemm  = make(map[string]map[string]*str.DbLemma)
 	WkCorpusMap = make(map[string][]string)
@@ -20,6 +21,7 @@ var (
 	WkGenres    = make(map[string]bool)
 	AuLocs      = make(map[string]bool)
 	WkLocs      = make(map[string]bool)
+	DocLocs     = make(map[string]bool)
 	LoadedCorp  = make(map[string]bool)
 )
 
@@ -31,4 +33,5 @@ func RePopulateGlobalMaps() {
 	WkGenres = Buildwkgenresmap()
 	AuLocs = Buildaulocationmap()
 	WkLocs = Buildwklocationmap()
+	DocLocs = Builddoclocationmap()
 }
@@ -86,6 +86,15 @@ func SessionIntoSearchlist(s str.ServerSession) ProcessedList {
 			}
 		}
 
+		// [b4a] document findspots to include (in, dp, ch only)
+		for _, l := range sessincl.DcLocations {
+			for _, w := range activeworks {
+				if d, ok := mps.AllDocs[w]; ok && d.HasPlace(l) {
+					inc.Works = append(inc.Works, w)
+				}
+			}
+		}
+
 		// 		a tricky spot: when/how to apply prunebydate()
 		//		if you want to be able to seek 5th BCE oratory and Plutarch, then you need to let auselections take precedence
 		//		accordingly we will do classes and genres first, then trim by date, then add inc individual choices
@@ -198,6 +207,15 @@ func SessionIntoSearchlist(s str.ServerSession) ProcessedList {
 			}
 		}
 
+		// [c2h] works excluded by document findspot
+		for _, l := range sessexl.DcLocations {
+			for _, w := range activeworks {
+				if d, ok := mps.AllDocs[w]; ok && d.HasPlace(l) {
+					exc.Works = append(exc.Works, w)
+				}
+			}
+		}
+
 		inc.Works = gen.SetSubtraction(inc.Works, exc.Works)
 	}
 
@@ -258,9 +276,28 @@ func prunebydate(searchlist []string, s str.ServerSession) []string {
 		e = l
 	}
 
+	// if the session wants document dates, the in, dp, and ch texts are judged by their own metadata and not by
+	// the work and author dates; documents without a usable date fall through to the old logic
+	docdated := func(uid string) (*str.DbDocument, bool) {
+		if !s.DocDates {
+			return nil, false
+		}
+		d, ok := mps.AllDocs[uid]
+		if !ok || !d.Dated {
+			return nil, false
+		}
+		return d, true
+	}
+
 	// [b5a] first prune the bad dates; nb: the inscriptions have lots of work dates; the gl and lt works don't
 	var trimmed []string
 	for _, uid := range searchlist {
+		if d, ok := docdated(uid); ok {
+			if d.Overlaps(e, l) {
+				trimmed = append(trimmed, uid)
+			}
+			continue
+		}
 		cda := mps.AllAuthors[mps.AllWorks[uid].AuID()].ConvDate
 		cdb := mps.AllWorks[uid].ConvDate
 		if (cda >= e && cda <= l) || (cdb >= e && cdb <= l) {
@@ -271,6 +308,9 @@ func prunebydate(searchlist []string, s str.ServerSession) []string {
 	// [b5b] add back in any varia and/or incerta as needed
 	if s.VariaOK {
 		for _, uid := range searchlist {
+			if _, ok := docdated(uid); ok {
+				continue
+			}
 			cda := mps.AllAuthors[mps.AllWorks[uid].AuID()].ConvDate
 			cdb := mps.AllWorks[uid].ConvDate
 			if (cda == vv.INCERTADATE || cda == vv.VARIADATE) && cdb == vv.VARIADATE {
@@ -281,6 +321,9 @@ func prunebydate(searchlist []string, s str.ServerSession) []string {
 
 	if s.IncertaOK {
 		for _, uid := range searchlist {
+			if _, ok := docdated(uid); ok {
+				continue
+			}
 			cda := mps.AllAuthors[mps.AllWorks[uid].AuID()].ConvDate
 			cdb := mps.AllWorks[uid].ConvDate
 			if (cda == vv.INCERTADATE || cda == vv.VARIADATE) && cdb == vv.INCERTADATE {
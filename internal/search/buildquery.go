@@ -74,7 +74,9 @@ func SSBuildQueries(s *str.SearchStruct) {
 	inc := s.SearchIn
 	exc := s.SearchEx
 
-	if len(s.LemmaOne) != 0 {
+	if len(s.WordList) != 0 {
+		s.SkgSlice = WordListIntoRegexSlice(s.WordList, s.WordListHW)
+	} else if len(s.LemmaOne) != 0 {
 		s.SkgSlice = LemmaIntoRegexSlice(s.LemmaOne)
 	} else {
 		s.SkgSlice = append(s.SkgSlice, s.Seeking)
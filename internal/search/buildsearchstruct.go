@@ -13,6 +13,7 @@ import (
 	"github.com/e-gun/HipparchiaGoServer/internal/vv"
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -246,7 +247,43 @@ func InsertNewContextIntoSS(ss *str.SearchStruct) {
 func SessionIntoBulkSearch(c echo.Context, lim int) str.SearchStruct {
 	user := vlt.ReadUUIDCookie(c)
 	sess := vlt.AllSessions.GetSess(user)
+	return selectionintobulksearch(c, sess, lim)
+}
+
+// StoredSelectionIntoBulkSearch - SessionIntoBulkSearch() for the selection that was set aside via RtSelectionStore()
+func StoredSelectionIntoBulkSearch(c echo.Context, lim int) str.SearchStruct {
+	user := vlt.ReadUUIDCookie(c)
+	sess := vlt.AllSessions.GetSess(user)
+	sess.Inclusions = sess.StoredIncl
+	sess.Exclusions = sess.StoredExcl
+	return selectionintobulksearch(c, sess, lim)
+}
+
+// DatedSelectionIntoBulkSearch - SessionIntoBulkSearch() for a session from DatedSession()
+func DatedSelectionIntoBulkSearch(c echo.Context, dated str.ServerSession, lim int) str.SearchStruct {
+	return selectionintobulksearch(c, dated, lim)
+}
+
+// DatedSession - a copy of the session that only admits the dated items between two dates that also fall inside the
+// session's own date range: no varia, no incerta; false if the two ranges do not meet
+func DatedSession(sess str.ServerSession, earliest int, latest int) (str.ServerSession, bool) {
+	// the session's dates have been validated on the way in; anything else leaves the period as it is
+	if e, err := strconv.Atoi(sess.Earliest); err == nil {
+		earliest = max(earliest, e)
+	}
+	if l, err := strconv.Atoi(sess.Latest); err == nil {
+		latest = min(latest, l)
+	}
+
+	sess.Earliest = strconv.Itoa(earliest)
+	sess.Latest = strconv.Itoa(latest)
+	sess.VariaOK = false
+	sess.IncertaOK = false
+	return sess, earliest <= latest
+}
 
+// selectionintobulksearch - grab every line of text in the selection recorded in the session
+func selectionintobulksearch(c echo.Context, sess str.ServerSession, lim int) str.SearchStruct {
 	ss := BuildDefaultSearch(c)
 	ss.Seeking = ""
 	ss.Proximate = ""
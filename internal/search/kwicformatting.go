@@ -0,0 +1,194 @@
+//    HipparchiaGoServer
+//    Copyright: E Gunderson 2022-24
+//    License: GNU GENERAL PUBLIC LICENSE 3
+//        (see LICENSE in the top level directory of the distribution)
+
+package search
+
+import (
+	"fmt"
+	"github.com/e-gun/HipparchiaGoServer/internal/base/gen"
+	"github.com/e-gun/HipparchiaGoServer/internal/base/str"
+	"github.com/e-gun/HipparchiaGoServer/internal/lnch"
+	"github.com/e-gun/HipparchiaGoServer/internal/vv"
+	"sort"
+	"strings"
+)
+
+// kwicrow - one line of a concordance: the node and the words on either side of it
+type kwicrow struct {
+	Hit   int
+	Line  str.DbWorkline
+	Left  []string
+	Node  []string
+	Right []string
+}
+
+// sortkey - the word at L1, L2, R1, or R2 (or "" if there is no such word)
+func (k kwicrow) sortkey(pos string) string {
+	var w string
+	switch pos {
+	case "l1":
+		if len(k.Left) > 0 {
+			w = k.Left[len(k.Left)-1]
+		}
+	case "l2":
+		if len(k.Left) > 1 {
+			w = k.Left[len(k.Left)-2]
+		}
+	case "r1":
+		if len(k.Right) > 0 {
+			w = k.Right[0]
+		}
+	case "r2":
+		if len(k.Right) > 1 {
+			w = k.Right[1]
+		}
+	}
+	return gen.StripaccentsSTR(w)
+}
+
+// FormatKWICResults - build a keyword-in-context concordance: the hit in the middle and vv.KWICWORDS words on either side
+func FormatKWICResults(ss *str.SearchStruct) str.SearchOutputJSON {
+	// EXAMPLE
+	// [1] Cicero, Pro Caecina: 17.1 | ... quae cum ita sint | dolor | est enim quasi ... |
+
+	const (
+		TABLEROW = `
+		<tr class="%s">
+			<td>
+				<span class="findnumber">[%d]</span>&nbsp;%s%s
+				<browser id="%s"><span class="foundauthor">%s</span>,&nbsp;<span class="foundwork">%s</span>: <span class="foundlocus">%s</span></browser>
+			</td>
+			<td class="kwicleft"><span class="foundtext">%s</span></td>
+			<td class="kwicnode"><span class="match">%s</span></td>
+			<td class="kwicright"><span class="foundtext">%s</span></td>
+		</tr>`
+		DATES  = `[<span class="date">%s</span>]`
+		SORTED = `<br>Concordance sorted by %s`
+	)
+
+	sortnames := map[string]string{
+		"hit": "the order of the results",
+		"l1":  "the first word to the left",
+		"l2":  "the second word to the left",
+		"r1":  "the first word to the right",
+		"r2":  "the second word to the right",
+	}
+
+	rows := kwicrows(ss)
+
+	so := ss.StoredSession.KWICSort
+	if _, ok := sortnames[so]; !ok {
+		so = vv.KWICSORT
+	}
+
+	if so != "hit" {
+		sort.SliceStable(rows, func(i, j int) bool { return rows[i].sortkey(so) < rows[j].sortkey(so) })
+	}
+
+	var b strings.Builder
+	for i, r := range rows {
+		rc := "regular"
+		if i%3 == 2 {
+			rc = "nthrow"
+		}
+		b.WriteString(fmt.Sprintf(TABLEROW, rc, r.Hit, FormatInscriptionDates(DATES, &r.Line), formatinscriptionplaces(&r.Line),
+			r.Line.BuildHyperlink(), DbWlnMyAu(&r.Line).Shortname, DbWlnMyWk(&r.Line).Title, strings.Join(r.Line.FindLocus(), "."),
+			strings.Join(r.Left, " "), strings.Join(r.Node, " "), strings.Join(r.Right, " ")))
+	}
+
+	var out str.SearchOutputJSON
+	out.JS = fmt.Sprintf(vv.BROWSERJS, "browser")
+	out.Title = RestoreWhiteSpace(ss.Seeking)
+	out.Image = ""
+	out.Searchsummary = formatfinalsearchsummary(ss) + fmt.Sprintf(SORTED, sortnames[so])
+	if fb := FormatLemmaFormBreakdown(ss); fb != "" {
+		out.Searchsummary += fb
+		out.JS += vv.LEMMAFORMJS
+	}
+
+	out.Found = "<tbody>" + b.String() + "</tbody>"
+	if lnch.Config.ZapLunates {
+		out.Found = gen.DeLunate(out.Found)
+	}
+
+	return out
+}
+
+// kwicrows - assemble the context for every instance of the node in every hit; the context runs across line breaks
+func kwicrows(ss *str.SearchStruct) []kwicrow {
+	// grab the neighborhood of each hit just as WithinXWordsSearch() does
+	second := CloneSearch(ss, 2)
+	second.Seeking = ""
+	second.LemmaOne = ""
+	second.Proximate = ""
+	second.LemmaTwo = ""
+	second.NotNear = false
+	second.ProxDist = vv.KWICWORDS
+	second.SetType()
+
+	bundles := XWordsNeighborhoods(ss, &second)
+
+	isnode := NodeFinder(ss)
+
+	// a phrase is a multi-word node
+	nodelen := 1
+	if ss.LemmaOne == "" {
+		nodelen = max(1, len(strings.Fields(RestoreWhiteSpace(ss.Seeking))))
+	}
+
+	var rows []kwicrow
+	for k := 0; k < ss.Results.Len(); k++ {
+		hit := ss.Results.Lines[k]
+		lines, ok := bundles[k]
+		if !ok {
+			lines = []str.DbWorkline{hit}
+		}
+
+		var toks []string
+		var inhit []bool
+		for _, l := range lines {
+			for _, t := range l.AccentedSlice() {
+				if t == "" {
+					continue
+				}
+				toks = append(toks, t)
+				inhit = append(inhit, l.TbIndex == hit.TbIndex && l.WkUID == hit.WkUID)
+			}
+		}
+
+		var starts []int
+		for i := range toks {
+			if inhit[i] && isnode(toks[i]) {
+				starts = append(starts, i)
+			}
+		}
+
+		nl := nodelen
+		if len(starts) == 0 {
+			// e.g., the find was in the hyphenated part of the line: the whole line becomes the node
+			nl = 0
+			for i := range toks {
+				if inhit[i] {
+					if nl == 0 {
+						starts = append(starts, i)
+					}
+					nl++
+				}
+			}
+		}
+
+		for _, i := range starts {
+			end := min(len(toks), i+nl)
+			rows = append(rows, kwicrow{
+				Hit:   k + 1,
+				Line:  hit,
+				Left:  toks[max(0, i-vv.KWICWORDS):i],
+				Node:  toks[i:end],
+				Right: toks[end:min(len(toks), end+vv.KWICWORDS)],
+			})
+		}
+	}
+	return rows
+}
@@ -0,0 +1,108 @@
+//    HipparchiaGoServer
+//    Copyright: E Gunderson 2022-24
+//    License: GNU GENERAL PUBLIC LICENSE 3
+//        (see LICENSE in the top level directory of the distribution)
+
+package search
+
+import (
+	"fmt"
+	"github.com/e-gun/HipparchiaGoServer/internal/base/gen"
+	"github.com/e-gun/HipparchiaGoServer/internal/base/str"
+	"github.com/e-gun/HipparchiaGoServer/internal/mps"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"sort"
+	"strings"
+)
+
+// FormatLemmaFormBreakdown - which forms of a lemma turned up in the results and how often; "" if not relevant
+func FormatLemmaFormBreakdown(ss *str.SearchStruct) string {
+	// e.g., Sought all 45 forms of »ἄνθρωποϲ«
+	//	ἄνθρωποϲ	112
+	//	ἀνθρώπων	97
+	//	...
+	// 	not found: ἀνθρώποιιν, ...
+
+	const (
+		TABLE = `
+		<br>
+		<table class="vectortable"><tbody>
+		<tr class="vectorrow"><th>form of »%s«</th><th>count</th></tr>
+		%s
+		</tbody></table>
+		%s`
+		ROW     = `<tr class="%s"><td class="vectorword"><lemmaform id="%s">%s</lemmaform></td><td class="vectorscore">%d</td></tr>`
+		CAPPED  = `<span class="small">(counts reflect only the %d passages retrieved)</span><br>`
+		MISSING = `<span class="small">%d of %d forms not found: %s</span>`
+	)
+
+	if !ss.StoredSession.LemmaForms || ss.LemmaOne == "" || ss.Twobox {
+		return ""
+	}
+
+	if _, ok := mps.AllLemm[ss.LemmaOne]; !ok {
+		return ""
+	}
+
+	counts := CountLemmaForms(ss)
+
+	m := message.NewPrinter(language.English)
+
+	var found []string
+	var missing []string
+	for _, f := range mps.AllLemm[ss.LemmaOne].Deriv {
+		if counts[f] > 0 {
+			found = append(found, f)
+		} else {
+			missing = append(missing, f)
+		}
+	}
+
+	sort.SliceStable(found, func(i, j int) bool { return counts[found[i]] > counts[found[j]] })
+	sort.Strings(missing)
+
+	var rows strings.Builder
+	for i, f := range found {
+		rc := "vectorrow"
+		if i%3 == 2 {
+			rc = "nthrow"
+		}
+		rows.WriteString(m.Sprintf(ROW, rc, f, f, counts[f]))
+	}
+
+	var notes string
+	if ss.Results.Len() == ss.CurrentLimit {
+		notes = fmt.Sprintf(CAPPED, ss.Results.Len())
+	}
+	if len(missing) > 0 {
+		notes += fmt.Sprintf(MISSING, len(missing), len(found)+len(missing), strings.Join(missing, ", "))
+	}
+
+	return fmt.Sprintf(TABLE, ss.LemmaOne, rows.String(), notes)
+}
+
+// CountLemmaForms - form: count for each of the Deriv forms of ss.LemmaOne that appears in the results
+func CountLemmaForms(ss *str.SearchStruct) map[string]int {
+	// the results come from "stripped_line" or "accented_line" (see SetType()); the lines might have a grave
+	// where the lemma has an acute, etc.: so normalize both sides before comparing
+
+	normalize := func(w string) string {
+		return gen.SwapAcuteForGrave(gen.UVσςϲ(strings.ToLower(w)))
+	}
+
+	formmap := make(map[string]string)
+	for _, f := range mps.AllLemm[ss.LemmaOne].Deriv {
+		formmap[normalize(f)] = f
+	}
+
+	counts := make(map[string]int)
+	for i := 0; i < ss.Results.Len(); i++ {
+		for _, w := range strings.Split(ColumnPicker(ss.SrchColumn, ss.Results.Lines[i]), " ") {
+			if f, ok := formmap[normalize(w)]; ok {
+				counts[f]++
+			}
+		}
+	}
+	return counts
+}
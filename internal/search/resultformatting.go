@@ -126,6 +126,10 @@ func FormatNoContextResults(ss *str.SearchStruct) str.SearchOutputJSON {
 	out.Title = ss.Seeking
 	out.Image = ""
 	out.Searchsummary = formatfinalsearchsummary(ss)
+	if fb := FormatLemmaFormBreakdown(ss); fb != "" {
+		out.Searchsummary += fb
+		out.JS += vv.LEMMAFORMJS
+	}
 
 	out.Found = "<tbody>" + b.String() + "</tbody>"
 	if lnch.Config.ZapLunates {
@@ -336,6 +340,10 @@ func FormatWithContextResults(thesearch *str.SearchStruct) str.SearchOutputJSON
 	out.Title = RestoreWhiteSpace(thesearch.Seeking)
 	out.Image = ""
 	out.Searchsummary = formatfinalsearchsummary(thesearch)
+	if fb := FormatLemmaFormBreakdown(thesearch); fb != "" {
+		out.Searchsummary += fb
+		out.JS += vv.LEMMAFORMJS
+	}
 	out.Found = b.String()
 
 	if lnch.Config.ZapLunates {
@@ -371,12 +379,13 @@ func formatfinalsearchsummary(s *str.SearchStruct) string {
 		%s
 		%s
 	`
-		BETW   = "Searched between %s and %s<br>"
-		DDM    = "<!-- dates did not matter -->"
-		NOCAP  = "<!-- did not hit the results cap -->"
-		YESCAP = `<span class="smallerthannormal">[Search suspended: result cap reached.]</span>`
-		INFAU  = "<!-- unlimited hits per author -->"
-		ONEAU  = `<br><span class="smaller">(only one hit allowed per author table)</span>`
+		BETW    = "Searched between %s and %s<br>"
+		DOCBETW = "Searched between %s and %s (documents dated by their own metadata)<br>"
+		DDM     = "<!-- dates did not matter -->"
+		NOCAP   = "<!-- did not hit the results cap -->"
+		YESCAP  = `<span class="smallerthannormal">[Search suspended: result cap reached.]</span>`
+		INFAU   = "<!-- unlimited hits per author -->"
+		ONEAU   = `<br><span class="smaller">(only one hit allowed per author table)</span>`
 	)
 
 	m := message.NewPrinter(language.English)
@@ -386,6 +395,9 @@ func formatfinalsearchsummary(s *str.SearchStruct) string {
 		a := gen.FormatBCEDate(sess.Earliest)
 		b := gen.FormatBCEDate(sess.Latest)
 		dr = fmt.Sprintf(BETW, a, b)
+		if sess.DocDates {
+			dr = fmt.Sprintf(DOCBETW, a, b)
+		}
 	} else {
 		dr = DDM
 	}
@@ -413,6 +425,10 @@ func formatfinalsearchsummary(s *str.SearchStruct) string {
 		so = "work location"
 	case "universalid":
 		so = "ID"
+	case "document_date":
+		so = "document date"
+	case "findspot":
+		so = "document findspot"
 	}
 
 	el := fmt.Sprintf("%.2f", time.Now().Sub(s.Launched).Seconds())
@@ -447,6 +463,9 @@ func FormatInscriptionDates(template string, dbw *str.DbWorkline) string {
 		if cd == "2500 C.E." {
 			cd = "??? BCE/CE"
 		}
+		if d, ok := mps.AllDocs[dbw.WkUID]; ok && d.Dated && d.Earliest != d.Latest {
+			cd = gen.IntToBCE(d.Earliest) + " - " + gen.IntToBCE(d.Latest)
+		}
 		datestring = fmt.Sprintf(template, strings.Replace(cd, ".", "", -1))
 	}
 	return datestring
@@ -462,7 +481,11 @@ func formatinscriptionplaces(dbw *str.DbWorkline) string {
 	fc := dbw.FindCorpus()
 	placed := fc == vv.INSCRIPTCORP || fc == vv.CHRISTINSC || fc == vv.PAPYRUSCORP
 	if placed {
-		placestring = fmt.Sprintf(PLACER, mps.AllWorks[dbw.WkUID].Prov)
+		pl := mps.AllWorks[dbw.WkUID].Prov
+		if d, ok := mps.AllDocs[dbw.WkUID]; ok && d.Findspot() != "" {
+			pl = d.Findspot()
+		}
+		placestring = fmt.Sprintf(PLACER, pl)
 	}
 	return placestring
 }
@@ -675,6 +698,8 @@ func gethighlighter(ss *str.SearchStruct) *regexp.Regexp {
 		re = SearchTermFinder(prx)
 	} else if len(ss.LemmaTwo) != 0 {
 		re = lemmahighlighter(ss.LemmaTwo)
+	} else if len(ss.WordList) != 0 {
+		re = wordlisthighlighter(ss)
 	} else {
 		// FAIL = "gethighlighter() cannot find anything to highlight\n\t%ss"
 		// mm(fmt.Sprintf(FAIL, ss.InitSum), MSGFYI)
@@ -7,6 +7,7 @@ package search
 
 import (
 	"github.com/e-gun/HipparchiaGoServer/internal/base/str"
+	"github.com/e-gun/HipparchiaGoServer/internal/mps"
 	"sort"
 )
 
@@ -112,6 +113,32 @@ func SortResults(s *str.SearchStruct) {
 		return DbWlnMyWk(one).Prov < DbWlnMyWk(two).Prov
 	}
 
+	// documentary texts can be sorted by their own dates and findspots; everything else falls back to the work
+	docdate := func(l *str.DbWorkline) int {
+		if d, ok := mps.AllDocs[l.WkUID]; ok && d.Dated {
+			return d.Earliest
+		}
+		if DbWlnMyWk(l).RecDate != NULL {
+			return DbWlnMyWk(l).ConvDate
+		}
+		return DbWlnMyAu(l).ConvDate
+	}
+
+	docDateIncreasing := func(one, two *str.DbWorkline) bool {
+		return docdate(one) < docdate(two)
+	}
+
+	docfindspot := func(l *str.DbWorkline) string {
+		if d, ok := mps.AllDocs[l.WkUID]; ok && d.Findspot() != "" {
+			return d.Findspot()
+		}
+		return DbWlnMyWk(l).Prov
+	}
+
+	increasingFindspot := func(one, two *str.DbWorkline) bool {
+		return docfindspot(one) < docfindspot(two)
+	}
+
 	sortby := s.StoredSession.SortHitsBy
 
 	switch {
@@ -124,6 +151,10 @@ func SortResults(s *str.SearchStruct) {
 	case sortby == "provenance":
 		// as this is likely an inscription search, why not sort next by date?
 		WLOrderedBy(increasingWLOC, dateIncreasing).Sort(s.Results.Lines)
+	case sortby == "document_date":
+		WLOrderedBy(docDateIncreasing, increasingFindspot, increasingID).Sort(s.Results.Lines)
+	case sortby == "findspot":
+		WLOrderedBy(increasingFindspot, docDateIncreasing, increasingID).Sort(s.Results.Lines)
 	default:
 		// author nameIncreasing
 		WLOrderedBy(nameIncreasing, increasingLines).Sort(s.Results.Lines)
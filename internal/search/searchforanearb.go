@@ -146,8 +146,6 @@ func WithinXWordsSearch(first str.SearchStruct) str.SearchStruct {
 	// as one would guess...
 
 	const (
-		PSGT = `%s_FROM_%d_TO_%d`
-		LNK  = `index/%s/%s/%d`
 		RGX  = `^(?P<head>.*?)%s(?P<tail>.*?)$`
 		MSG1 = "%s WithinXWordsSearch(): %d initial hits"
 		MSG2 = "%s WithinXWordsSearch(): %d subsequent hits"
@@ -187,63 +185,17 @@ func WithinXWordsSearch(first str.SearchStruct) str.SearchStruct {
 
 	second.SetType()
 
-	// [a1] hard code a suspect assumption...
-	need := 2 + (first.ProxDist / vv.AVGWORDSPERLINE)
-
-	resultmapper := make(map[string]int, first.Results.Len())
-	newpsg := make([]string, first.Results.Len())
-
-	// [a2] pick the lines to grab and associate them with the hits they go with
-	// map[index/gr0007/018/15195:93 index/gr0007/018/15196:93 index/gr0007/018/15197:93 index/gr0007/018/15198:93 ...
-
-	count := 0
-	rr := first.Results.YieldAll()
-	for r := range rr {
-		low := r.TbIndex - need
-		if low < 1 {
-			low = 1
-		}
-		np := fmt.Sprintf(PSGT, r.AuID(), low, r.TbIndex+need)
-		newpsg[count] = np
-		for j := r.TbIndex - need; j <= r.TbIndex+need; j++ {
-			m := fmt.Sprintf(LNK, r.AuID(), r.WkID(), j)
-			resultmapper[m] = count
-		}
-		count++
-	}
-
-	second.CurrentLimit = vv.FIRSTSEARCHLIM
-	second.SearchIn.Passages = newpsg
-	SSBuildQueries(&second)
-
-	// [b] run the second "search" for anything/everything: ""
-
-	SearchAndInsertResults(&second)
+	// [a1] - [c1] grab the lines around each hit and bundle them
+	bundlemapper := XWordsNeighborhoods(&first, &second)
 
 	d = fmt.Sprintf("[Δ: %.3fs] ", time.Now().Sub(previous).Seconds())
 	Msg.PEEK(fmt.Sprintf(MSG2, d, first.Results.Len()))
 	previous = time.Now()
 
-	// [c] convert these finds into strings and then search those strings
-	// [c1] build bundles of lines
-	bundlemapper := make(map[int][]str.DbWorkline)
-
-	rr = second.Results.YieldAll()
-	for r := range rr {
-		url := r.BuildHyperlink()
-		bun := resultmapper[url]
-		bundlemapper[bun] = append(bundlemapper[bun], r)
-	}
-
-	for k, b := range bundlemapper {
-		sort.Slice(b, func(i, j int) bool { return b[i].TbIndex < b[j].TbIndex })
-		bundlemapper[k] = b
-	}
-
 	// [c2] decompose them into long strings and assign to a KVPair (K will let you get back to first.Results[i])
 
 	kvp := make([]KVPair, len(bundlemapper))
-	count = 0
+	count := 0
 	for idx, lines := range bundlemapper {
 		var bundle []string
 		for i := 0; i < len(lines); i++ {
@@ -343,6 +295,68 @@ func WithinXWordsSearch(first str.SearchStruct) str.SearchStruct {
 	return second
 }
 
+// XWordsNeighborhoods - run "second" as a search for everything near each of the hits in "first"; bundle the lines by hit
+func XWordsNeighborhoods(first *str.SearchStruct, second *str.SearchStruct) map[int][]str.DbWorkline {
+	// the keys of the returned map are the indices of the hits in first.Results.Lines; the bundles are in index order;
+	// a line that is near more than one hit goes into the bundle of each of them
+
+	const (
+		PSGT = `%s_FROM_%d_TO_%d`
+		LNK  = `index/%s/%s/%d`
+	)
+
+	// [a1] hard code a suspect assumption...
+	need := 2 + (first.ProxDist / vv.AVGWORDSPERLINE)
+
+	resultmapper := make(map[string][]int, first.Results.Len())
+	newpsg := make([]string, first.Results.Len())
+
+	// [a2] pick the lines to grab and associate them with the hits they go with
+	// map[index/gr0007/018/15195:[93] index/gr0007/018/15196:[93 94] index/gr0007/018/15197:[93 94] ...
+
+	count := 0
+	rr := first.Results.YieldAll()
+	for r := range rr {
+		low := r.TbIndex - need
+		if low < 1 {
+			low = 1
+		}
+		np := fmt.Sprintf(PSGT, r.AuID(), low, r.TbIndex+need)
+		newpsg[count] = np
+		for j := r.TbIndex - need; j <= r.TbIndex+need; j++ {
+			m := fmt.Sprintf(LNK, r.AuID(), r.WkID(), j)
+			resultmapper[m] = append(resultmapper[m], count)
+		}
+		count++
+	}
+
+	second.CurrentLimit = vv.FIRSTSEARCHLIM
+	second.SearchIn.Passages = newpsg
+	SSBuildQueries(second)
+
+	// [b] run the second "search" for anything/everything: ""
+
+	SearchAndInsertResults(second)
+
+	// [c] build bundles of lines
+	bundlemapper := make(map[int][]str.DbWorkline)
+
+	rr = second.Results.YieldAll()
+	for r := range rr {
+		url := r.BuildHyperlink()
+		for _, bun := range resultmapper[url] {
+			bundlemapper[bun] = append(bundlemapper[bun], r)
+		}
+	}
+
+	for k, b := range bundlemapper {
+		sort.Slice(b, func(i, j int) bool { return b[i].TbIndex < b[j].TbIndex })
+		bundlemapper[k] = b
+	}
+
+	return bundlemapper
+}
+
 //
 // FAN-OUT AND FAN-IN SECOND HALF OF WithinXWordsSearch()
 //
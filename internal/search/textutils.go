@@ -16,6 +16,7 @@ import (
 	"regexp"
 	"sort"
 	"strings"
+	"unicode"
 )
 
 func LemmaIntoRegexSlice(hdwd string) []string {
@@ -32,29 +33,28 @@ func LemmaIntoRegexSlice(hdwd string) []string {
 		return []string{FAILSLC}
 	}
 
-	tp := `(^|\s)%s(\s|$)`
-
 	// there is a problem: unless you do something, "(^|\s)ἁλιεύϲ(\s|$)" will be a search term but this will not find "ἁλιεὺϲ"
 	var lemm []string
 	for _, l := range mps.AllLemm[hdwd].Deriv {
 		lemm = append(lemm, gen.FindAcuteOrGrave(l))
 	}
 
-	ct := 0
-	for true {
-		var bnd []string
-		for i := 0; i < vv.MAXLEMMACHUNKSIZE; i++ {
-			if ct > len(lemm)-1 {
-				break
-			}
-			re := fmt.Sprintf(tp, lemm[ct])
-			bnd = append(bnd, re)
-			ct += 1
+	qq = BundleRegexTerms(lemm)
+	return qq
+}
+
+// BundleRegexTerms - turn a list of words into a list of "(^|\s)A(\s|$)|(^|\s)B(\s|$)|..." chunks of vv.MAXLEMMACHUNKSIZE
+func BundleRegexTerms(terms []string) []string {
+	tp := `(^|\s)%s(\s|$)`
+
+	var qq []string
+	for i := 0; i < len(terms); i += vv.MAXLEMMACHUNKSIZE {
+		end := min(i+vv.MAXLEMMACHUNKSIZE, len(terms))
+		bnd := make([]string, end-i)
+		for j := i; j < end; j++ {
+			bnd[j-i] = fmt.Sprintf(tp, terms[j])
 		}
 		qq = append(qq, strings.Join(bnd, "|"))
-		if ct >= len(lemm)-1 {
-			break
-		}
 	}
 	return qq
 }
@@ -247,6 +247,63 @@ func RestoreWhiteSpace(skg string) string {
 	return skg
 }
 
+// NodeFinder - build a test for whether a token is an instance of the thing that was searched for
+func NodeFinder(srch *str.SearchStruct) func(string) bool {
+	const (
+		FAIL = "NodeFinder() could not compile '%s'"
+	)
+
+	if srch.LemmaOne != "" {
+		forms := make(map[string]bool)
+		if _, ok := mps.AllLemm[srch.LemmaOne]; ok {
+			for _, f := range mps.AllLemm[srch.LemmaOne].Deriv {
+				forms[gen.SwapAcuteForGrave(gen.UVσςϲ(strings.ToLower(f)))] = true
+			}
+		}
+		return func(t string) bool {
+			return forms[gen.SwapAcuteForGrave(gen.UVσςϲ(t))]
+		}
+	}
+
+	// " dolor " means "dolor" as a whole word; a phrase is anchored on its first word
+	skg := RestoreWhiteSpace(srch.Seeking)
+	pat := strings.TrimSpace(skg)
+	if strings.HasPrefix(skg, " ") {
+		pat = "^" + pat
+	}
+	if strings.HasSuffix(skg, " ") && !strings.Contains(pat, " ") {
+		pat = pat + "$"
+	}
+	pat = strings.Split(pat, " ")[0]
+
+	// the pattern and the token are normalized alike: accents only count if the search was for an accented form; the
+	// ASCII of the pattern is left alone since "\S" and "\s" are not the same thing
+	accented := srch.SrchColumn == "accented_line"
+	norm := func(s string, pattern bool) string {
+		if accented {
+			return gen.SwapAcuteForGrave(s)
+		}
+		return strings.Map(func(r rune) rune {
+			if pattern && r <= unicode.MaxASCII {
+				return r
+			}
+			if x, ok := gen.RuneRed[r]; ok {
+				return x
+			}
+			return r
+		}, s)
+	}
+
+	re, e := regexp.Compile(norm(pat, true))
+	if e != nil {
+		Msg.FYI(fmt.Sprintf(FAIL, pat))
+		return func(t string) bool { return false }
+	}
+	return func(t string) bool {
+		return re.MatchString(norm(gen.UVσςϲ(strings.ToLower(t)), false))
+	}
+}
+
 // ColumnPicker - convert from db column name into struct name
 func ColumnPicker(c string, r str.DbWorkline) string {
 	const (
@@ -418,6 +475,7 @@ func InclusionOverview(s *str.SearchStruct, sessincl str.SearchIncExl) string {
 	var ov []string
 	ov = append(ov, in.AuGenres...)
 	ov = append(ov, in.WkGenres...)
+	ov = append(ov, in.DcLocations...)
 	ov = append(ov, in.ListedABN...)
 	ov = append(ov, in.ListedWBN...)
 	ov = append(ov, nameslc...)
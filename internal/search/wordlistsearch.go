@@ -0,0 +1,365 @@
+//    HipparchiaGoServer
+//    Copyright: E Gunderson 2022-24
+//    License: GNU GENERAL PUBLIC LICENSE 3
+//        (see LICENSE in the top level directory of the distribution)
+
+package search
+
+import (
+	"fmt"
+	"github.com/e-gun/HipparchiaGoServer/internal/base/gen"
+	"github.com/e-gun/HipparchiaGoServer/internal/base/str"
+	"github.com/e-gun/HipparchiaGoServer/internal/lnch"
+	"github.com/e-gun/HipparchiaGoServer/internal/mps"
+	"github.com/e-gun/HipparchiaGoServer/internal/vv"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+//
+// WORD LIST SEARCHES: look for every item on a list in one pass
+//
+
+var unbracket = regexp.MustCompile(`\[(.)(.)\]`)
+
+// WordListHit - one line of the results and the list items found on that line
+type WordListHit struct {
+	Citation string   `json:"citation"`
+	Link     string   `json:"link"`
+	Line     string   `json:"line"`
+	Items    []string `json:"items"`
+}
+
+// WordListCount - how often did an item on the list turn up?
+type WordListCount struct {
+	Item  string `json:"item"`
+	Count int    `json:"count"`
+}
+
+// CleanWordList - apply the CleanInput() rules to each item on a word list; drop blanks and duplicates; the list is
+// capped at vv.MAXWORDLISTITEMS: the second value is the number of items cut off by the cap
+func CleanWordList(raw []string, hw bool) ([]string, int) {
+	dropping := vv.USELESSINPUT + lnch.Config.BadChars
+
+	var cleaned []string
+	for _, w := range raw {
+		w = strings.TrimSpace(w)
+		if !hw {
+			w = strings.ToLower(w)
+			w = gen.UVσςϲ(w)
+		}
+		w = gen.Purgechars(dropping, w)
+		rw := []rune(w)
+		if len(rw) > vv.MAXINPUTLEN {
+			w = string(rw[0:vv.MAXINPUTLEN])
+		}
+		if w != "" {
+			cleaned = append(cleaned, w)
+		}
+	}
+
+	cleaned = gen.Unique(cleaned)
+	sort.Strings(cleaned)
+
+	dropped := 0
+	if len(cleaned) > vv.MAXWORDLISTITEMS {
+		dropped = len(cleaned) - vv.MAXWORDLISTITEMS
+		cleaned = cleaned[0:vv.MAXWORDLISTITEMS]
+	}
+	return cleaned, dropped
+}
+
+// ConfigureWordListSearch - turn a SearchStruct into a word list search and rebuild its queries
+func ConfigureWordListSearch(ss *str.SearchStruct, items []string, hw bool) {
+	const (
+		SUMM = `Sought %d items from a list of %s: <span class="sought">»%s«</span>%s`
+		MORE = ` and %d others`
+		SHOW = 5
+	)
+
+	ss.WordList = items
+	ss.WordListHW = hw
+	ss.Seeking = ""
+	ss.LemmaOne = ""
+	ss.Proximate = ""
+	ss.LemmaTwo = ""
+	ss.Twobox = false
+	ss.SkgSlice = []string{}
+
+	// lemmata and accented words need "accented_line"; see SetType() and CleanInput()
+	ss.SrchColumn = vv.DEFAULTCOLUMN
+	for _, w := range items {
+		if (hw && str.IsGreek.MatchString(w)) || (!hw && str.HasAccent.MatchString(w)) {
+			ss.SrchColumn = "accented_line"
+			break
+		}
+	}
+
+	kind := "words"
+	if hw {
+		kind = "headwords"
+	}
+
+	shown := items
+	more := ""
+	if len(items) > SHOW {
+		shown = items[0:SHOW]
+		more = fmt.Sprintf(MORE, len(items)-SHOW)
+	}
+	ss.InitSum = fmt.Sprintf(SUMM, len(items), kind, strings.Join(shown, "«, »"), more)
+
+	SSBuildQueries(ss)
+	ss.TableSize = len(ss.Queries)
+}
+
+// WordListIntoRegexSlice - bundle every form of every item on the list into vv.MAXLEMMACHUNKSIZE regex chunks
+func WordListIntoRegexSlice(items []string, hw bool) []string {
+	var forms []string
+	for _, f := range wordlistforms(items, hw) {
+		forms = append(forms, f...)
+	}
+	forms = gen.Unique(forms)
+	return BundleRegexTerms(forms)
+}
+
+// TagWordListHits - dedupe the results and record which list items are on each line
+func TagWordListHits(ss *str.SearchStruct) ([]WordListHit, []WordListCount) {
+	const (
+		CITE = "%s, %s: %s"
+		FAIL = "TagWordListHits() could not compile '%s' into a regex"
+	)
+
+	// the chunked queries can find the same line more than once: "(A|B|...)" and "(Z|...)" will both grab "A Z"
+
+	// [a] map every searchable form back to the item(s) it came from: the queries wrapped each form in
+	// "(^|\s)...(\s|$)", so a word of the line is a hit if the whole of it matches the whole of the form;
+	// most forms are only "[ὰά]" away from being plain strings and can be looked up; the rest are regex
+
+	type formpattern struct {
+		re   *regexp.Regexp
+		item string
+	}
+
+	plainmap := make(map[string][]string)
+	var patterns []formpattern
+	for item, ff := range wordlistforms(ss.WordList, ss.WordListHW) {
+		for _, f := range ff {
+			variants := formvariants(f)
+			plain := true
+			for _, v := range variants {
+				if regexp.QuoteMeta(v) != v {
+					plain = false
+				}
+			}
+			if plain {
+				for _, v := range variants {
+					plainmap[v] = append(plainmap[v], item)
+				}
+				continue
+			}
+			re, e := regexp.Compile("^(" + f + ")$")
+			if e != nil {
+				Msg.FYI(fmt.Sprintf(FAIL, f))
+				continue
+			}
+			patterns = append(patterns, formpattern{re, item})
+		}
+	}
+
+	matched := make(map[string][]string)
+	itemsfor := func(w string) []string {
+		if ii, ok := matched[w]; ok {
+			return ii
+		}
+		ii := plainmap[w]
+		for _, p := range patterns {
+			if p.re.MatchString(w) {
+				ii = append(ii, p.item)
+			}
+		}
+		ii = gen.Unique(ii)
+		matched[w] = ii
+		return ii
+	}
+
+	// [b] walk the results; every occurrence of an item counts
+	seen := make(map[string]bool)
+	counts := make(map[string]int)
+	var kept []str.DbWorkline
+	var hits []WordListHit
+
+	rr := ss.Results.YieldAll()
+	for r := range rr {
+		lk := r.BuildHyperlink()
+		if seen[lk] {
+			continue
+		}
+		seen[lk] = true
+		kept = append(kept, r)
+
+		found := make(map[string]bool)
+		for _, w := range strings.Fields(ColumnPicker(ss.SrchColumn, r)) {
+			for _, item := range itemsfor(w) {
+				found[item] = true
+				counts[item]++
+			}
+		}
+
+		items := gen.StringMapKeysIntoSlice(found)
+		items = gen.Unique(items)
+		sort.Strings(items)
+
+		c := fmt.Sprintf(CITE, DbWlnMyAu(&r).Shortname, DbWlnMyWk(&r).Title, strings.Join(r.FindLocus(), "."))
+		hits = append(hits, WordListHit{Citation: c, Link: lk, Line: r.Accented, Items: items})
+	}
+
+	ss.Results.Lines = kept
+
+	// [c] the counts; zeros are informative too
+	wlc := make([]WordListCount, len(ss.WordList))
+	for i, w := range ss.WordList {
+		wlc[i] = WordListCount{Item: w, Count: counts[w]}
+	}
+	sort.SliceStable(wlc, func(i, j int) bool { return wlc[i].Count > wlc[j].Count })
+
+	return hits, wlc
+}
+
+// FormatWordListResults - an HTML table of the hits with their items + a table of the per-item counts
+func FormatWordListResults(ss *str.SearchStruct, hits []WordListHit, counts []WordListCount) str.SearchOutputJSON {
+	const (
+		TABLEROW = `
+		<tr class="%s">
+			<td>
+				<span class="findnumber">[%d]</span>&nbsp;%s%s
+				<browser id="%s"><span class="foundauthor">%s</span></browser>
+			</td>
+			<td class="leftpad">
+				<span class="foundtext">%s</span>
+			</td>
+			<td class="leftpad">
+				<span class="match">%s</span>
+			</td>
+		</tr>`
+		DATES   = `[<span class="date">%s</span>]`
+		CTTABLE = `
+		<br>
+		<table class="vectortable"><tbody>
+		<tr class="vectorrow"><th>item</th><th>hits</th></tr>
+		%s
+		</tbody></table>`
+		CTROW = `<tr class="%s"><td class="vectorword">%s</td><td class="vectorscore">%d</td></tr>`
+	)
+
+	m := message.NewPrinter(language.English)
+	searchterm := gethighlighter(ss)
+
+	var b strings.Builder
+	for i, h := range hits {
+		r := ss.Results.Lines[i]
+		r.PurgeMetadata()
+		r.MarkedUp = searchterm.ReplaceAllString(r.MarkedUp, MUREPLACE)
+		rc := "regular"
+		if i%3 == 2 {
+			rc = "nthrow"
+		}
+		b.WriteString(fmt.Sprintf(TABLEROW, rc, i+1, FormatInscriptionDates(DATES, &r), formatinscriptionplaces(&r),
+			h.Link, h.Citation, formateditorialbrackets(r.MarkedUp), strings.Join(h.Items, ", ")))
+	}
+
+	var ct strings.Builder
+	for i, c := range counts {
+		rc := "vectorrow"
+		if i%3 == 2 {
+			rc = "nthrow"
+		}
+		ct.WriteString(m.Sprintf(CTROW, rc, c.Item, c.Count))
+	}
+
+	var out str.SearchOutputJSON
+	out.JS = fmt.Sprintf(vv.BROWSERJS, "browser")
+	out.Title = "word list"
+	out.Searchsummary = formatfinalsearchsummary(ss) + fmt.Sprintf(CTTABLE, ct.String())
+	out.Found = "<tbody>" + b.String() + "</tbody>"
+
+	if lnch.Config.ZapLunates {
+		out.Found = gen.DeLunate(out.Found)
+	}
+	return out
+}
+
+// wordlisthighlighter - set regex to highlight every form on a word list
+func wordlisthighlighter(ss *str.SearchStruct) *regexp.Regexp {
+	const (
+		FAIL    = "wordlisthighlighter() could not compile the word list into a regex"
+		FAILURE = "MATCH_NOTHING"
+	)
+
+	var pats []string
+	for _, ff := range wordlistforms(ss.WordList, ss.WordListHW) {
+		for _, f := range ff {
+			pats = append(pats, gen.UniversalPatternMaker(unregexform(f)))
+		}
+	}
+	pats = gen.Unique(pats)
+
+	// longest first so that "ἄνθρωποϲ" is not highlighted as "ἄνθρωπ" + "οϲ"
+	sort.Slice(pats, func(i, j int) bool { return len(pats[i]) > len(pats[j]) })
+
+	r, e := regexp.Compile(strings.Join(pats, "|"))
+	if e != nil || len(pats) == 0 {
+		Msg.FYI(FAIL)
+		return regexp.MustCompile(FAILURE)
+	}
+	return r
+}
+
+// formvariants - "[ὰά]νθρωποϲ" into "ὰνθρωποϲ" and "άνθρωποϲ"; every string that FindAcuteOrGrave() can match
+func formvariants(f string) []string {
+	variants := []string{""}
+	last := 0
+	for _, m := range unbracket.FindAllStringSubmatchIndex(f, -1) {
+		var next []string
+		for _, v := range variants {
+			pre := v + f[last:m[0]]
+			next = append(next, pre+f[m[2]:m[3]], pre+f[m[4]:m[5]])
+		}
+		variants = next
+		last = m[1]
+	}
+	for i := range variants {
+		variants[i] = variants[i] + f[last:]
+	}
+	return variants
+}
+
+// unregexform - "[ὰά]νθρωποϲ" into "ὰνθρωποϲ"; undo FindAcuteOrGrave()
+func unregexform(f string) string {
+	return unbracket.ReplaceAllString(f, "$1")
+}
+
+// wordlistforms - item: []forms; headwords yield all of their forms (which are already regex-ready)
+func wordlistforms(items []string, hw bool) map[string][]string {
+	const (
+		FAILMSG = "wordlistforms() could not find '%s'"
+	)
+
+	ff := make(map[string][]string, len(items))
+	for _, w := range items {
+		if !hw {
+			ff[w] = []string{w}
+			continue
+		}
+		if _, ok := mps.AllLemm[w]; !ok {
+			Msg.FYI(fmt.Sprintf(FAILMSG, w))
+			continue
+		}
+		for _, l := range mps.AllLemm[w].Deriv {
+			ff[w] = append(ff[w], gen.FindAcuteOrGrave(l))
+		}
+	}
+	return ff
+}
@@ -0,0 +1,358 @@
+//    HipparchiaGoServer
+//    Copyright: E Gunderson 2022-24
+//    License: GNU GENERAL PUBLIC LICENSE 3
+//        (see LICENSE in the top level directory of the distribution)
+
+package vec
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"github.com/e-gun/HipparchiaGoServer/internal/base/gen"
+	"github.com/e-gun/HipparchiaGoServer/internal/base/str"
+	"github.com/e-gun/HipparchiaGoServer/internal/db"
+	sr "github.com/e-gun/HipparchiaGoServer/internal/search"
+	"github.com/e-gun/HipparchiaGoServer/internal/vlt"
+	"github.com/e-gun/HipparchiaGoServer/internal/vv"
+	"github.com/labstack/echo/v4"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+//
+// COLLOCATES: which headwords keep turning up within N words of X?
+//
+
+// Collocate - one co-occurring headword and its association measures
+type Collocate struct {
+	Headword string  `json:"headword"`
+	Count    int     `json:"count"`
+	Corpus   int     `json:"corpus"`
+	Expected float64 `json:"expected"`
+	PMI      float64 `json:"pmi"`
+	LL       float64 `json:"loglikelihood"`
+	TScore   float64 `json:"tscore"`
+}
+
+// CollocatesJSON - the collocates and the numbers that produced them
+type CollocatesJSON struct {
+	Node       string      `json:"node"`
+	Span       int         `json:"span"`
+	Nodes      int         `json:"nodes"`
+	Tokens     int         `json:"tokens"`
+	Unparsed   int         `json:"unparsed"`
+	CorpusSize int         `json:"corpussize"`
+	Collocates []Collocate `json:"collocates"`
+}
+
+// CollocatesSearch - a special case for RtSearch(): rank the headwords found within ±N words of each hit
+func CollocatesSearch(c echo.Context, srch str.SearchStruct) error {
+	// the neighborhoods come from XWordsNeighborhoods() and the headwords from buildmorphmapstrslc(); N is the
+	// proximity setting; the measures compare the counts inside the windows with dictionary_headword_wordcounts
+
+	// add "?fmt=json" or "?fmt=csv" to the search URL to get the full list back as data
+
+	const (
+		SUMM = `Collocates of »<span class="sought">%s</span>« within %d words: %d windows around %d hits;
+		%d tokens (%d could not be parsed)<br>%s`
+		NONE  = `<code>No collocates found.</code>`
+		CSVFN = "collocates_%s.csv"
+		AMSG  = `Assembling the neighborhoods`
+		PMSG  = `Parsing the neighborhoods`
+	)
+
+	c.Response().After(func() { Msg.LogPaths("CollocatesSearch()") })
+
+	span := srch.ProxDist
+	if span < 1 {
+		span = 1
+	}
+
+	node := srch.LemmaOne
+	if node == "" {
+		node = strings.TrimSpace(sr.RestoreWhiteSpace(srch.Seeking))
+	}
+
+	// [a] find the hits
+
+	sr.SearchAndInsertResults(&srch)
+	if srch.HasPhraseBoxA {
+		sr.FindPhrasesAcrossLines(&srch)
+	}
+
+	// [b] grab everything around the hits: "second" is a search for anything/everything nearby
+
+	vlt.WSInfo.UpdateVProgMsg <- vlt.WSSIKVs{srch.ID, AMSG}
+
+	second := sr.CloneSearch(&srch, 2)
+	second.Seeking = ""
+	second.LemmaOne = ""
+	second.Proximate = ""
+	second.LemmaTwo = ""
+	second.NotNear = false
+	second.ProxDist = span
+	second.SetType()
+
+	bundles := sr.XWordsNeighborhoods(&srch, &second)
+
+	// [c] cut a window of ±span words around every instance of the node; every hit has a bundle of its own; every
+	// window counts in full unless it overlaps the window of another node: a token (one place in the text, not one
+	// word) that lies inside two overlapping windows counts once; the same word near two different hits counts twice
+
+	isnode := sr.NodeFinder(&srch)
+
+	type tokenplace struct {
+		wk  string
+		ln  int
+		pos int
+	}
+
+	// walk the hits in textual order so that only the windows of neighboring hits can meet
+	order := make([]int, 0, len(bundles))
+	for k := range bundles {
+		order = append(order, k)
+	}
+	sort.Slice(order, func(i, j int) bool {
+		a, b := srch.Results.Lines[order[i]], srch.Results.Lines[order[j]]
+		if a.WkUID != b.WkUID {
+			return a.WkUID < b.WkUID
+		}
+		return a.TbIndex < b.TbIndex
+	})
+
+	// "covered" holds the places inside the windows of the current work only: windows in other works cannot overlap
+	var covered map[tokenplace]bool
+	var windowed []string
+	nodes := 0
+	work := ""
+	for _, k := range order {
+		hit := srch.Results.Lines[k]
+		if hit.WkUID != work {
+			work = hit.WkUID
+			covered = make(map[tokenplace]bool)
+		}
+
+		var toks []string
+		var inhit []bool
+		var places []tokenplace
+		for _, l := range bundles[k] {
+			for p, t := range l.AccentedSlice() {
+				if t == "" {
+					continue
+				}
+				toks = append(toks, t)
+				inhit = append(inhit, l.TbIndex == hit.TbIndex && l.WkUID == hit.WkUID)
+				places = append(places, tokenplace{l.WkUID, l.TbIndex, p})
+			}
+		}
+		for i := range toks {
+			if !inhit[i] || !isnode(toks[i]) {
+				continue
+			}
+			nodes++
+			for j := max(0, i-span); j <= min(len(toks)-1, i+span); j++ {
+				if j == i || covered[places[j]] {
+					continue
+				}
+				covered[places[j]] = true
+				windowed = append(windowed, gen.UVσςϲ(gen.SwapAcuteForGrave(toks[j])))
+			}
+		}
+	}
+
+	// [d] words into headwords
+
+	vlt.WSInfo.UpdateVProgMsg <- vlt.WSSIKVs{srch.ID, PMSG}
+
+	var cj CollocatesJSON
+	cj.Node = node
+	cj.Span = span
+	cj.Nodes = nodes
+	cj.Collocates = []Collocate{}
+
+	if len(windowed) != 0 {
+		cj = collocatemeasures(cj, windowed)
+	}
+
+	// [e] output
+
+	vlt.WSInfo.Del <- srch.WSID
+
+	switch c.QueryParam("fmt") {
+	case "json":
+		return gen.JSONresponse(c, cj)
+	case "csv":
+		c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%s", fmt.Sprintf(CSVFN, gen.StripaccentsSTR(node))))
+		return c.Blob(http.StatusOK, "text/csv; charset=utf-8", collocatescsv(cj))
+	}
+
+	m := message.NewPrinter(language.English)
+
+	q := c.Request().URL.Query()
+	q.Set("fmt", "json")
+	jsurl := fmt.Sprintf("/srch/exec/%s_json?%s", srch.ID, q.Encode())
+	q.Set("fmt", "csv")
+	csvurl := fmt.Sprintf("/srch/exec/%s_csv?%s", srch.ID, q.Encode())
+
+	found := NONE
+	if len(cj.Collocates) != 0 {
+		found = collocatestable(cj)
+	}
+
+	soj := str.SearchOutputJSON{
+		Title: fmt.Sprintf("Collocates of '%s'", node),
+		Searchsummary: m.Sprintf(SUMM, node, span, nodes, srch.Results.Len(), cj.Tokens, cj.Unparsed,
+			fmt.Sprintf(vv.COLLOCEXPORT, jsurl, csvurl)),
+		Found: found,
+		JS:    vv.VECTORJS + vv.COLLOCATESJS,
+	}
+
+	return gen.JSONresponse(c, soj)
+}
+
+// collocatemeasures - count the headwords in the windows and score them against the corpus counts
+func collocatemeasures(cj CollocatesJSON, windowed []string) CollocatesJSON {
+	// W: parsed tokens in the windows; O: a headword's count in the windows; F: its count in the corpus; N: the corpus
+	// E = W * F / N
+	// PMI = log2(O/E)
+	// t = (O - E) / sqrt(O)
+	// LL = 2 * Σ o ln(o/e) over the 2x2 table [in windows, elsewhere] x [headword, everything else]
+
+	morphmapdbm := db.ArrayToGetRequiredMorphObjects(gen.Unique(windowed))
+	winners := buildwinnertakesallparsemap(buildmorphmapstrslc(windowed, morphmapdbm))
+
+	counts := make(map[string]int)
+	for _, w := range windowed {
+		if hw, ok := winners[strings.ToLower(w)]; ok {
+			counts[hw]++
+			cj.Tokens++
+		} else {
+			cj.Unparsed++
+		}
+	}
+
+	hws := make(map[string]bool, len(counts))
+	for h := range counts {
+		hws[h] = true
+	}
+	baseline := db.FetchHeadwordCounts(hws)
+
+	n := float64(db.FetchHeadwordCorpusTotal())
+	cj.CorpusSize = int(n)
+	w := float64(cj.Tokens)
+
+	ll := func(o, e float64) float64 {
+		if o <= 0 || e <= 0 {
+			return 0
+		}
+		return o * math.Log(o/e)
+	}
+
+	for h, o := range counts {
+		obs := float64(o)
+		f := math.Max(float64(baseline[h]), obs)
+		nn := math.Max(n, w+f)
+
+		exp := w * f / nn
+
+		// the 2x2 table
+		a := obs
+		b := w - obs
+		cc := f - obs
+		d := nn - w - cc
+		e11 := w * f / nn
+		e12 := w * (nn - f) / nn
+		e21 := (nn - w) * f / nn
+		e22 := (nn - w) * (nn - f) / nn
+
+		col := Collocate{
+			Headword: h,
+			Count:    o,
+			Corpus:   baseline[h],
+			Expected: exp,
+			PMI:      math.Log2(obs / exp),
+			LL:       2 * (ll(a, e11) + ll(b, e12) + ll(cc, e21) + ll(d, e22)),
+			TScore:   (obs - exp) / math.Sqrt(obs),
+		}
+		cj.Collocates = append(cj.Collocates, col)
+	}
+
+	sort.Slice(cj.Collocates, func(i, j int) bool {
+		if cj.Collocates[i].LL == cj.Collocates[j].LL {
+			return cj.Collocates[i].Headword < cj.Collocates[j].Headword
+		}
+		return cj.Collocates[i].LL > cj.Collocates[j].LL
+	})
+
+	return cj
+}
+
+// collocatestable - the top vv.COLLOCATESTOSHOW collocates as a sortable html table
+func collocatestable(cj CollocatesJSON) string {
+	const (
+		TABLE = `
+	<table class="vectortable" id="collocatestable"><thead>
+	<tr class="vectorrow">
+		<th class="vectorrank collocsort" data-col="0">Headword</th>
+		<th class="vectorrank collocsort" data-col="1">Freq.</th>
+		<th class="vectorrank collocsort" data-col="2">Corpus</th>
+		<th class="vectorrank collocsort" data-col="3">PMI</th>
+		<th class="vectorrank collocsort" data-col="4">Log-likelihood</th>
+		<th class="vectorrank collocsort" data-col="5">t-score</th>
+	</tr></thead>
+	<tbody>%s
+	</tbody></table>
+	%s`
+		ROW = `
+	<tr class="%s">
+		<td class="vectorword"><vectorheadword id="%s">%s</vectorheadword></td>
+		<td class="vectorscore" data-val="%d">%d</td>
+		<td class="vectorscore" data-val="%d">%d</td>
+		<td class="vectorscore" data-val="%.4f">%.3f</td>
+		<td class="vectorscore" data-val="%.4f">%.2f</td>
+		<td class="vectorscore" data-val="%.4f">%.3f</td>
+	</tr>`
+		MORE = `<span class="small">(showing %d of %d collocates; export to see them all)</span>`
+	)
+
+	show := cj.Collocates
+	more := ""
+	if len(show) > vv.COLLOCATESTOSHOW {
+		show = show[0:vv.COLLOCATESTOSHOW]
+		more = fmt.Sprintf(MORE, vv.COLLOCATESTOSHOW, len(cj.Collocates))
+	}
+
+	var b strings.Builder
+	for i, cl := range show {
+		rc := "vectorrow"
+		if i%3 == 2 {
+			rc = "nthrow"
+		}
+		b.WriteString(fmt.Sprintf(ROW, rc, cl.Headword, cl.Headword, cl.Count, cl.Count, cl.Corpus, cl.Corpus,
+			cl.PMI, cl.PMI, cl.LL, cl.LL, cl.TScore, cl.TScore))
+	}
+
+	return fmt.Sprintf(TABLE, b.String(), more)
+}
+
+// collocatescsv - all of the collocates as CSV
+func collocatescsv(cj CollocatesJSON) []byte {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	_ = w.Write([]string{"headword", "count", "corpus", "expected", "pmi", "loglikelihood", "tscore"})
+	for _, cl := range cj.Collocates {
+		_ = w.Write([]string{cl.Headword, fmt.Sprintf("%d", cl.Count), fmt.Sprintf("%d", cl.Corpus),
+			fmt.Sprintf("%.6f", cl.Expected), fmt.Sprintf("%.6f", cl.PMI), fmt.Sprintf("%.6f", cl.LL),
+			fmt.Sprintf("%.6f", cl.TScore)})
+	}
+	w.Flush()
+	Msg.EC(w.Error())
+
+	return buf.Bytes()
+}
@@ -0,0 +1,762 @@
+//    HipparchiaGoServer
+//    Copyright: E Gunderson 2022-24
+//    License: GNU GENERAL PUBLIC LICENSE 3
+//        (see LICENSE in the top level directory of the distribution)
+
+package vec
+
+import (
+	"fmt"
+	"github.com/e-gun/HipparchiaGoServer/internal/base/gen"
+	"github.com/e-gun/HipparchiaGoServer/internal/base/str"
+	"github.com/e-gun/HipparchiaGoServer/internal/lnch"
+	"github.com/e-gun/HipparchiaGoServer/internal/mps"
+	"github.com/e-gun/HipparchiaGoServer/internal/search"
+	"github.com/e-gun/HipparchiaGoServer/internal/vlt"
+	"github.com/e-gun/HipparchiaGoServer/internal/vv"
+	"github.com/go-echarts/go-echarts/v2/charts"
+	"github.com/go-echarts/go-echarts/v2/opts"
+	"github.com/labstack/echo/v4"
+	"gonum.org/v1/gonum/mat"
+	"gonum.org/v1/gonum/stat"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+//
+// STYLOMETRY: Burrows' Delta, Craig's Zeta, and a PCA of the function words
+//
+
+// stylowork - one work of the selection and the samples cut from it
+type stylowork struct {
+	uid      string
+	au       string
+	label    string
+	disputed bool
+	samples  []int
+}
+
+// stylosample - a run of prepared words from one work
+type stylosample struct {
+	wk    int
+	words []string
+}
+
+// StylometrySearch - compare the works of the current selection; the text prep is that of the vectors
+func StylometrySearch(c echo.Context) error {
+	const (
+		SUMM = `
+		<div id="searchsummary">Stylometry for %s<br>
+			%d works cut into %d samples of %d words (text prep: <code>%s</code>)<br>
+			Delta uses the %d most frequent words; the PCA uses %d function words%s<br>
+			<span class="small">(%ss)</span><br>
+			%s
+		</div>
+		`
+		TOOFEW   = `<div id="searchsummary">Stylometry needs at least %d works and %d samples: found %d work(s) and %d sample(s) of %d words.</div>`
+		SPURIA   = `; disputed works are marked with ⁑`
+		HITCAP   = `<span class="small"><span class="red emph">sampling incomplete:</span>: hit the cap of %d on allowed lines</span>`
+		MSG1     = "Grabbing the lines...&nbsp;(part 1 of 4)"
+		MSG2     = "Preparing the samples...&nbsp;(part 2 of 4)"
+		MSG3     = "Measuring the distances...&nbsp;(part 3 of 4)"
+		MSG4     = "Building the graph...&nbsp;(part 4 of 4)"
+		MINWORKS = 2
+		MINSAMPL = 3
+	)
+
+	c.Response().After(func() { Msg.LogPaths("StylometrySearch()") })
+
+	start := time.Now()
+	se := vlt.AllSessions.GetSess(vlt.ReadUUIDCookie(c))
+	size := min(max(se.StyloSample, vv.STYLOMINSAMPLE), vv.STYLOMAXSAMPLE)
+	nmfw := max(se.StyloMFW, 1)
+
+	// "si" is a blank search struct used for progress reporting
+	si := search.BuildDefaultSearch(c)
+	si.Type = "stylometry"
+
+	vlt.WSInfo.UpdateSummMsg <- vlt.WSSIKVs{si.WSID, MSG1}
+	vlt.WSInfo.UpdateRemain <- vlt.WSSIKVi{si.WSID, 1}
+
+	// [a] get the lines and sort their words by work
+
+	vs := search.SessionIntoBulkSearch(c, lnch.Config.VectorMaxlines)
+
+	if vs.Results.Len() == 0 {
+		vlt.WSInfo.Del <- si.WSID
+		vlt.WSInfo.Del <- vs.WSID
+		return gen.JSONresponse(c, str.SearchOutputJSON{})
+	}
+
+	vlt.WSInfo.UpdateSummMsg <- vlt.WSSIKVs{si.WSID, MSG2}
+
+	var order []string
+	bywork := make(map[string][]string)
+	rr := vs.Results.YieldAll()
+	for r := range rr {
+		if _, ok := bywork[r.WkUID]; !ok {
+			order = append(order, r.WkUID)
+		}
+		for _, w := range r.AccentedSlice() {
+			bywork[r.WkUID] = append(bywork[r.WkUID], gen.UVσςϲ(gen.SwapAcuteForGrave(w)))
+		}
+	}
+
+	// [b] text prep and sampling: nothing is dropped at this stage since the function words are the point of the exercise
+
+	nostops := make(map[string]struct{})
+	var works []stylowork
+	var samples []stylosample
+
+	for _, u := range order {
+		// flatstring() writes every word twice: "unparsed" needs nothing more than the words themselves
+		prepped := bywork[u]
+		if se.VecTextPrep != "unparsed" {
+			prepped = strings.Fields(textprepstring(se.VecTextPrep, bywork[u], nostops))
+		}
+		if len(prepped) == 0 {
+			continue
+		}
+		wk := stylowork{uid: u, label: u, au: u}
+		if w, ok := mps.AllWorks[u]; ok {
+			wk.label = mps.DbWkMyAu(w).Shortname + ", " + w.Title
+			wk.au = w.AuID()
+			wk.disputed = !w.Authentic
+		}
+		for _, ch := range stylochunks(prepped, size) {
+			wk.samples = append(wk.samples, len(samples))
+			samples = append(samples, stylosample{wk: len(works), words: ch})
+		}
+		works = append(works, wk)
+	}
+
+	if len(works) < MINWORKS || len(samples) < MINSAMPL {
+		vlt.WSInfo.Del <- si.WSID
+		vlt.WSInfo.Del <- vs.WSID
+		return gen.JSONresponse(c, str.SearchOutputJSON{Searchsummary: fmt.Sprintf(TOOFEW, MINWORKS, MINSAMPL, len(works), len(samples), size)})
+	}
+
+	// [c] Delta, PCA, and Zeta
+
+	vlt.WSInfo.UpdateSummMsg <- vlt.WSSIKVs{si.WSID, MSG3}
+
+	stops := getstopset()
+
+	mfw := stylomfw(samples, nmfw)
+	delta := stylodelta(samples, mfw)
+	wkdelta := styloworkdelta(works, delta)
+
+	var fw []string
+	for _, w := range stylomfw(samples, len(samples)*size) {
+		if _, ok := stops[w]; ok {
+			fw = append(fw, w)
+		}
+	}
+
+	proj, explained, pcok := stylopca(samples, fw)
+
+	var tables []string
+	tables = append(tables, stylodeltatable(works, wkdelta, len(mfw)))
+	tables = append(tables, stylozetatables(works, samples, stops))
+
+	// [d] the graph
+
+	vlt.WSInfo.UpdateSummMsg <- vlt.WSSIKVs{si.WSID, MSG4}
+
+	incl := search.InclusionOverview(&vs, se.Inclusions)
+	set := fmt.Sprintf("text prep: %s; %d-word samples", se.VecTextPrep, size)
+
+	var img string
+	if se.StyloGraph == "dendrogram" || !pcok {
+		img = stylodendrogram(incl, set, works, wkdelta)
+	} else {
+		img = stylopcascatter(incl, set, works, proj, explained)
+	}
+
+	// [e] the summary
+
+	sp := ""
+	for _, w := range works {
+		if w.disputed {
+			sp = SPURIA
+			break
+		}
+	}
+
+	cp := ""
+	if vs.Results.Len() == lnch.Config.VectorMaxlines {
+		cp = fmt.Sprintf(HITCAP, lnch.Config.VectorMaxlines)
+	}
+
+	nfw := len(fw)
+	if !pcok {
+		nfw = 0
+	}
+
+	el := fmt.Sprintf("%.2f", time.Now().Sub(start).Seconds())
+	sum := fmt.Sprintf(SUMM, incl, len(works), len(samples), size, se.VecTextPrep, len(mfw), nfw, sp, el, cp)
+
+	htm := strings.Join(tables, "")
+	if lnch.Config.ZapLunates {
+		htm = gen.DeLunate(htm)
+	}
+
+	soj := str.SearchOutputJSON{
+		Title:         "Stylometry",
+		Searchsummary: sum,
+		Found:         htm,
+		Image:         img,
+		JS:            vv.VECTORJS,
+	}
+
+	vlt.WSInfo.Del <- si.WSID
+	vlt.WSInfo.Del <- vs.WSID
+
+	return gen.JSONresponse(c, soj)
+}
+
+// stylochunks - cut a work into samples; a short work is one sample; a short remainder is dropped
+func stylochunks(words []string, size int) [][]string {
+	if len(words) <= size {
+		return [][]string{words}
+	}
+
+	var chunks [][]string
+	for i := 0; i < len(words); i += size {
+		j := min(i+size, len(words))
+		if j-i < size/2 {
+			break
+		}
+		chunks = append(chunks, words[i:j])
+	}
+	return chunks
+}
+
+// stylomfw - the n most frequent words across all of the samples
+func stylomfw(samples []stylosample, n int) []string {
+	count := make(map[string]int)
+	for _, s := range samples {
+		for _, w := range s.words {
+			count[w]++
+		}
+	}
+
+	keys := gen.StringMapKeysIntoSlice(count)
+	sort.Slice(keys, func(i, j int) bool {
+		if count[keys[i]] == count[keys[j]] {
+			return keys[i] < keys[j]
+		}
+		return count[keys[i]] > count[keys[j]]
+	})
+
+	if len(keys) > n {
+		keys = keys[0:n]
+	}
+	return keys
+}
+
+// stylozscores - samples x words; relative frequencies standardized by column; a constant column is all zeros
+func stylozscores(samples []stylosample, words []string) *mat.Dense {
+	idx := make(map[string]int)
+	for i, w := range words {
+		idx[w] = i
+	}
+
+	z := mat.NewDense(len(samples), len(words), nil)
+	for i, s := range samples {
+		for _, w := range s.words {
+			if j, ok := idx[w]; ok {
+				z.Set(i, j, z.At(i, j)+1)
+			}
+		}
+		for j := range words {
+			z.Set(i, j, z.At(i, j)/float64(len(s.words)))
+		}
+	}
+
+	col := make([]float64, len(samples))
+	for j := range words {
+		mat.Col(col, j, z)
+		mean, sd := stat.MeanStdDev(col, nil)
+		for i := range samples {
+			if sd == 0 || math.IsNaN(sd) {
+				z.Set(i, j, 0)
+			} else {
+				z.Set(i, j, (col[i]-mean)/sd)
+			}
+		}
+	}
+	return z
+}
+
+// stylodelta - Burrows' Delta between every pair of samples: the mean absolute difference of the z-scores
+func stylodelta(samples []stylosample, mfw []string) *mat.SymDense {
+	z := stylozscores(samples, mfw)
+	d := mat.NewSymDense(len(samples), nil)
+
+	for i := range samples {
+		for j := i + 1; j < len(samples); j++ {
+			var t float64
+			for k := range mfw {
+				t += math.Abs(z.At(i, k) - z.At(j, k))
+			}
+			d.SetSym(i, j, t/float64(len(mfw)))
+		}
+	}
+	return d
+}
+
+// styloworkdelta - the mean sample-to-sample Delta between works; the diagonal is the spread within a work (NaN if unknowable)
+func styloworkdelta(works []stylowork, delta *mat.SymDense) *mat.SymDense {
+	wd := mat.NewSymDense(len(works), nil)
+	for a := range works {
+		for b := a; b < len(works); b++ {
+			var t float64
+			var n int
+			for _, i := range works[a].samples {
+				for _, j := range works[b].samples {
+					if i == j || (a == b && j < i) {
+						continue
+					}
+					t += delta.At(i, j)
+					n++
+				}
+			}
+			if n == 0 {
+				wd.SetSym(a, b, math.NaN())
+			} else {
+				wd.SetSym(a, b, t/float64(n))
+			}
+		}
+	}
+	return wd
+}
+
+// stylopca - project the samples onto the first two principal components of their function word frequencies
+func stylopca(samples []stylosample, fw []string) (*mat.Dense, [2]float64, bool) {
+	var explained [2]float64
+	if len(fw) < 2 || len(samples) < 3 {
+		return nil, explained, false
+	}
+
+	z := stylozscores(samples, fw)
+
+	var pc stat.PC
+	if ok := pc.PrincipalComponents(z, nil); !ok {
+		Msg.PEEK("stylopca() could not find the principal components")
+		return nil, explained, false
+	}
+
+	var vecs mat.Dense
+	pc.VectorsTo(&vecs)
+	vars := pc.VarsTo(nil)
+
+	if _, c := vecs.Dims(); c < 2 {
+		return nil, explained, false
+	}
+
+	var total float64
+	for _, v := range vars {
+		total += v
+	}
+	if total > 0 {
+		explained = [2]float64{vars[0] / total, vars[1] / total}
+	}
+
+	// the z-scores are already centered
+	var proj mat.Dense
+	proj.Mul(z, vecs.Slice(0, len(fw), 0, 2))
+	return &proj, explained, true
+}
+
+// stylodeltatable - works x works table of the mean Delta
+func stylodeltatable(works []stylowork, wd *mat.SymDense, nmfw int) string {
+	const (
+		TABLE = `
+	<table class="vectortable"><tbody>
+	<tr class="vectorrow">
+		<td class="vectorrank" colspan="%d">Burrows' Delta (%d most frequent words): lower is closer</td>
+	</tr>
+	<tr class="vectorrow">
+		<td class="vectorrank">&nbsp;</td>%s
+	</tr>
+	%s
+	<tr class="vectorrow">
+		<td class="vectorrank small" colspan="%d">(the diagonal is the mean Delta between the samples of a single work)</td>
+	</tr>
+	</tbody></table>
+	<hr>`
+		HEAD  = `<td class="vectorrank">%d</td>`
+		ROW   = `<tr class="%s"><td class="vectorword">%d. %s</td>%s</tr>`
+		CELL  = `<td class="vectorscore">%.3f</td>`
+		BLANK = `<td class="vectorscore">&nbsp;</td>`
+		NTH   = 3
+	)
+
+	var hd strings.Builder
+	for i := range works {
+		hd.WriteString(fmt.Sprintf(HEAD, i+1))
+	}
+
+	var rows strings.Builder
+	for a := range works {
+		var cells strings.Builder
+		for b := range works {
+			v := wd.At(a, b)
+			if math.IsNaN(v) {
+				cells.WriteString(BLANK)
+			} else {
+				cells.WriteString(fmt.Sprintf(CELL, v))
+			}
+		}
+		rn := "vectorrow"
+		if a%NTH == 0 {
+			rn = "nthrow"
+		}
+		rows.WriteString(fmt.Sprintf(ROW, rn, a+1, stylolabel(works[a]), cells.String()))
+	}
+
+	return fmt.Sprintf(TABLE, len(works)+1, nmfw, hd.String(), rows.String(), len(works)+1)
+}
+
+// stylozetatables - Craig's Zeta: the markers of the author with the most samples vs everyone else, and each work's lean
+func stylozetatables(works []stylowork, samples []stylosample, stops map[string]struct{}) string {
+	const (
+		NOZETA = `
+	<table class="vectortable"><tbody>
+	<tr class="vectorrow"><td class="vectorrank">Zeta requires undisputed works by at least two authors</td></tr>
+	</tbody></table>
+	<hr>`
+		MARKERS = `
+	<table class="vectortable"><tbody>
+	<tr class="vectorrow">
+		<td class="vectorrank" colspan="5">Craig's Zeta: %s vs %s (undisputed works only)</td>
+	</tr>
+	<tr class="vectorrow">
+		<td class="vectorrank">Zeta</td>
+		<td class="vectorrank">preferred by %s</td>
+		<td class="vectorrank">&nbsp;&nbsp;&nbsp;</td>
+		<td class="vectorrank">Zeta</td>
+		<td class="vectorrank">preferred by %s</td>
+	</tr>
+	%s
+	</tbody></table>
+	<hr>`
+		MKROW = `
+	<tr class="%s">
+		<td class="vectorscore">%s</td>
+		<td class="vectorword">%s</td>
+		<td class="vectorword">&nbsp;&nbsp;&nbsp;</td>
+		<td class="vectorscore">%s</td>
+		<td class="vectorword">%s</td>
+	</tr>`
+		LEAN = `
+	<table class="vectortable"><tbody>
+	<tr class="vectorrow">
+		<td class="vectorrank" colspan="4">Share of the Zeta markers found in the samples of each work</td>
+	</tr>
+	<tr class="vectorrow">
+		<td class="vectorrank">Work</td>
+		<td class="vectorrank">%s</td>
+		<td class="vectorrank">%s</td>
+		<td class="vectorrank">leans toward</td>
+	</tr>
+	%s
+	</tbody></table>
+	<hr>`
+		LNROW = `
+	<tr class="%s">
+		<td class="vectorword">%s</td>
+		<td class="vectorscore">%.3f</td>
+		<td class="vectorscore">%.3f</td>
+		<td class="vectorword">%s</td>
+	</tr>`
+		OTHERS = "the others"
+		NTH    = 3
+	)
+
+	// [a] who is "A"?
+	per := make(map[string]int)
+	for _, w := range works {
+		if !w.disputed {
+			per[w.au] += len(w.samples)
+		}
+	}
+
+	if len(per) < 2 {
+		return NOZETA
+	}
+
+	aa := gen.StringMapKeysIntoSlice(per)
+	sort.Slice(aa, func(i, j int) bool {
+		if per[aa[i]] == per[aa[j]] {
+			return aa[i] < aa[j]
+		}
+		return per[aa[i]] > per[aa[j]]
+	})
+	auth := aa[0]
+
+	aname := auth
+	if a, ok := mps.AllAuthors[auth]; ok {
+		aname = a.Shortname
+	}
+
+	// [b] document frequencies of the content words in the two groups
+	inA := make(map[string]float64)
+	inB := make(map[string]float64)
+	var nA, nB float64
+
+	for _, s := range samples {
+		w := works[s.wk]
+		if w.disputed {
+			continue
+		}
+		seen := make(map[string]struct{})
+		for _, x := range s.words {
+			if _, ok := stops[x]; !ok {
+				seen[x] = struct{}{}
+			}
+		}
+		for x := range seen {
+			if w.au == auth {
+				inA[x]++
+			} else {
+				inB[x]++
+			}
+		}
+		if w.au == auth {
+			nA++
+		} else {
+			nB++
+		}
+	}
+
+	zeta := make(map[string]float64)
+	for x := range inA {
+		zeta[x] = inA[x]/nA - inB[x]/nB
+	}
+	for x := range inB {
+		if _, ok := zeta[x]; !ok {
+			zeta[x] = -inB[x] / nB
+		}
+	}
+
+	kk := gen.StringMapKeysIntoSlice(zeta)
+	sort.Slice(kk, func(i, j int) bool {
+		if zeta[kk[i]] == zeta[kk[j]] {
+			return kk[i] < kk[j]
+		}
+		return zeta[kk[i]] > zeta[kk[j]]
+	})
+
+	n := min(vv.STYLOZETAWORDS, len(kk)/2)
+	var amk, bmk []string
+	for i := 0; i < n; i++ {
+		if zeta[kk[i]] > 0 {
+			amk = append(amk, kk[i])
+		}
+		if zeta[kk[len(kk)-1-i]] < 0 {
+			bmk = append(bmk, kk[len(kk)-1-i])
+		}
+	}
+
+	// [c] the markers table
+	var mrows strings.Builder
+	for i := 0; i < max(len(amk), len(bmk)); i++ {
+		za, wa, zb, wb := "", "", "", ""
+		if i < len(amk) {
+			za, wa = fmt.Sprintf("%.3f", zeta[amk[i]]), amk[i]
+		}
+		if i < len(bmk) {
+			zb, wb = fmt.Sprintf("%.3f", zeta[bmk[i]]), bmk[i]
+		}
+		rn := "vectorrow"
+		if i%NTH == 0 {
+			rn = "nthrow"
+		}
+		mrows.WriteString(fmt.Sprintf(MKROW, rn, za, wa, zb, wb))
+	}
+
+	out := fmt.Sprintf(MARKERS, aname, OTHERS, aname, OTHERS, mrows.String())
+
+	// [d] how much of each marker set turns up in the samples of each work (disputed works included)
+	share := func(words []string, mk []string) float64 {
+		if len(mk) == 0 {
+			return 0
+		}
+		set := gen.ToSet(words)
+		var t float64
+		for _, m := range mk {
+			if _, ok := set[m]; ok {
+				t++
+			}
+		}
+		return t / float64(len(mk))
+	}
+
+	var lrows strings.Builder
+	for i, w := range works {
+		var sa, sb float64
+		for _, s := range w.samples {
+			sa += share(samples[s].words, amk)
+			sb += share(samples[s].words, bmk)
+		}
+		sa = sa / float64(len(w.samples))
+		sb = sb / float64(len(w.samples))
+
+		lean := aname
+		if sb > sa {
+			lean = OTHERS
+		}
+		rn := "vectorrow"
+		if i%NTH == 0 {
+			rn = "nthrow"
+		}
+		lrows.WriteString(fmt.Sprintf(LNROW, rn, stylolabel(w), sa, sb, lean))
+	}
+
+	out += fmt.Sprintf(LEAN, aname, OTHERS, lrows.String())
+	return out
+}
+
+// stylolabel - "Shortname, Title" plus a mark for a disputed work
+func stylolabel(w stylowork) string {
+	if w.disputed {
+		return w.label + " ⁑"
+	}
+	return w.label
+}
+
+// stylodendrogram - average linkage (UPGMA) clustering of the works by Delta drawn as a tree
+func stylodendrogram(incl string, set string, works []stylowork, wd *mat.SymDense) string {
+	const (
+		TITLE    = "Delta dendrogram of %s"
+		SAVEFILE = "stylometry_dendrogram"
+	)
+
+	labels := make([]string, len(works))
+	for i, w := range works {
+		labels[i] = stylolabel(w)
+	}
+	root := upgmatree(labels, wd)
+
+	wd2, ht := getvecchrtwdht()
+
+	tree := charts.NewTree()
+	tree.SetGlobalOptions(
+		charts.WithTitleOpts(getcharttitleopts(fmt.Sprintf(TITLE, incl), set)),
+		charts.WithInitializationOpts(opts.Initialization{Width: wd2, Height: ht}),
+		charts.WithTooltipOpts(getcharttooltip()),
+		charts.WithToolboxOpts(getcharttoolboxopts(SAVEFILE)),
+	)
+
+	tree.AddSeries("delta", []opts.TreeData{*root},
+		charts.WithTreeOpts(opts.TreeChart{
+			Layout:           "orthogonal",
+			Orient:           "LR",
+			InitialTreeDepth: -1,
+			Roam:             true,
+			Label:            &opts.Label{Show: true, Position: "left"},
+			Leaves:           &opts.TreeLeaves{Label: &opts.Label{Show: true, Position: "right"}},
+			Right:            "30%",
+		}),
+		getchartseriesstyle(0),
+	)
+
+	return customtreehtmlandjs(tree)
+}
+
+// upgmatree - average linkage (UPGMA) clustering of the items whose distances are in the matrix; NaN distances are
+// ignored; each inner node is named after the distance at which its two children were merged
+func upgmatree(labels []string, dist *mat.SymDense) *opts.TreeData {
+	const (
+		NODE = "%.3f"
+	)
+
+	type cluster struct {
+		members []int
+		node    *opts.TreeData
+	}
+
+	var cc []cluster
+	for i, l := range labels {
+		cc = append(cc, cluster{members: []int{i}, node: &opts.TreeData{Name: l}})
+	}
+
+	linkage := func(a, b cluster) float64 {
+		var t float64
+		var n int
+		for _, i := range a.members {
+			for _, j := range b.members {
+				if v := dist.At(i, j); !math.IsNaN(v) {
+					t += v
+					n++
+				}
+			}
+		}
+		if n == 0 {
+			return math.Inf(1)
+		}
+		return t / float64(n)
+	}
+
+	for len(cc) > 1 {
+		bi, bj, best := 0, 1, math.Inf(1)
+		for i := range cc {
+			for j := i + 1; j < len(cc); j++ {
+				if d := linkage(cc[i], cc[j]); d < best {
+					bi, bj, best = i, j, d
+				}
+			}
+		}
+		merged := cluster{
+			members: append(append([]int{}, cc[bi].members...), cc[bj].members...),
+			node:    &opts.TreeData{Name: fmt.Sprintf(NODE, best), Children: []*opts.TreeData{cc[bi].node, cc[bj].node}},
+		}
+		cc = append(cc[:bj], cc[bj+1:]...)
+		cc[bi] = merged
+	}
+
+	return cc[0].node
+}
+
+// stylopcascatter - the samples on the first two principal components of the function words; one series per work
+func stylopcascatter(incl string, set string, works []stylowork, proj *mat.Dense, explained [2]float64) string {
+	const (
+		DOTSIZE  = 10
+		DOTSTYLE = "circle"
+		TITLE    = "Function word PCA of %s"
+		AXIS     = "PC%d (%.1f%%)"
+		SAVEFILE = "stylometry_pca_scattergraph"
+		SAMPLE   = "%s [sample %d]"
+	)
+
+	wd, ht := getvecchrtwdht()
+
+	scatter := charts.NewScatter()
+	scatter.SetGlobalOptions(
+		charts.WithTitleOpts(getcharttitleopts(fmt.Sprintf(TITLE, incl), set)),
+		charts.WithInitializationOpts(opts.Initialization{Width: wd, Height: ht}),
+		charts.WithTooltipOpts(getcharttooltip()),
+		charts.WithToolboxOpts(getcharttoolboxopts(SAVEFILE)),
+		charts.WithXAxisOpts(opts.XAxis{Name: fmt.Sprintf(AXIS, 1, explained[0]*100)}),
+		charts.WithYAxisOpts(opts.YAxis{Name: fmt.Sprintf(AXIS, 2, explained[1]*100)}),
+	)
+
+	for i, w := range works {
+		items := make([]opts.ScatterData, 0)
+		for k, s := range w.samples {
+			items = append(items, opts.ScatterData{
+				Value:      []float64{proj.At(s, 0), proj.At(s, 1)},
+				Symbol:     DOTSTYLE,
+				SymbolSize: DOTSIZE,
+				Name:       fmt.Sprintf(SAMPLE, stylolabel(w), k+1),
+			})
+		}
+		scatter.AddSeries(stylolabel(w), items, getchartseriesstyle(i))
+	}
+
+	return customscatterhtmlandjs(scatter)
+}
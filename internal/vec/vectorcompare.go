@@ -0,0 +1,302 @@
+//    HipparchiaGoServer
+//    Copyright: E Gunderson 2022-24
+//    License: GNU GENERAL PUBLIC LICENSE 3
+//        (see LICENSE in the top level directory of the distribution)
+
+package vec
+
+import (
+	"fmt"
+	"github.com/e-gun/HipparchiaGoServer/internal/base/gen"
+	"github.com/e-gun/HipparchiaGoServer/internal/base/str"
+	"github.com/e-gun/HipparchiaGoServer/internal/lnch"
+	sr "github.com/e-gun/HipparchiaGoServer/internal/search"
+	"github.com/e-gun/HipparchiaGoServer/internal/vlt"
+	"github.com/e-gun/HipparchiaGoServer/internal/vv"
+	"github.com/e-gun/wego/pkg/embedding"
+	"github.com/e-gun/wego/pkg/search"
+	"github.com/labstack/echo/v4"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"strings"
+	"time"
+)
+
+//
+// COMPARING THE NEIGHBORS OF A WORD IN TWO SELECTIONS
+//
+
+// "A" is the stored selection (see RtSelectionStore()) and "B" is the current selection: cf. RtKeyness() and
+// RtVocabCompare(); each gets its own model, which is fingerprinted and stored like any other neighbors model
+
+// the models are not aligned (cf. DiachronicSearch()): only the neighbor lists are compared
+
+// nnoverlap - how alike are two lists of neighbors?
+type nnoverlap struct {
+	shared  map[string]bool
+	jaccard float64
+	rho     float64 // Spearman's rank correlation of the shared neighbors; only meaningful if there are 2+ of them
+}
+
+// CompareNeighborsSearch - the neighbors of one word in the stored selection and in the current selection
+func CompareNeighborsSearch(c echo.Context, srch str.SearchStruct, word string) error {
+	const (
+		SUMM = `
+		<div id="searchsummary">The neighbors of »<span class="colorhighlight">%s</span>« in<br>
+			A (the stored selection: %s): %s<br>
+			B (the current selection: %s): %s<br>
+			%d shared neighbor(s) of %d; Jaccard similarity: %.3f; %s<br>
+			<span class="small">(model type: <code>%s</code>; text prep: <code>%s</code>)</span><br>
+			<span class="small">(%ss)</span><br>
+		</div>
+		`
+		MODEL    = `%s lines; %s words in the model`
+		STORED   = `a stored model with %s words`
+		RHO      = `rank correlation of the shared neighbors (Spearman): %.3f`
+		NORHO    = `too few shared neighbors for a rank correlation`
+		SETTINGS = `model type: %s; text prep: %s; A is %s; B is %s`
+		INCL     = "A and B"
+		MSGA     = "Model A (the stored selection): acquiring a model"
+		MSGB     = "Model B (the current selection): acquiring a model"
+		NOSOURCE = `<div id="searchsummary">Comparing neighbors needs two selections: store the first selection and then select the second</div>`
+		NOWORD   = `<div id="searchsummary">Comparing neighbors needs a word: use the lemma box or the word box</div>`
+		NOTIN    = `<div id="searchsummary">»%s« is in neither model</div>`
+	)
+
+	c.Response().After(func() { Msg.LogPaths("CompareNeighborsSearch()") })
+
+	start := time.Now()
+	m := message.NewPrinter(language.English)
+	user := vlt.ReadUUIDCookie(c)
+	sess := vlt.AllSessions.GetSess(user)
+
+	word = gen.RestoreInitialVJ(strings.ToLower(strings.TrimSpace(word)))
+
+	if sess.StoredIncl.IsEmpty() {
+		vlt.WSInfo.Del <- srch.WSID
+		return gen.JSONresponse(c, str.SearchOutputJSON{Searchsummary: NOSOURCE})
+	}
+
+	if word == "" {
+		vlt.WSInfo.Del <- srch.WSID
+		return gen.JSONresponse(c, str.SearchOutputJSON{Searchsummary: NOWORD})
+	}
+
+	modeler := srch.VecModeler
+
+	// [a] the two models
+
+	vlt.WSInfo.UpdateSummMsg <- vlt.WSSIKVs{srch.WSID, MSGA}
+	asess := sess
+	asess.Inclusions = sess.StoredIncl
+	asess.Exclusions = sess.StoredExcl
+	aembs, alines, asrch := comparemodelfor(c, srch, asess, true, modeler)
+
+	vlt.WSInfo.UpdateSummMsg <- vlt.WSSIKVs{srch.WSID, MSGB}
+	bembs, blines, bsrch := comparemodelfor(c, srch, sess, false, modeler)
+
+	// [b] the neighbors
+
+	k := neighborcount(sess)
+	ann := neighborsweb(aembs, word, k)
+	bnn := neighborsweb(bembs, word, k)
+
+	if len(ann[word]) == 0 && len(bnn[word]) == 0 {
+		vlt.WSInfo.Del <- srch.WSID
+		return gen.JSONresponse(c, str.SearchOutputJSON{Searchsummary: fmt.Sprintf(NOTIN, word)})
+	}
+
+	ov := neighboroverlap(ann[word], bnn[word])
+
+	// [c] the graph: both models in one web
+
+	aio := sr.InclusionOverview(&asrch, sess.StoredIncl)
+	bio := sr.InclusionOverview(&bsrch, sess.Inclusions)
+
+	set := fmt.Sprintf(SETTINGS, modeler, srch.VecTextPrep, aio, bio)
+	blank := buildblanknngraph(set, word, INCL)
+	graph := formatnngraph(c, blank, word, ann, bnn)
+	img := customnngraphhtmlandjs(graph)
+
+	// [d] the tables
+
+	htm := comparenntable(word, ann[word], bnn[word], ov, k)
+
+	// [e] the summary
+
+	desc := func(embs embedding.Embeddings, lines int) string {
+		if lines == 0 {
+			return fmt.Sprintf(STORED, m.Sprintf("%d", len(embs)))
+		}
+		return fmt.Sprintf(MODEL, m.Sprintf("%d", lines), m.Sprintf("%d", len(embs)))
+	}
+
+	rho := NORHO
+	if len(ov.shared) > 1 {
+		rho = fmt.Sprintf(RHO, ov.rho)
+	}
+
+	el := fmt.Sprintf("%.2f", time.Now().Sub(start).Seconds())
+	sum := fmt.Sprintf(SUMM, word, aio, desc(aembs, alines), bio, desc(bembs, blines), len(ov.shared), k, ov.jaccard,
+		rho, modeler, srch.VecTextPrep, el)
+
+	if lnch.Config.ZapLunates {
+		htm = gen.DeLunate(htm)
+	}
+
+	soj := str.SearchOutputJSON{
+		Title:         fmt.Sprintf("Neighbors of '%s' in A and B", word),
+		Searchsummary: sum,
+		Found:         htm,
+		Image:         img,
+		JS:            vv.VECTORJS,
+	}
+
+	vlt.WSInfo.Del <- srch.WSID
+	return gen.JSONresponse(c, soj)
+}
+
+// comparemodelfor - fetch or generate the model for one side of a comparison; also return the number of lines
+// behind it (if known) and a search struct that knows what it searched
+func comparemodelfor(c echo.Context, srch str.SearchStruct, sess str.ServerSession, stored bool, modeler string) (embedding.Embeddings, int, str.SearchStruct) {
+	sl := sr.SessionIntoSearchlist(sess)
+
+	fs := srch
+	fs.SearchIn = sl.Inc
+	fs.SearchEx = sl.Excl
+	fs.VecModeler = modeler
+	fp := FingerprintNNVectorSearch(fs)
+
+	if VectorDBCheckNN(fp) {
+		lines := 0
+		if r, ok := VectorRegistryLookup(fp); ok {
+			lines = r.Lines
+		}
+		return VectorDBFetchNN(fp), lines, fs
+	}
+
+	var embs embedding.Embeddings
+	if modeler == "imported" {
+		// an imported model cannot be generated
+		return embs, 0, fs
+	}
+
+	// GenerateVectEmbeddings() will report to and then delete ps.WSID: keep it away from the search that the
+	// client is polling (cf. diachronicmodelfor())
+	var ps str.SearchStruct
+	if stored {
+		ps = sr.StoredSelectionIntoBulkSearch(c, lnch.Config.VectorMaxlines)
+	} else {
+		ps = sr.SessionIntoBulkSearch(c, lnch.Config.VectorMaxlines)
+	}
+	ps.WSID = ps.ID
+	ps.VecModeler = modeler
+
+	embs = GenerateVectEmbeddings(c, modeler, ps)
+	VectorDBAddNN(fp, embs)
+	return embs, ps.Results.Len(), fs
+}
+
+// neighboroverlap - shared neighbors, Jaccard similarity, and Spearman's rho for the shared neighbors
+func neighboroverlap(a search.Neighbors, b search.Neighbors) nnoverlap {
+	ov := nnoverlap{shared: make(map[string]bool)}
+
+	inb := make(map[string]bool, len(b))
+	for _, n := range b {
+		inb[n.Word] = true
+	}
+
+	for _, n := range a {
+		if inb[n.Word] {
+			ov.shared[n.Word] = true
+		}
+	}
+
+	union := len(a) + len(b) - len(ov.shared)
+	if union > 0 {
+		ov.jaccard = float64(len(ov.shared)) / float64(union)
+	}
+
+	// rank the shared neighbors 1..n within each list; then ρ = 1 - 6Σd² / n(n² - 1)
+	rerank := func(nn search.Neighbors) map[string]int {
+		r := make(map[string]int, len(ov.shared))
+		for _, n := range nn {
+			if ov.shared[n.Word] {
+				r[n.Word] = len(r) + 1
+			}
+		}
+		return r
+	}
+
+	n := len(ov.shared)
+	if n > 1 {
+		ra, rb := rerank(a), rerank(b)
+		d2 := 0
+		for w := range ov.shared {
+			d := ra[w] - rb[w]
+			d2 += d * d
+		}
+		ov.rho = 1 - float64(6*d2)/float64(n*(n*n-1))
+	}
+
+	return ov
+}
+
+// comparenntable - the two lists of neighbors side by side; the shared neighbors are highlighted
+func comparenntable(word string, a search.Neighbors, b search.Neighbors, ov nnoverlap, k int) string {
+	const (
+		TBL = `
+	<table class="vectortable"><tbody>
+    <tr class="vectorrow">
+        <td class="vectorrank" colspan = "6">Nearest neighbors of »<span class="colorhighlight">%s</span>« in A and in B</td>
+    </tr>
+	<tr class="vectorrow">
+		<td class="vectorrank">Rank</td>
+		<td class="vectorrank">Similarity</td>
+		<td class="vectorrank">A</td>
+		<td class="vectorrank">&nbsp;&nbsp;&nbsp;</td>
+		<td class="vectorrank">Similarity</td>
+		<td class="vectorrank">B</td>
+	</tr>
+	%s
+    <tr class="vectorrow">
+        <td class="vectorrank small" colspan = "6">(neighbors found in both A and B are highlighted)</td>
+    </tr>
+	</tbody></table>
+	<hr>`
+		ROW = `
+	<tr class="%s">
+		<td class="vectorrank">%d</td>%s
+		<td class="vectorword">&nbsp;&nbsp;&nbsp;</td>%s
+	</tr>`
+		CELL   = `<td class="vectorscore">%.4f</td><td class="vectorword"><vectorheadword id="%s">%s</vectorheadword></td>`
+		SHARED = `<td class="vectorscore">%.4f</td><td class="vectorword"><vectorheadword id="%s"><span class="colorhighlight">%s</span></vectorheadword></td>`
+		EMPTY  = `<td class="vectorscore">&nbsp;</td><td class="vectorword">&nbsp;</td>`
+		NONE   = `<td class="vectorscore">&nbsp;</td><td class="vectorword small">(not in this model)</td>`
+		NTH    = 3
+	)
+
+	cell := func(nn search.Neighbors, r int) string {
+		switch {
+		case len(nn) == 0 && r == 0:
+			return NONE
+		case r >= len(nn):
+			return EMPTY
+		case ov.shared[nn[r].Word]:
+			return fmt.Sprintf(SHARED, nn[r].Similarity, nn[r].Word, nn[r].Word)
+		default:
+			return fmt.Sprintf(CELL, nn[r].Similarity, nn[r].Word, nn[r].Word)
+		}
+	}
+
+	var rows strings.Builder
+	for r := 0; r < k; r++ {
+		rn := "vectorrow"
+		if r%NTH == 0 {
+			rn = "nthrow"
+		}
+		rows.WriteString(fmt.Sprintf(ROW, rn, r+1, cell(a, r), cell(b, r)))
+	}
+
+	return fmt.Sprintf(TBL, word, rows.String())
+}
@@ -103,6 +103,7 @@ var (
 		PerplexEvalFrq:  vv.LDAPERPEVALFRQ,
 		PerplexTol:      vv.LDAPERPTOL,
 		MaxLDAGraphSize: vv.LDAMAXGRAPHLINES,
+		ChunkWords:      vv.LDACHUNKWORDS,
 	}
 )
 
@@ -270,6 +271,7 @@ func VectorDBAddNN(fp string, embs embedding.Embeddings) {
 	_, err = db.SQLPool.Exec(context.Background(), ex, l2, b)
 	dbi.EC(err)
 	Msg.TMI(MSG1 + fp)
+	registrystored(fp)
 
 	// compressed is c. 33% of original
 	// l1 := len(eb)
@@ -314,6 +316,8 @@ func VectorDBFetchNN(fp string) embedding.Embeddings {
 
 	if emb.Empty() {
 		Msg.NOTE(fmt.Sprintf(MSG2, fp))
+	} else {
+		registryused(fp)
 	}
 
 	// mm(MSG1+fp, MSGPEEK)
@@ -321,21 +325,36 @@ func VectorDBFetchNN(fp string) embedding.Embeddings {
 	return emb
 }
 
-// VectorDBReset - drop vv.VECTORTABLENAMENN
+// VectorDBDeleteNN - remove one set of embeddings from vv.VECTORTABLENAMENN (and from the registry)
+func VectorDBDeleteNN(fp string) {
+	const (
+		DEL = `DELETE FROM %s WHERE fingerprint = $1`
+		MSG = "VectorDBDeleteNN() removed %s"
+	)
+
+	_, err := db.SQLPool.Exec(context.Background(), fmt.Sprintf(DEL, vv.VECTORTABLENAMENN), fp)
+	dbi.EC(err)
+	_, err = db.SQLPool.Exec(context.Background(), fmt.Sprintf(DEL, vv.VECTORTABLENAMEREG), fp)
+	dbi.EC(err)
+	Msg.TMI(fmt.Sprintf(MSG, fp))
+}
+
+// VectorDBReset - drop vv.VECTORTABLENAMENN, vv.VECTORTABLENAMEREG, and vv.VECTORTABLENAMELDA
 func VectorDBReset() {
 	const (
 		MSG1 = "VectorDBReset() dropped "
 		MSG2 = "VectorDBReset(): 'DROP TABLE %s' returned an (ignored) error: \n\t%s"
 		E    = `DROP TABLE %s`
 	)
-	ex := fmt.Sprintf(E, vv.VECTORTABLENAMENN)
 
-	_, err := db.SQLPool.Exec(context.Background(), ex)
-	if err != nil {
-		ms := err.Error()
-		Msg.TMI(fmt.Sprintf(MSG2, vv.VECTORTABLENAMENN, ms))
-	} else {
-		Msg.NOTE(MSG1 + vv.VECTORTABLENAMENN)
+	for _, t := range []string{vv.VECTORTABLENAMENN, vv.VECTORTABLENAMEREG, vv.VECTORTABLENAMELDA} {
+		_, err := db.SQLPool.Exec(context.Background(), fmt.Sprintf(E, t))
+		if err != nil {
+			ms := err.Error()
+			Msg.TMI(fmt.Sprintf(MSG2, t, ms))
+		} else {
+			Msg.NOTE(MSG1 + t)
+		}
 	}
 }
 
@@ -371,6 +390,141 @@ func VectorDBCountNN(priority int) {
 	Msg.Emit(fmt.Sprintf(MSG4, size), priority)
 }
 
+// VectorDBInitLDA - initialize vv.VECTORTABLENAMELDA
+func VectorDBInitLDA() {
+	const (
+		CREATE = `
+			CREATE TABLE %s
+			(
+			  fingerprint character(32),
+			  modelsize   int,
+			  modeldata   bytea
+			)`
+		EXISTS = "already exists"
+	)
+	ex := fmt.Sprintf(CREATE, vv.VECTORTABLENAMELDA)
+	_, err := db.SQLPool.Exec(context.Background(), ex)
+	if err != nil {
+		m := err.Error()
+		if !strings.Contains(m, EXISTS) {
+			dbi.EC(err)
+		}
+	} else {
+		Msg.FYI("VectorDBInitLDA(): success")
+	}
+}
+
+// VectorDBCheckLDA - has a topic model with this fingerprint already been stored?
+func VectorDBCheckLDA(fp string) bool {
+	const (
+		Q   = `SELECT fingerprint FROM %s WHERE fingerprint = '%s' LIMIT 1`
+		F   = `VectorDBCheckLDA() found %s`
+		DNE = "does not exist"
+	)
+
+	q := fmt.Sprintf(Q, vv.VECTORTABLENAMELDA, fp)
+	foundrow, err := db.SQLPool.Query(context.Background(), q)
+	if err != nil {
+		m := err.Error()
+		if strings.Contains(m, DNE) {
+			VectorDBInitLDA()
+		}
+		return false
+	}
+
+	type simplestring struct {
+		S string
+	}
+
+	ss, err := pgx.CollectOneRow(foundrow, pgx.RowToStructByPos[simplestring])
+	if err != nil {
+		return false
+	} else {
+		Msg.TMI(fmt.Sprintf(F, ss.S))
+		return true
+	}
+}
+
+// VectorDBAddLDA - add a topic model to vv.VECTORTABLENAMELDA
+func VectorDBAddLDA(fp string, tm storedtopicmodel) {
+	const (
+		MSG1 = "VectorDBAddLDA(): "
+		FAIL = "VectorDBAddLDA() failed when calling json.Marshal(tm): nothing stored"
+		INS  = `
+			INSERT INTO %s
+				(fingerprint, modelsize, modeldata)
+			VALUES ('%s', $1, $2)`
+		GZ = gzip.BestSpeed
+	)
+
+	tb, err := json.Marshal(tm)
+	if err != nil {
+		Msg.NOTE(FAIL)
+		return
+	}
+
+	var buf bytes.Buffer
+	zw, err := gzip.NewWriterLevel(&buf, GZ)
+	dbi.EC(err)
+	_, err = zw.Write(tb)
+	dbi.EC(err)
+	err = zw.Close()
+	dbi.EC(err)
+
+	b := buf.Bytes()
+
+	ex := fmt.Sprintf(INS, vv.VECTORTABLENAMELDA, fp)
+	_, err = db.SQLPool.Exec(context.Background(), ex, len(b), b)
+	dbi.EC(err)
+	Msg.TMI(MSG1 + fp)
+	buf.Reset()
+}
+
+// VectorDBFetchLDA - get a topic model from vv.VECTORTABLENAMELDA
+func VectorDBFetchLDA(fp string) storedtopicmodel {
+	const (
+		MSG1 = "VectorDBFetchLDA() pulled an empty topic model for %s"
+		Q    = `SELECT modeldata FROM %s WHERE fingerprint = '%s' LIMIT 1`
+	)
+
+	q := fmt.Sprintf(Q, vv.VECTORTABLENAMELDA, fp)
+	var tmb []byte
+	foundrow, err := db.SQLPool.Query(context.Background(), q)
+	dbi.EC(err)
+
+	defer foundrow.Close()
+	for foundrow.Next() {
+		err = foundrow.Scan(&tmb)
+		dbi.EC(err)
+	}
+
+	var tm storedtopicmodel
+	if len(tmb) == 0 {
+		Msg.NOTE(fmt.Sprintf(MSG1, fp))
+		return tm
+	}
+
+	var buf bytes.Buffer
+	buf.Write(tmb)
+
+	zr, err := gzip.NewReader(&buf)
+	dbi.EC(err)
+	err = zr.Close()
+	dbi.EC(err)
+	decompr, err := io.ReadAll(zr)
+	dbi.EC(err)
+
+	err = json.Unmarshal(decompr, &tm)
+	dbi.EC(err)
+	buf.Reset()
+
+	if tm.Topics == 0 {
+		Msg.NOTE(fmt.Sprintf(MSG1, fp))
+	}
+
+	return tm
+}
+
 //
 // LDA vv.CONFIGURATION
 //
@@ -385,6 +539,7 @@ type LDAConfig struct {
 	PerplexTol      float64
 	Goroutines      int
 	MaxLDAGraphSize int
+	ChunkWords      int
 }
 
 func ldavecconfig() LDAConfig {
@@ -430,6 +585,10 @@ func ldavecconfig() LDAConfig {
 	if cfg.MaxLDAGraphSize == 0 {
 		cfg.MaxLDAGraphSize = vv.LDAMAXGRAPHLINES
 	}
+
+	if cfg.ChunkWords == 0 {
+		cfg.ChunkWords = vv.LDACHUNKWORDS
+	}
 	return cfg
 }
 
@@ -0,0 +1,477 @@
+//    HipparchiaGoServer
+//    Copyright: E Gunderson 2022-24
+//    License: GNU GENERAL PUBLIC LICENSE 3
+//        (see LICENSE in the top level directory of the distribution)
+
+package vec
+
+import (
+	"errors"
+	"fmt"
+	"github.com/e-gun/HipparchiaGoServer/internal/base/gen"
+	"github.com/e-gun/HipparchiaGoServer/internal/base/str"
+	"github.com/e-gun/HipparchiaGoServer/internal/lnch"
+	sr "github.com/e-gun/HipparchiaGoServer/internal/search"
+	"github.com/e-gun/HipparchiaGoServer/internal/vlt"
+	"github.com/e-gun/HipparchiaGoServer/internal/vv"
+	"github.com/e-gun/wego/pkg/embedding"
+	"github.com/e-gun/wego/pkg/search"
+	"github.com/labstack/echo/v4"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"gonum.org/v1/gonum/floats"
+	"gonum.org/v1/gonum/mat"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//
+// DIACHRONIC SEMANTIC CHANGE: one model per period; align the models; what moved?
+//
+
+// [a] every period is the current selection restricted to the dated works between two dates and inside the selection's
+//	own date limits (see DatedSession());
+//	each period gets its own model, which is fingerprinted and stored like any other neighbors model
+// [b] the periods share a vocabulary: the words found in every model
+// [c] every period is rotated onto the last one via orthogonal Procrustes on the shared vocabulary: R = UVᵀ where
+//	UΣVᵀ is the SVD of AᵀB; rotation preserves the distances inside each model
+// [d] the shift of a word is the cosine distance between its aligned vectors: first period to last ("drift") and
+//	from each period to the next ("steps")
+// [e] the trajectory of a word is its nearest neighbors inside each period's own model
+
+// DiachronicPeriod - a named date range
+type DiachronicPeriod struct {
+	Name     string
+	Earliest int
+	Latest   int
+}
+
+// diachronicmodel - a DiachronicPeriod and its model
+type diachronicmodel struct {
+	DiachronicPeriod
+	lines   int
+	embs    embedding.Embeddings
+	aligned map[string][]float64
+}
+
+// diachronicshift - how far a word moved
+type diachronicshift struct {
+	word  string
+	drift float64
+	steps []float64
+}
+
+// ParseDiachronicPeriods - "archaic:-800:-480,classical:-479:-323" --> []DiachronicPeriod
+func ParseDiachronicPeriods(p string) ([]DiachronicPeriod, error) {
+	const (
+		FAIL1 = "cannot parse the period '%s': use name:earliest:latest"
+		FAIL2 = "the period '%s' ends before it begins"
+		FAIL3 = "at least two periods are needed"
+	)
+
+	var pp []DiachronicPeriod
+	for _, item := range strings.Split(p, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		f := strings.Split(item, ":")
+		if len(f) != 3 {
+			return nil, fmt.Errorf(FAIL1, item)
+		}
+		e, e1 := strconv.Atoi(strings.TrimSpace(f[1]))
+		l, e2 := strconv.Atoi(strings.TrimSpace(f[2]))
+		if e1 != nil || e2 != nil {
+			return nil, fmt.Errorf(FAIL1, item)
+		}
+		if l < e {
+			return nil, fmt.Errorf(FAIL2, item)
+		}
+		pp = append(pp, DiachronicPeriod{Name: strings.TrimSpace(f[0]), Earliest: max(e, vv.MINDATE), Latest: min(l, vv.MAXDATE)})
+	}
+
+	if len(pp) < 2 {
+		return nil, errors.New(FAIL3)
+	}
+	return pp, nil
+}
+
+// DiachronicSearch - build (or fetch) a model per period, align them, and report the words that moved the most
+func DiachronicSearch(c echo.Context, srch str.SearchStruct, periods []DiachronicPeriod, word string) error {
+	const (
+		SUMM = `
+		<div id="searchsummary">Semantic change across %d periods of %s<br>
+			%s
+			shared vocabulary: %s words; models aligned to the last period (orthogonal Procrustes)<br>
+			%s
+			<span class="small">(%ss)</span><br>
+		</div>
+		`
+		PERIOD   = `%s (%s to %s): %s lines; %s words in the model<br>`
+		SKIPPED  = `<span class="small">skipped: %s (fewer than %d lines)</span><br>`
+		MSGP     = "Period %d of %d (<code>%s</code>): acquiring a model"
+		MSGA     = "Aligning the models"
+		TOOFEW   = `<div id="searchsummary">Semantic change needs at least two periods with enough text: %s</div>`
+		NOSHARED = `<div id="searchsummary">The periods do not share any vocabulary</div>`
+	)
+
+	c.Response().After(func() { Msg.LogPaths("DiachronicSearch()") })
+
+	start := time.Now()
+	m := message.NewPrinter(language.English)
+	user := vlt.ReadUUIDCookie(c)
+	sess := vlt.AllSessions.GetSess(user)
+
+	modeler := srch.VecModeler
+	if modeler == "imported" {
+		// an imported model cannot be rebuilt period by period
+		modeler = lnch.Config.VectorModel
+	}
+
+	// [a] the models
+
+	var models []diachronicmodel
+	var skipped []string
+	for i, p := range periods {
+		vlt.WSInfo.UpdateSummMsg <- vlt.WSSIKVs{srch.WSID, fmt.Sprintf(MSGP, i+1, len(periods), p.Name)}
+		dm := diachronicmodelfor(c, srch, sess, p, modeler)
+		if dm.lines < vv.DIACHRONICMINLINES || dm.embs.Empty() {
+			skipped = append(skipped, p.Name)
+			continue
+		}
+		models = append(models, dm)
+	}
+
+	sk := ""
+	if len(skipped) > 0 {
+		sk = fmt.Sprintf(SKIPPED, strings.Join(skipped, ", "), vv.DIACHRONICMINLINES)
+	}
+
+	if len(models) < 2 {
+		vlt.WSInfo.Del <- srch.WSID
+		return gen.JSONresponse(c, str.SearchOutputJSON{Searchsummary: fmt.Sprintf(TOOFEW, sk)})
+	}
+
+	// [b] the shared vocabulary
+
+	shared := diachronicsharedvocab(models)
+	if len(shared) == 0 {
+		vlt.WSInfo.Del <- srch.WSID
+		return gen.JSONresponse(c, str.SearchOutputJSON{Searchsummary: NOSHARED})
+	}
+
+	// [c] alignment
+
+	vlt.WSInfo.UpdateSummMsg <- vlt.WSSIKVs{srch.WSID, MSGA}
+	diachronicalign(models, shared)
+
+	// [d] the shifts
+
+	shifts := diachronicshifts(models, shared)
+
+	// [e] the trajectory: the word requested or else the word that moved the most
+
+	word = gen.RestoreInitialVJ(strings.ToLower(strings.TrimSpace(word)))
+	if word == "" && len(shifts) > 0 {
+		word = shifts[0].word
+	}
+
+	htm := diachronictrajectory(models, word, neighborcount(sess)) + diachronicshifttable(models, shifts)
+
+	// [f] the summary
+
+	var pl strings.Builder
+	for _, dm := range models {
+		pl.WriteString(fmt.Sprintf(PERIOD, dm.Name, gen.IntToBCE(dm.Earliest), gen.IntToBCE(dm.Latest),
+			m.Sprintf("%d", dm.lines), m.Sprintf("%d", len(dm.embs))))
+	}
+
+	io := sr.InclusionOverview(&srch, sess.Inclusions)
+	el := fmt.Sprintf("%.2f", time.Now().Sub(start).Seconds())
+	sum := fmt.Sprintf(SUMM, len(models), io, pl.String(), m.Sprintf("%d", len(shared)), sk, el)
+
+	if lnch.Config.ZapLunates {
+		htm = gen.DeLunate(htm)
+	}
+
+	soj := str.SearchOutputJSON{
+		Title:         "Semantic change",
+		Searchsummary: sum,
+		Found:         htm,
+		Image:         "",
+		JS:            vv.VECTORJS,
+	}
+
+	vlt.WSInfo.Del <- srch.WSID
+	return gen.JSONresponse(c, soj)
+}
+
+// diachronicmodelfor - fetch or generate the model for one period
+func diachronicmodelfor(c echo.Context, srch str.SearchStruct, sess str.ServerSession, p DiachronicPeriod, modeler string) diachronicmodel {
+	dm := diachronicmodel{DiachronicPeriod: p}
+
+	// the fingerprint only needs the searchlist; the lines are only needed if there is no stored model
+	dated, ok := sr.DatedSession(sess, p.Earliest, p.Latest)
+	if !ok {
+		return dm
+	}
+
+	sl := sr.SessionIntoSearchlist(dated)
+	if sl.Size == 0 {
+		return dm
+	}
+
+	fs := srch
+	fs.SearchIn = sl.Inc
+	fs.SearchEx = sl.Excl
+	fs.VecModeler = modeler
+	fp := FingerprintNNVectorSearch(fs)
+
+	if VectorDBCheckNN(fp) {
+		dm.embs = VectorDBFetchNN(fp)
+		if r, ok := VectorRegistryLookup(fp); ok && r.Lines > 0 {
+			dm.lines = r.Lines
+		} else {
+			// a model that predates the registry: it was big enough to be worth building
+			dm.lines = vv.DIACHRONICMINLINES
+		}
+		return dm
+	}
+
+	// GenerateVectEmbeddings() will report to and then delete ps.WSID: keep it away from the search that the
+	// client is polling
+	ps := sr.DatedSelectionIntoBulkSearch(c, dated, lnch.Config.VectorMaxlines)
+	ps.WSID = ps.ID
+	ps.VecModeler = modeler
+	dm.lines = ps.Results.Len()
+	if dm.lines < vv.DIACHRONICMINLINES {
+		return dm
+	}
+
+	dm.embs = GenerateVectEmbeddings(c, modeler, ps)
+	VectorDBAddNN(fp, dm.embs)
+	return dm
+}
+
+// diachronicsharedvocab - the words that are in every model
+func diachronicsharedvocab(models []diachronicmodel) []string {
+	count := make(map[string]int)
+	for _, dm := range models {
+		for _, e := range dm.embs {
+			count[e.Word]++
+		}
+	}
+
+	var shared []string
+	for w, n := range count {
+		if n == len(models) {
+			shared = append(shared, w)
+		}
+	}
+	sort.Strings(shared)
+	return shared
+}
+
+// diachronicalign - unit-length vectors for the shared vocabulary; every period rotated onto the last one
+func diachronicalign(models []diachronicmodel, shared []string) {
+	d := models[0].embs[0].Dim
+
+	unitmatrix := func(dm diachronicmodel) *mat.Dense {
+		idx := make(map[string]int, len(dm.embs))
+		for i, e := range dm.embs {
+			idx[e.Word] = i
+		}
+		a := mat.NewDense(len(shared), d, nil)
+		for r, w := range shared {
+			e := dm.embs[idx[w]]
+			row := make([]float64, d)
+			copy(row, e.Vector)
+			if e.Norm != 0 {
+				floats.Scale(1/e.Norm, row)
+			}
+			a.SetRow(r, row)
+		}
+		return a
+	}
+
+	ref := unitmatrix(models[len(models)-1])
+
+	for i := range models {
+		a := unitmatrix(models[i])
+		if i < len(models)-1 {
+			var rot mat.Dense
+			rot.Mul(a, procrustes(a, ref))
+			a = &rot
+		}
+		models[i].aligned = make(map[string][]float64, len(shared))
+		for r, w := range shared {
+			models[i].aligned[w] = mat.Row(nil, r, a)
+		}
+	}
+}
+
+// procrustes - the orthogonal matrix R that minimizes ||AR - B||
+func procrustes(a *mat.Dense, b *mat.Dense) *mat.Dense {
+	_, d := a.Dims()
+
+	var m mat.Dense
+	m.Mul(a.T(), b)
+
+	var svd mat.SVD
+	if ok := svd.Factorize(&m, mat.SVDThin); !ok {
+		Msg.WARN("procrustes() could not factorize the matrix: the models will not be aligned")
+		id := mat.NewDense(d, d, nil)
+		for i := 0; i < d; i++ {
+			id.Set(i, i, 1)
+		}
+		return id
+	}
+
+	var u, v, r mat.Dense
+	svd.UTo(&u)
+	svd.VTo(&v)
+	r.Mul(&u, v.T())
+	return &r
+}
+
+// diachronicshifts - the shared vocabulary sorted by drift
+func diachronicshifts(models []diachronicmodel, shared []string) []diachronicshift {
+	cosdist := func(a []float64, b []float64) float64 {
+		na, nb := floats.Norm(a, 2), floats.Norm(b, 2)
+		if na == 0 || nb == 0 {
+			return 1
+		}
+		return 1 - floats.Dot(a, b)/(na*nb)
+	}
+
+	shifts := make([]diachronicshift, len(shared))
+	for i, w := range shared {
+		s := diachronicshift{word: w}
+		for j := 1; j < len(models); j++ {
+			s.steps = append(s.steps, cosdist(models[j-1].aligned[w], models[j].aligned[w]))
+		}
+		s.drift = cosdist(models[0].aligned[w], models[len(models)-1].aligned[w])
+		shifts[i] = s
+	}
+
+	sort.SliceStable(shifts, func(i, j int) bool { return shifts[i].drift > shifts[j].drift })
+	return shifts
+}
+
+// diachronictrajectory - the neighbors of one word in each period
+func diachronictrajectory(models []diachronicmodel, word string, k int) string {
+	const (
+		TBL = `
+	<table class="vectortable"><tbody>
+    <tr class="vectorrow">
+        <td class="vectorrank" colspan = "%d">Neighbors of »<span class="colorhighlight">%s</span>« in each period</td>
+    </tr>
+	<tr class="vectorrow">
+		<td class="vectorrank">Rank</td>%s
+	</tr>
+	%s
+	</tbody></table>
+	<hr>`
+		HEAD  = `<td class="vectorrank">%s</td>`
+		ROW   = `<tr class="%s"><td class="vectorrank">%d</td>%s</tr>`
+		CELL  = `<td class="vectorword"><vectorheadword id="%s">%s</vectorheadword> <span class="small">(%.3f)</span></td>`
+		EMPTY = `<td class="vectorword">&nbsp;</td>`
+		NONE  = `<td class="vectorword small">(not in this model)</td>`
+		NTH   = 3
+	)
+
+	var head strings.Builder
+	cols := make([]search.Neighbors, len(models))
+	absent := make([]bool, len(models))
+	for i, dm := range models {
+		head.WriteString(fmt.Sprintf(HEAD, dm.Name))
+		s := search.Searcher{Items: dm.embs}
+		nn, err := s.SearchInternal(word, k)
+		if err != nil {
+			absent[i] = true
+			continue
+		}
+		cols[i] = nn
+	}
+
+	var rows strings.Builder
+	for r := 0; r < k; r++ {
+		var cells strings.Builder
+		for i := range models {
+			switch {
+			case absent[i] && r == 0:
+				cells.WriteString(NONE)
+			case r < len(cols[i]):
+				n := cols[i][r]
+				cells.WriteString(fmt.Sprintf(CELL, n.Word, n.Word, n.Similarity))
+			default:
+				cells.WriteString(EMPTY)
+			}
+		}
+		rn := "vectorrow"
+		if r%NTH == 0 {
+			rn = "nthrow"
+		}
+		rows.WriteString(fmt.Sprintf(ROW, rn, r+1, cells.String()))
+	}
+
+	return fmt.Sprintf(TBL, len(models)+1, word, head.String(), rows.String())
+}
+
+// diachronicshifttable - the words that moved the most
+func diachronicshifttable(models []diachronicmodel, shifts []diachronicshift) string {
+	const (
+		TBL = `
+	<table class="vectortable"><tbody>
+    <tr class="vectorrow">
+        <td class="vectorrank" colspan = "%d">The words whose neighborhoods shifted the most (cosine distance of the aligned vectors)</td>
+    </tr>
+	<tr class="vectorrow">
+		<td class="vectorrank">Rank</td>
+		<td class="vectorrank">Word</td>
+		<td class="vectorrank">%s → %s</td>%s
+	</tr>
+	%s
+	</tbody></table>`
+		HEAD = `<td class="vectorrank">%s → %s</td>`
+		ROW  = `
+	<tr class="%s">
+		<td class="vectorrank">%d</td>
+		<td class="vectorword"><vectorheadword id="%s">%s</vectorheadword></td>
+		<td class="vectorscore">%.4f</td>%s
+	</tr>`
+		STEP = `<td class="vectorscore">%.4f</td>`
+		NTH  = 3
+	)
+
+	var head strings.Builder
+	if len(models) > 2 {
+		for j := 1; j < len(models); j++ {
+			head.WriteString(fmt.Sprintf(HEAD, models[j-1].Name, models[j].Name))
+		}
+	}
+
+	var rows strings.Builder
+	for i, s := range shifts[0:min(len(shifts), vv.DIACHRONICSHIFTS)] {
+		var steps strings.Builder
+		if len(models) > 2 {
+			for _, st := range s.steps {
+				steps.WriteString(fmt.Sprintf(STEP, st))
+			}
+		}
+		rn := "vectorrow"
+		if i%NTH == 0 {
+			rn = "nthrow"
+		}
+		rows.WriteString(fmt.Sprintf(ROW, rn, i+1, s.word, s.word, s.drift, steps.String()))
+	}
+
+	span := 3
+	if len(models) > 2 {
+		span += len(models) - 1
+	}
+
+	return fmt.Sprintf(TBL, span, models[0].Name, models[len(models)-1].Name, head.String(), rows.String())
+}
@@ -0,0 +1,219 @@
+//    HipparchiaGoServer
+//    Copyright: E Gunderson 2022-24
+//    License: GNU GENERAL PUBLIC LICENSE 3
+//        (see LICENSE in the top level directory of the distribution)
+
+package vec
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/e-gun/wego/pkg/embedding"
+	"github.com/e-gun/wego/pkg/embedding/embutil"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+//
+// EMBEDDINGS IN AND OUT: word2vec text, word2vec binary, and GloVe text
+//
+
+// NNModelMeta - the sidecar that travels with an exported model
+type NNModelMeta struct {
+	Fingerprint string `json:"fingerprint"`
+	Modeler     string `json:"modeler"`
+	TextPrep    string `json:"textprep"`
+	Selection   string `json:"selection"`
+	Settings    any    `json:"settings"`
+	Words       int    `json:"words"`
+	Dimensions  int    `json:"dimensions"`
+	Exported    string `json:"exported"`
+}
+
+// NNModelSettings - the hyperparameters that a modeler will use: see FingerprintNNVectorSearch()
+func NNModelSettings(modeler string) any {
+	switch modeler {
+	case "imported":
+		return "imported from elsewhere: unknown"
+	case "glove":
+		return glovevectorconfig()
+	case "lexvec":
+		return lexvecvectorconfig()
+	default:
+		return w2vvectorconfig()
+	}
+}
+
+// EmbeddingsToText - "word v1 v2 ... vN" per line; word2vec adds a "count dimensions" header and GloVe does not
+func EmbeddingsToText(embs embedding.Embeddings, header bool) []byte {
+	var buf bytes.Buffer
+	if header && !embs.Empty() {
+		buf.WriteString(fmt.Sprintf("%d %d\n", len(embs), embs[0].Dim))
+	}
+
+	for _, e := range embs {
+		buf.WriteString(e.Word)
+		for _, v := range e.Vector {
+			buf.WriteString(" ")
+			buf.WriteString(strconv.FormatFloat(v, 'f', 6, 64))
+		}
+		buf.WriteString("\n")
+	}
+	return buf.Bytes()
+}
+
+// EmbeddingsToW2VBinary - the original word2vec binary format: a text header and then "word " + little-endian float32s
+func EmbeddingsToW2VBinary(embs embedding.Embeddings) []byte {
+	var buf bytes.Buffer
+	if embs.Empty() {
+		return buf.Bytes()
+	}
+
+	buf.WriteString(fmt.Sprintf("%d %d\n", len(embs), embs[0].Dim))
+	fl := make([]byte, 4)
+	for _, e := range embs {
+		buf.WriteString(e.Word + " ")
+		for _, v := range e.Vector {
+			binary.LittleEndian.PutUint32(fl, math.Float32bits(float32(v)))
+			buf.Write(fl)
+		}
+		buf.WriteString("\n")
+	}
+	return buf.Bytes()
+}
+
+// ParseEmbeddings - read word2vec text, word2vec binary, or GloVe text; the format is sniffed from the first lines;
+// the source is read as it is parsed and is never held in memory all at once
+func ParseEmbeddings(src io.Reader, clean func(string) string) (embedding.Embeddings, error) {
+	const (
+		FAIL1 = "no embeddings found"
+		FAIL2 = "dimensions do not match: %d in the header and %d in the data"
+		SNIFF = 1024 * 1024
+	)
+
+	br := bufio.NewReaderSize(src, SNIFF)
+
+	// [a] a "count dimensions" header means word2vec
+	hd, err := br.ReadBytes('\n')
+	if err == io.EOF {
+		return nil, errors.New(FAIL1)
+	} else if err != nil {
+		return nil, err
+	}
+
+	count, dim, isw2v := 0, 0, false
+	if ff := strings.Fields(string(hd)); len(ff) == 2 {
+		n, e1 := strconv.Atoi(ff[0])
+		d, e2 := strconv.Atoi(ff[1])
+		if e1 == nil && e2 == nil {
+			count, dim, isw2v = n, d, true
+		}
+	}
+
+	if !isw2v {
+		return parsetextembeddings(io.MultiReader(bytes.NewReader(hd), br), 0, clean)
+	}
+
+	// [b] word2vec text or binary: text if the next line is a word and dim numbers; Peek() hands back whatever
+	// it has along with its error when the data is shorter than SNIFF
+	pk, _ := br.Peek(SNIFF)
+	first, _, _ := bytes.Cut(pk, []byte("\n"))
+	if ff := strings.Fields(string(first)); len(ff) == dim+1 {
+		if _, e := strconv.ParseFloat(ff[1], 64); e == nil {
+			embs, e := parsetextembeddings(br, dim, clean)
+			if e != nil {
+				return nil, e
+			}
+			if !embs.Empty() && embs[0].Dim != dim {
+				return nil, fmt.Errorf(FAIL2, dim, embs[0].Dim)
+			}
+			return embs, nil
+		}
+	}
+
+	return parsebinaryembeddings(br, count, dim, clean)
+}
+
+// parsetextembeddings - "word v1 v2 ... vN" per line; a dim of 0 means "whatever the first line says"
+func parsetextembeddings(src io.Reader, dim int, clean func(string) string) (embedding.Embeddings, error) {
+	const (
+		FAIL = "line %d: expected %d values and found %d"
+	)
+
+	var embs embedding.Embeddings
+	sc := bufio.NewScanner(src)
+	sc.Buffer(make([]byte, 1024*1024), 64*1024*1024)
+
+	ln := 0
+	for sc.Scan() {
+		ln++
+		ff := strings.Fields(sc.Text())
+		if len(ff) < 2 {
+			continue
+		}
+		if dim == 0 {
+			dim = len(ff) - 1
+		}
+		if len(ff)-1 != dim {
+			return nil, fmt.Errorf(FAIL, ln, dim, len(ff)-1)
+		}
+
+		vec := make([]float64, dim)
+		for i, s := range ff[1:] {
+			v, err := strconv.ParseFloat(s, 64)
+			if err != nil {
+				return nil, err
+			}
+			vec[i] = v
+		}
+		embs = append(embs, embedding.Embedding{Word: clean(ff[0]), Dim: dim, Vector: vec, Norm: embutil.Norm(vec)})
+	}
+
+	if err := sc.Err(); err != nil && err != io.EOF {
+		return nil, err
+	}
+	return embs, nil
+}
+
+// parsebinaryembeddings - "word " + dim little-endian float32s; a newline may or may not follow each vector
+func parsebinaryembeddings(r *bufio.Reader, count int, dim int, clean func(string) string) (embedding.Embeddings, error) {
+	const (
+		FAIL = "binary data ended after %d of %d words"
+	)
+
+	// the header is not to be trusted with the size of the allocation
+	embs := make(embedding.Embeddings, 0, min(count, 1<<16))
+	fl := make([]byte, 4*dim)
+
+	// a failed read is either the end of the data or a problem with the source
+	failed := func(i int, err error) error {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return fmt.Errorf(FAIL, i, count)
+		}
+		return err
+	}
+
+	for i := 0; i < count; i++ {
+		w, err := r.ReadString(' ')
+		if err != nil {
+			return nil, failed(i, err)
+		}
+		w = strings.TrimLeft(strings.TrimSuffix(w, " "), "\n")
+
+		if _, err = io.ReadFull(r, fl); err != nil {
+			return nil, failed(i, err)
+		}
+
+		vec := make([]float64, dim)
+		for j := 0; j < dim; j++ {
+			vec[j] = float64(math.Float32frombits(binary.LittleEndian.Uint32(fl[j*4 : j*4+4])))
+		}
+		embs = append(embs, embedding.Embedding{Word: clean(w), Dim: dim, Vector: vec, Norm: embutil.Norm(vec)})
+	}
+	return embs, nil
+}
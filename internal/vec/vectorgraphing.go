@@ -33,6 +33,10 @@ const (
 	DOTLUM    = 45
 	DOTLUMPER = DOTLUM + 25
 	DOTSHIFT  = 0
+
+	// the extra hues of a graph that draws more than one model: see formatnngraph()
+	DOTHUEPERMODEL = 124
+	DOTHUESHARED   = 130
 )
 
 var (
@@ -71,8 +75,8 @@ func buildblanknngraph(settings string, coreword string, incl string) *charts.Gr
 	return graph
 }
 
-// formatnngraph - fill out a blank graph
-func formatnngraph(c echo.Context, graph *charts.Graph, coreword string, nn map[string]search.Neighbors) *charts.Graph {
+// formatnngraph - fill out a blank graph; with more than one model the dots are colored by the model they came from
+func formatnngraph(c echo.Context, graph *charts.Graph, coreword string, nns ...map[string]search.Neighbors) *charts.Graph {
 	const (
 		SYMSIZE       = 25
 		PERIPHSYMSZ   = 15
@@ -105,22 +109,48 @@ func formatnngraph(c echo.Context, graph *charts.Graph, coreword string, nn map[
 
 	// find the average similarity: this will let you adjust bubble size so that most similar are biggest
 	var maxsim float64
-	for _, w := range nn[coreword] {
-		if w.Similarity > maxsim {
-			maxsim = w.Similarity
+	for _, nn := range nns {
+		for _, w := range nn[coreword] {
+			if w.Similarity > maxsim {
+				maxsim = w.Similarity
+			}
 		}
 	}
 
+	// which model(s) did a word come from? a bitmask: model 0 is 1, model 1 is 2, ...; "core" is a neighbor of the
+	// coreword (or the coreword itself); "periph" is a neighbor of a neighbor
+	core := make(map[string]int)
+	periph := make(map[string]int)
+	for i, nn := range nns {
+		for t, nbs := range nn {
+			core[t] |= 1 << i
+			for _, w := range nbs {
+				periph[w.Word] |= 1 << i
+			}
+		}
+	}
+
+	dothue := func(from int) int {
+		if len(nns) < 2 || from == 0 {
+			return DOTHUE
+		}
+		if from&(from-1) != 0 {
+			// more than one bit set: the word is in more than one model
+			return DOTHUESHARED
+		}
+		return DOTHUE + int(math.Log2(float64(from)))*DOTHUEPERMODEL
+	}
+
 	// dotstyle := opts.ItemStyle{Color: DOTCOLOR}
-	vardot := func(i int) *opts.ItemStyle {
-		dv := DOTHUE + (i * DOTSHIFT)
+	vardot := func(i int, from int) *opts.ItemStyle {
+		dv := dothue(from) + (i * DOTSHIFT)
 		vd := fmthsl(dv, DOTSAT, DOTLUM)
 		return &opts.ItemStyle{Color: vd}
 	}
 
 	// periphdot := opts.ItemStyle{Color: DOTCOLPERIPH}
-	periphvardot := func(i int) *opts.ItemStyle {
-		dv := DOTHUE + (i * DOTSHIFT)
+	periphvardot := func(i int, from int) *opts.ItemStyle {
+		dv := dothue(from) + (i * DOTSHIFT)
 		vd := fmthsl(dv, DOTSAT, DOTLUMPER)
 		return &opts.ItemStyle{Color: vd}
 	}
@@ -128,29 +158,41 @@ func formatnngraph(c echo.Context, graph *charts.Graph, coreword string, nn map[
 	used := make(map[string]bool)
 
 	// the center point
-	gnn = append(gnn, opts.GraphNode{Name: coreword, Value: 0, SymbolSize: fmt.Sprintf("%.4f", SYMSIZE*SIZEDISTORT), ItemStyle: vardot(-1)})
+	gnn = append(gnn, opts.GraphNode{Name: coreword, Value: 0, SymbolSize: fmt.Sprintf("%.4f", SYMSIZE*SIZEDISTORT), ItemStyle: vardot(-1, 0)})
 	used[coreword] = true
 
 	// the words directly related to this word
-	for i, w := range nn[coreword] {
-		sizemod := fmt.Sprintf("%.4f", ((w.Similarity/maxsim)*SIZEDISTORT)*SYMSIZE)
-		gnn = append(gnn, opts.GraphNode{Name: w.Word, Value: round(w.Similarity), SymbolSize: sizemod, ItemStyle: vardot(i)})
-		gll = append(gll, opts.GraphLink{Source: coreword, Target: w.Word, Value: round(w.Similarity), Label: &valuelabel})
-		used[w.Word] = true
+	for _, nn := range nns {
+		for i, w := range nn[coreword] {
+			gll = append(gll, opts.GraphLink{Source: coreword, Target: w.Word, Value: round(w.Similarity), Label: &valuelabel})
+			if used[w.Word] {
+				continue
+			}
+			sizemod := fmt.Sprintf("%.4f", ((w.Similarity/maxsim)*SIZEDISTORT)*SYMSIZE)
+			gnn = append(gnn, opts.GraphNode{Name: w.Word, Value: round(w.Similarity), SymbolSize: sizemod, ItemStyle: vardot(i, core[w.Word])})
+			used[w.Word] = true
+		}
 	}
 
 	// the relationships between the other words
-	coreterms := gen.ToSet(gen.StringMapKeysIntoSlice(nn))
+	coreterms := make(map[string]struct{})
+	for _, nn := range nns {
+		for t := range nn {
+			coreterms[t] = struct{}{}
+		}
+	}
 
 	// populate the nodes with just the core collection of terms
 	simpleweb := func() {
-		for t := range coreterms {
-			if t == coreword {
-				continue
-			}
-			for _, w := range nn[t] {
-				if _, ok := coreterms[w.Word]; ok {
-					gll = append(gll, opts.GraphLink{Source: t, Target: w.Word, Value: round(w.Similarity), Label: &valuelabel})
+		for _, nn := range nns {
+			for t := range nn {
+				if t == coreword {
+					continue
+				}
+				for _, w := range nn[t] {
+					if _, ok := coreterms[w.Word]; ok {
+						gll = append(gll, opts.GraphLink{Source: t, Target: w.Word, Value: round(w.Similarity), Label: &valuelabel})
+					}
 				}
 			}
 		}
@@ -159,21 +201,23 @@ func formatnngraph(c echo.Context, graph *charts.Graph, coreword string, nn map[
 	// populate the nodes with both the core terms and the neighbors of those terms as well
 
 	expandedweb := func() {
-		i := -1
-		for t := range coreterms {
-			i += 1
-			if t == coreword {
-				continue
-			}
-			for _, w := range nn[t] {
-				if _, ok := coreterms[w.Word]; ok {
-					gll = append(gll, opts.GraphLink{Source: t, Target: w.Word, Value: round(w.Similarity), Label: &valuelabel})
+		for _, nn := range nns {
+			i := -1
+			for t := range nn {
+				i += 1
+				if t == coreword {
+					continue
 				}
-				if _, ok := used[w.Word]; !ok {
-					gnn = append(gnn, opts.GraphNode{Name: w.Word, Value: round(w.Similarity), SymbolSize: PERIPHSYMSZ, ItemStyle: periphvardot(i)})
-					used[w.Word] = true
+				for _, w := range nn[t] {
+					if _, ok := coreterms[w.Word]; ok {
+						gll = append(gll, opts.GraphLink{Source: t, Target: w.Word, Value: round(w.Similarity), Label: &valuelabel})
+					}
+					if _, ok := used[w.Word]; !ok {
+						gnn = append(gnn, opts.GraphNode{Name: w.Word, Value: round(w.Similarity), SymbolSize: PERIPHSYMSZ, ItemStyle: periphvardot(i, periph[w.Word])})
+						used[w.Word] = true
+					}
+					gll = append(gll, opts.GraphLink{Source: t, Target: w.Word, Value: round(w.Similarity), Label: &hiddenvals})
 				}
-				gll = append(gll, opts.GraphLink{Source: t, Target: w.Word, Value: round(w.Similarity), Label: &hiddenvals})
 			}
 		}
 	}
@@ -266,6 +310,118 @@ func fmthsl(h int, s int, l int) string {
 	return "hsla(" + st(h) + ", " + st(s) + "%, " + st(l) + "%, 1)"
 }
 
+//
+// BAR CHARTS
+//
+
+// HorizontalBarChart - one bar per label; positive values in one color and negative values in another: see RtKeyness()
+func HorizontalBarChart(t string, st string, sfn string, labels []string, values []float64) string {
+	wd, ht := getvecchrtwdht()
+
+	bar := charts.NewBar()
+	bar.SetGlobalOptions(
+		charts.WithTitleOpts(getcharttitleopts(t, st)),
+		charts.WithInitializationOpts(opts.Initialization{Width: wd, Height: ht}),
+		charts.WithTooltipOpts(getcharttooltip()),
+		charts.WithToolboxOpts(getcharttoolboxopts(sfn)),
+	)
+
+	// the category axis is drawn from the bottom up: reverse so that the first item is on top
+	yy := make([]string, len(labels))
+	items := make([]opts.BarData, len(values))
+	for i := range values {
+		j := len(values) - 1 - i
+		is := getchartitemstyle(0)
+		if values[i] < 0 {
+			is = getchartitemstyle(1)
+		}
+		yy[j] = labels[i]
+		items[j] = opts.BarData{Name: labels[i], Value: values[i], ItemStyle: &is}
+	}
+
+	bar.SetXAxis(yy).AddSeries(st, items)
+	bar.XYReversal()
+
+	return custombarhtmlandjs(bar)
+}
+
+func custombarhtmlandjs(b *charts.Bar) string {
+	b.Validate()
+
+	// [a] we are building a page with only one chart and doing it by hand
+	p := components.NewPage()
+	p.Renderer = NewCustomPageRender(p, p.Validate)
+
+	// [b] add assets to the page
+	assets := b.GetAssets()
+	for _, v := range assets.JSAssets.Values {
+		p.JSAssets.Add(v)
+	}
+
+	for _, v := range assets.CSSAssets.Values {
+		p.CSSAssets.Add(v)
+	}
+
+	// [c] add the chart to the page
+	p.Charts = append(p.Charts, b)
+	p.Validate()
+
+	// [d] render the chart and get the html+js for it
+	var buf bytes.Buffer
+	err := p.Render(&buf)
+	if err != nil {
+		Msg.WARN("custombarhtmlandjs() failed to render the page template")
+	}
+
+	return string(buf.Bytes())
+}
+
+//
+// HIT MAPS
+//
+
+// HitMapChart - one row per label with a dot for every hit at its position (0-100) along the row: see RtDispersion()
+func HitMapChart(t string, st string, sfn string, labels []string, hits [][]float64, names [][]string) string {
+	const (
+		DOTSIZE  = 8
+		DOTSTYLE = "rect"
+		XAXIS    = "position in the work (%)"
+	)
+
+	wd, ht := getvecchrtwdht()
+
+	// the category axis is drawn from the bottom up: reverse so that the first item is on top
+	yy := make([]string, len(labels))
+	for i := range labels {
+		yy[len(labels)-1-i] = labels[i]
+	}
+
+	scatter := charts.NewScatter()
+	scatter.SetGlobalOptions(
+		charts.WithTitleOpts(getcharttitleopts(t, st)),
+		charts.WithInitializationOpts(opts.Initialization{Width: wd, Height: ht}),
+		charts.WithTooltipOpts(getcharttooltip()),
+		charts.WithToolboxOpts(getcharttoolboxopts(sfn)),
+		charts.WithXAxisOpts(opts.XAxis{Name: XAXIS, Min: 0, Max: 100}),
+		charts.WithYAxisOpts(opts.YAxis{Type: "category", Data: yy}),
+	)
+
+	for i := range labels {
+		items := make([]opts.ScatterData, 0)
+		for j, h := range hits[i] {
+			items = append(items, opts.ScatterData{
+				Value:      []interface{}{h, len(labels) - 1 - i},
+				Symbol:     DOTSTYLE,
+				SymbolSize: DOTSIZE,
+				Name:       names[i][j],
+			})
+		}
+		scatter.AddSeries(labels[i], items, getchartseriesstyle(i))
+	}
+
+	return customscatterhtmlandjs(scatter)
+}
+
 //
 // SHARED CHART FEATURES
 //
@@ -624,6 +780,70 @@ func customscatterhtmlandjs(s *charts.Scatter) string {
 	return htmlandjs
 }
 
+// customtreehtmlandjs - customscatterhtmlandjs() for a charts.Tree: see stylodendrogram()
+func customtreehtmlandjs(t *charts.Tree) string {
+	t.Validate()
+
+	// [a] we are building a page with only one chart and doing it by hand
+	p := components.NewPage()
+	p.Renderer = NewCustomPageRender(p, p.Validate)
+
+	// [b] add assets to the page
+	assets := t.GetAssets()
+	for _, v := range assets.JSAssets.Values {
+		p.JSAssets.Add(v)
+	}
+
+	for _, v := range assets.CSSAssets.Values {
+		p.CSSAssets.Add(v)
+	}
+
+	// [c] add the chart to the page
+	p.Charts = append(p.Charts, t)
+	p.Validate()
+
+	// [d] render the chart and get the html+js for it
+	var buf bytes.Buffer
+	err := p.Render(&buf)
+	if err != nil {
+		Msg.WARN("customtreehtmlandjs() failed to render the page template")
+	}
+
+	return string(buf.Bytes())
+}
+
+// customheatmaphtmlandjs - customscatterhtmlandjs() for a charts.HeatMap: see topicheatmap()
+func customheatmaphtmlandjs(h *charts.HeatMap) string {
+	h.Validate()
+
+	// [a] we are building a page with only one chart and doing it by hand
+	p := components.NewPage()
+	p.Renderer = NewCustomPageRender(p, p.Validate)
+
+	// [b] add assets to the page
+	assets := h.GetAssets()
+	for _, v := range assets.JSAssets.Values {
+		p.JSAssets.Add(v)
+	}
+
+	for _, v := range assets.CSSAssets.Values {
+		p.CSSAssets.Add(v)
+	}
+
+	// [c] add the chart to the page
+	p.Charts = append(p.Charts, h)
+	p.Validate()
+
+	// [d] render the chart and get the html+js for it
+	var buf bytes.Buffer
+	err := p.Render(&buf)
+	if err != nil {
+		Msg.WARN("customheatmaphtmlandjs() failed to render the page template")
+	}
+
+	return string(buf.Bytes())
+}
+
 func custom3dscatterhtmlandjs(s *charts.Scatter3D) string {
 	// WARNING: this will not produce a chart right now
 
@@ -43,68 +43,98 @@ import (
 // generateneighborsdata - generate the Neighbors data for a headword within a search
 func generateneighborsdata(c echo.Context, s str.SearchStruct) map[string]search.Neighbors {
 	const (
-		FMSG  = `Fetching a stored model`
-		GMSG  = `Generating a model`
-		FAIL1 = "generateneighborsdata() could not find neighbors of a neighbor: '%s' neighbors (via '%s')"
-		FAIL2 = "generateneighborsdata() failed to produce a Searcher"
-		FAIL3 = "generateneighborsdata() failed to yield Neighbors"
 		MQMEG = `Querying the model`
 	)
 
-	fp := FingerprintNNVectorSearch(s)
-	isstored := VectorDBCheckNN(fp)
-	var embs embedding.Embeddings
-	if isstored {
-		vlt.WSInfo.UpdateVProgMsg <- vlt.WSSIKVs{s.ID, FMSG}
-		embs = VectorDBFetchNN(fp)
-	} else {
-		vlt.WSInfo.UpdateVProgMsg <- vlt.WSSIKVs{s.ID, GMSG}
-		embs = GenerateVectEmbeddings(c, s.VecModeler, s)
-		VectorDBAddNN(fp, embs)
-		if !embs.Empty() {
-			VectorDBSizeNN(mm.MSGPEEK)
-		}
-	}
+	// [a] get a model
+
+	embs := fetchorgenerateembeddings(c, s)
 
 	// [b] make a query against the model
 
 	// len(s.Results) is zero, so it is OK to UpdateSS() without copying 500k lines
 	vlt.WSInfo.UpdateVProgMsg <- vlt.WSSIKVs{s.ID, MQMEG}
 
+	nn := neighborsweb(embs, s.LemmaOne, neighborcount(s.StoredSession))
+
+	vlt.WSInfo.Del <- s.ID
+	return nn
+}
+
+// neighborsweb - the neighbors of a word and the neighbors of those neighbors
+func neighborsweb(embs embedding.Embeddings, word string, k int) map[string]search.Neighbors {
+	const (
+		FAIL1 = "neighborsweb() could not find neighbors of a neighbor: '%s' neighbors (via '%s')"
+		FAIL2 = "neighborsweb() failed to produce a Searcher"
+		FAIL3 = "neighborsweb() failed to yield Neighbors"
+	)
+
+	nn := make(map[string]search.Neighbors)
+
 	searcher, err := search.New(embs...)
 	if err != nil {
 		Msg.FYI(FAIL2)
-		searcher = func() *search.Searcher { return &search.Searcher{} }()
-	}
-
-	se := s.StoredSession
-	ncount := se.VecNeighbCt // how many neighbors to output; min is 1
-	if ncount < vv.VECTORNEIGHBORSMIN || ncount > vv.VECTORNEIGHBORSMAX {
-		ncount = vv.VECTORNEIGHBORS
+		searcher = &search.Searcher{}
 	}
 
-	word := s.LemmaOne
-	nn := make(map[string]search.Neighbors)
-	neighbors, err := searcher.SearchInternal(word, ncount)
+	neighbors, err := searcher.SearchInternal(word, k)
 	if err != nil {
 		Msg.FYI(FAIL3)
-		neighbors = search.Neighbors{}
+		return nn
 	}
 
 	nn[word] = neighbors
 	for _, n := range neighbors {
-		meta, e := searcher.SearchInternal(n.Word, ncount)
+		meta, e := searcher.SearchInternal(n.Word, k)
 		if e != nil {
 			Msg.FYI(fmt.Sprintf(FAIL1, n.Word, word))
 		} else {
 			nn[n.Word] = meta
 		}
 	}
-
-	vlt.WSInfo.Del <- s.ID
 	return nn
 }
 
+// fetchorgenerateembeddings - use the stored model for this selection if there is one; otherwise build and store it
+func fetchorgenerateembeddings(c echo.Context, s str.SearchStruct) embedding.Embeddings {
+	const (
+		FMSG = `Fetching a stored model`
+		GMSG = `Generating a model`
+		NONE = "fetchorgenerateembeddings(): nothing has been imported for this selection and text prep"
+	)
+
+	fp := FingerprintNNVectorSearch(s)
+	isstored := VectorDBCheckNN(fp)
+	var embs embedding.Embeddings
+	if !isstored && s.VecModeler == "imported" {
+		// an imported model cannot be generated
+		Msg.FYI(NONE)
+		return embs
+	}
+
+	if isstored {
+		vlt.WSInfo.UpdateVProgMsg <- vlt.WSSIKVs{s.ID, FMSG}
+		embs = VectorDBFetchNN(fp)
+	} else {
+		vlt.WSInfo.UpdateVProgMsg <- vlt.WSSIKVs{s.ID, GMSG}
+		embs = GenerateVectEmbeddings(c, s.VecModeler, s)
+		VectorDBAddNN(fp, embs)
+		if !embs.Empty() {
+			VectorDBSizeNN(mm.MSGPEEK)
+		}
+	}
+	return embs
+}
+
+// neighborcount - how many neighbors to output; min is 1
+func neighborcount(se str.ServerSession) int {
+	ncount := se.VecNeighbCt
+	if ncount < vv.VECTORNEIGHBORSMIN || ncount > vv.VECTORNEIGHBORSMAX {
+		ncount = vv.VECTORNEIGHBORS
+	}
+	return ncount
+}
+
 // GenerateVectEmbeddings - turn a search into a collection of semantic vector embeddings
 func GenerateVectEmbeddings(c echo.Context, modeltype string, s str.SearchStruct) embedding.Embeddings {
 	const (
@@ -137,6 +167,7 @@ func GenerateVectEmbeddings(c echo.Context, modeltype string, s str.SearchStruct
 		vlt.WSInfo.UpdateVProgMsg <- vlt.WSSIKVs{s.ID, p.Sprintf(TBMSG, vs.Results.Len())}
 	}
 
+	nl := s.Results.Len()
 	thetext := buildtextblock(&s)
 	s.Results.Lines = []str.DbWorkline{}
 
@@ -274,6 +305,10 @@ func GenerateVectEmbeddings(c echo.Context, modeltype string, s str.SearchStruct
 
 	buf.Reset()
 
+	if !embs.Empty() {
+		VectorRegistryNoteBuild(FingerprintNNVectorSearch(s), s, modeltype, nl, time.Now().Sub(start))
+	}
+
 	vlt.WSInfo.Del <- s.ID
 
 	return embs
@@ -294,6 +329,11 @@ func buildtextblock(s *str.SearchStruct) string {
 		}
 	}
 
+	return textprepstring(s.VecTextPrep, slicedwords, getstopset())
+}
+
+// textprepstring - the [b]-[d] of buildtextblock(): parse the words, apply the text prep, drop the stops
+func textprepstring(textprep string, slicedwords []string, stops map[string]struct{}) string {
 	// [b] get basic morphology info for those words
 	morphmapdbm := db.ArrayToGetRequiredMorphObjects(slicedwords) // map[string]DbMorphology
 
@@ -315,14 +355,12 @@ func buildtextblock(s *str.SearchStruct) string {
 	// with strings.Builder we only need .1s to build the text...
 
 	var sb strings.Builder
-	preallocate := vv.CHARSPERLINE * s.Results.Len() // NB: a long line has 60 chars
+	preallocate := vv.CHARSPERLINE / vv.AVGWORDSPERLINE * len(slicedwords) // NB: a long line has 60 chars
 	sb.Grow(preallocate)
 
-	stops := getstopset()
-
-	switch s.VecTextPrep {
+	switch textprep {
 	case "unparsed":
-		flatstring(&sb, slicedwords)
+		flatstring(&sb, slicedwords, stops)
 	case "montecarlo":
 		mcm := buildmontecarloparsemap(morphmapstrslc)
 
@@ -337,6 +375,9 @@ func buildtextblock(s *str.SearchStruct) string {
 		// map[abscondere:abscondo apte:apte•aptus capitolia:capitolium celsa:celsus¹ concludere:concludo cui:quis²•quis¹•qui²•qui¹ dactylum:dactylus de:de deum:deus fieri:fio freta:fretum•fretus¹ i:eo¹ ille:ille iungens:jungo liber:liber¹•liber⁴•libo¹ metris:metrum moenibus:moenia¹ non:non nulla:nullus patuere:pateo•patesco posse:possum repostos:re-pono rerum:res romanarum:romanus sed:sed sinus:sinus¹ spondeum:spondeum•spondeus sponte:sponte ternis:terni totum:totus²•totus¹ triumphis:triumphus tutae:tueor uersum:verro•versum•versus³•verto urbes:urbs †uilem:†uilem]
 
 		yokedstring(&sb, slicedwords, yokedmap, stops)
+	case "contextual":
+		hc := buildcontextualparsemap(morphmapstrslc, [][]string{slicedwords})
+		contextualstring(&sb, slicedwords, hc, stops)
 	default: // "winner"
 		winnermap := buildwinnertakesallparsemap(morphmapstrslc)
 
@@ -0,0 +1,552 @@
+//    HipparchiaGoServer
+//    Copyright: E Gunderson 2022-24
+//    License: GNU GENERAL PUBLIC LICENSE 3
+//        (see LICENSE in the top level directory of the distribution)
+
+package vec
+
+import (
+	"fmt"
+	"github.com/e-gun/HipparchiaGoServer/internal/base/gen"
+	"github.com/e-gun/HipparchiaGoServer/internal/base/str"
+	"github.com/e-gun/HipparchiaGoServer/internal/db"
+	"github.com/e-gun/HipparchiaGoServer/internal/lnch"
+	"github.com/e-gun/HipparchiaGoServer/internal/mps"
+	sr "github.com/e-gun/HipparchiaGoServer/internal/search"
+	"github.com/e-gun/HipparchiaGoServer/internal/vlt"
+	"github.com/e-gun/HipparchiaGoServer/internal/vv"
+	"github.com/e-gun/wego/pkg/embedding"
+	"github.com/labstack/echo/v4"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"gonum.org/v1/gonum/floats"
+	"gonum.org/v1/gonum/mat"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+//
+// "MORE LIKE THIS": PASSAGE VECTORS
+//
+
+// [a] a passage is a run of vv.VECTORPASSAGELINES consecutive lines of one work
+// [b] its words become the tokens that the text prep put into the model (see passagemapper()); its vector is the
+//	weighted average of the vectors of those tokens
+// [c] "sif" weights (Arora, Liang, & Ma 2017): a/(a + p(w)) where p(w) is the frequency of the token in the
+//	selection; then the first principal component of all the passage vectors is removed from each of them; "mean"
+//	weights every token equally and removes nothing
+// [d] the index is a matrix of unit-length passage vectors: one matrix-vector product scores every passage against
+//	a query; the indices are kept in memory (vv.VECTORPASSAGECACHE of them) and are keyed to the model fingerprint
+// [e] a query is either a passage (which need not be in the selection) or a bag of words
+
+// passagespan - where a passage is to be found
+type passagespan struct {
+	wkuid string
+	first int
+	last  int
+}
+
+// passagehit - a passage and its similarity to the query
+type passagehit struct {
+	span  passagespan
+	score float64
+}
+
+// passageindex - unit-length vectors for the passages of a selection
+type passageindex struct {
+	spans     []passagespan
+	vecs      *mat.Dense
+	dim       int
+	lines     int
+	textprep  string
+	weighting string
+	freq      map[string]float64   // p(w) of every token in the selection
+	wordvecs  map[string][]float64 // the model
+	common    []float64            // the component removed from every "sif" vector
+}
+
+// passageindexvault - the passage indices kept in memory; the oldest is dropped first
+type passageindexvault struct {
+	mutex sync.RWMutex
+	order []string
+	idx   map[string]*passageindex
+}
+
+var (
+	passageindices = passageindexvault{idx: make(map[string]*passageindex)}
+)
+
+func (pv *passageindexvault) get(k string) (*passageindex, bool) {
+	pv.mutex.RLock()
+	defer pv.mutex.RUnlock()
+	pi, ok := pv.idx[k]
+	return pi, ok
+}
+
+func (pv *passageindexvault) put(k string, pi *passageindex) {
+	pv.mutex.Lock()
+	defer pv.mutex.Unlock()
+	if _, ok := pv.idx[k]; !ok {
+		pv.order = append(pv.order, k)
+	}
+	pv.idx[k] = pi
+	for len(pv.order) > vv.VECTORPASSAGECACHE {
+		delete(pv.idx, pv.order[0])
+		pv.order = pv.order[1:]
+	}
+}
+
+// MoreLikeThisSearch - the passages of the current selection that are most like a passage or a bag of words
+func MoreLikeThisSearch(c echo.Context, srch str.SearchStruct, locus string, bag string, weighting string) error {
+	const (
+		SUMM = `
+		<div id="searchsummary">The passages of %s most like %s<br>
+			%s passages of %d lines (%s lines); passage vectors: %s<br>
+			<span class="small">(model type: <code>%s</code>; text prep: <code>%s</code>)</span><br>
+			<span class="small">(%ss)</span><br>
+		</div>
+		`
+		TBL = `
+		<table class="vectortable"><tbody>
+		<tr class="vectorrow">
+			<td class="vectorrank">Rank</td>
+			<td class="vectorrank">Similarity</td>
+			<td class="vectorrank">Passage</td>
+		</tr>
+		%s
+		</tbody></table>`
+		TBLRW = `
+		<tr class="%s">
+			<td class="vectorrank">%d</td>
+			<td class="vectorscore">%.4f</td>
+			<td class="leftpad"><browser id="%s"><span class="foundauthor">%s</span>,&nbsp;<span class="foundwork">%s</span>: <span class="foundlocus">%s</span></browser><br>%s</td>
+		</tr>`
+		QPSG     = `<browser id="%s"><span class="foundauthor">%s</span>,&nbsp;<span class="foundwork">%s</span>: <span class="foundlocus">%s</span></browser>`
+		QBAG     = `»%s«`
+		SIF      = "SIF-weighted averages of the word vectors, less their common component"
+		MEAN     = "averages of the word vectors"
+		MSG1     = "Acquiring a model"
+		MSG2     = "Building the passage vectors"
+		MSG3     = "Querying the passages"
+		NOQUERY  = `<div id="searchsummary">"More like this" needs a passage or some words</div>`
+		NOMODEL  = `<div id="searchsummary">There is no model for this selection: nothing has been imported for it</div>`
+		FAIL     = `<div id="searchsummary">"More like this" could not use the query: %s</div>`
+		EMPTYQRY = "none of its words are in the model"
+		NTH      = 3
+	)
+
+	c.Response().After(func() { Msg.LogPaths("MoreLikeThisSearch()") })
+
+	start := time.Now()
+	m := message.NewPrinter(language.English)
+	user := vlt.ReadUUIDCookie(c)
+	sess := vlt.AllSessions.GetSess(user)
+
+	bye := func(s string) error {
+		vlt.WSInfo.Del <- srch.WSID
+		return gen.JSONresponse(c, str.SearchOutputJSON{Searchsummary: s})
+	}
+
+	if locus == "" && strings.TrimSpace(bag) == "" {
+		return bye(NOQUERY)
+	}
+
+	if weighting != "mean" {
+		weighting = "sif"
+	}
+
+	// [a] the index: the same fingerprint as the model plus the weighting
+
+	sl := sr.SessionIntoSearchlist(sess)
+	fs := srch
+	fs.SearchIn = sl.Inc
+	fs.SearchEx = sl.Excl
+	key := FingerprintNNVectorSearch(fs) + weighting
+
+	pi, ok := passageindices.get(key)
+	if !ok {
+		vlt.WSInfo.UpdateSummMsg <- vlt.WSSIKVs{srch.WSID, MSG1}
+		ls := sr.SessionIntoBulkSearch(c, lnch.Config.VectorMaxlines)
+
+		// fetchorgenerateembeddings() will report to and then delete ps.ID: keep it away from the search that the
+		// client is polling (cf. diachronicmodelfor())
+		ps := ls
+		ps.WSID = ps.ID
+		embs := fetchorgenerateembeddings(c, ps)
+		if embs.Empty() {
+			return bye(NOMODEL)
+		}
+
+		vlt.WSInfo.UpdateSummMsg <- vlt.WSSIKVs{srch.WSID, MSG2}
+		pi = buildpassageindex(ls.Results.Lines, embs, srch.VecTextPrep, weighting)
+		passageindices.put(key, pi)
+	}
+
+	// [b] the query
+
+	vlt.WSInfo.UpdateSummMsg <- vlt.WSSIKVs{srch.WSID, MSG3}
+
+	var qwords []string
+	var qdesc string
+	skip := func(passagespan) bool { return false }
+
+	if locus != "" {
+		ql, err := passagequerylines(locus)
+		if err != nil {
+			return bye(fmt.Sprintf(FAIL, err.Error()))
+		}
+		for i := range ql {
+			qwords = append(qwords, passagewords(&ql[i])...)
+		}
+		f, l := ql[0], ql[len(ql)-1]
+		qdesc = fmt.Sprintf(QPSG, f.BuildHyperlink(), sr.DbWlnMyAu(&f).Shortname, sr.DbWlnMyWk(&f).Title, f.Citation())
+		// the query should not find itself
+		skip = func(ps passagespan) bool {
+			return ps.wkuid == f.WkUID && ps.first <= l.TbIndex && ps.last >= f.TbIndex
+		}
+	} else {
+		bag = gen.UVσςϲ(strings.ToLower(bag))
+		for _, w := range strings.Fields(bag) {
+			qwords = append(qwords, gen.SwapAcuteForGrave(w))
+		}
+		qdesc = fmt.Sprintf(QBAG, strings.Join(strings.Fields(bag), " "))
+	}
+
+	qv := pi.embed(passagetokens(qwords, pi.textprep))
+	if qv == nil {
+		return bye(fmt.Sprintf(FAIL, EMPTYQRY))
+	}
+
+	hits := pi.nearest(qv, vv.VECTORPASSAGESTOSHOW, skip)
+
+	// [c] the table
+
+	var trr strings.Builder
+	for i, h := range hits {
+		pl := passagelines(h.span)
+		if len(pl) == 0 {
+			continue
+		}
+		f := pl[0]
+		var txt []string
+		for _, l := range pl {
+			txt = append(txt, l.MarkedUp)
+		}
+		rn := "vectorrow"
+		if i%NTH == 0 {
+			rn = "nthrow"
+		}
+		trr.WriteString(fmt.Sprintf(TBLRW, rn, i+1, h.score, f.BuildHyperlink(), sr.DbWlnMyAu(&f).Shortname,
+			sr.DbWlnMyWk(&f).Title, f.Citation(), sr.TextBlockCleaner(strings.Join(txt, "<br>"))))
+	}
+
+	htm := fmt.Sprintf(TBL, trr.String())
+
+	// [d] the summary
+
+	wt := SIF
+	if pi.weighting == "mean" {
+		wt = MEAN
+	}
+
+	io := sr.InclusionOverview(&fs, sess.Inclusions)
+	el := fmt.Sprintf("%.2f", time.Now().Sub(start).Seconds())
+	sum := fmt.Sprintf(SUMM, io, qdesc, m.Sprintf("%d", len(pi.spans)), vv.VECTORPASSAGELINES, m.Sprintf("%d", pi.lines),
+		wt, srch.VecModeler, pi.textprep, el)
+
+	if lnch.Config.ZapLunates {
+		htm = gen.DeLunate(htm)
+		sum = gen.DeLunate(sum)
+	}
+
+	soj := str.SearchOutputJSON{
+		Title:         "More like this",
+		Searchsummary: sum,
+		Found:         htm,
+		Image:         "",
+		JS:            fmt.Sprintf(vv.BROWSERJS, "browser"),
+	}
+
+	vlt.WSInfo.Del <- srch.WSID
+	return gen.JSONresponse(c, soj)
+}
+
+// buildpassageindex - cut the lines into passages and turn the passages into vectors
+func buildpassageindex(lines []str.DbWorkline, embs embedding.Embeddings, textprep string, weighting string) *passageindex {
+	const (
+		MSG = "buildpassageindex() indexed %d passages (%d lines) in %.2fs"
+	)
+
+	start := time.Now()
+
+	pi := passageindex{
+		textprep:  textprep,
+		weighting: weighting,
+		lines:     len(lines),
+		freq:      make(map[string]float64),
+		wordvecs:  make(map[string][]float64, len(embs)),
+	}
+
+	for _, e := range embs {
+		pi.wordvecs[e.Word] = e.Vector
+	}
+	if len(embs) > 0 {
+		pi.dim = embs[0].Dim
+	}
+
+	// [a] the words of every line and then the tokens of every line
+
+	lw := make([][]string, len(lines))
+	var all []string
+	for i := range lines {
+		lw[i] = passagewords(&lines[i])
+		all = append(all, lw[i]...)
+	}
+
+	hw := passagemapper(textprep, all)
+
+	// [b] the passages: they do not cross from one work to another or skip over a gap in the selection
+
+	var toks [][]string
+	total := 0
+	for i := 0; i < len(lines); {
+		j := i + 1
+		for j < len(lines) && j-i < vv.VECTORPASSAGELINES && lines[j].WkUID == lines[i].WkUID &&
+			lines[j].TbIndex == lines[j-1].TbIndex+1 {
+			j++
+		}
+
+		var pt []string
+		for k := i; k < j; k++ {
+			for _, w := range lw[k] {
+				if t := hw(w); t != "" {
+					pt = append(pt, t)
+					pi.freq[t]++
+					total++
+				}
+			}
+		}
+
+		pi.spans = append(pi.spans, passagespan{wkuid: lines[i].WkUID, first: lines[i].TbIndex, last: lines[j-1].TbIndex})
+		toks = append(toks, pt)
+		i = j
+	}
+
+	for t := range pi.freq {
+		pi.freq[t] = pi.freq[t] / float64(max(total, 1))
+	}
+
+	// [c] the vectors; passages without a single token in the model are dropped
+
+	var spans []passagespan
+	var rows [][]float64
+	for i := range pi.spans {
+		if v := pi.average(toks[i]); v != nil {
+			spans = append(spans, pi.spans[i])
+			rows = append(rows, v)
+		}
+	}
+	pi.spans = spans
+
+	if len(rows) == 0 || pi.dim == 0 {
+		pi.vecs = nil
+		return &pi
+	}
+
+	pi.vecs = mat.NewDense(len(rows), pi.dim, nil)
+	for i, r := range rows {
+		pi.vecs.SetRow(i, r)
+	}
+
+	// [d] remove the common component: the eigenvector of XᵀX with the largest eigenvalue
+
+	if weighting == "sif" && len(rows) > 1 {
+		var xtx mat.SymDense
+		xtx.SymOuterK(1, pi.vecs.T())
+		var eig mat.EigenSym
+		if ok := eig.Factorize(&xtx, true); ok {
+			var ev mat.Dense
+			eig.VectorsTo(&ev)
+			// the eigenvalues are in ascending order
+			pi.common = mat.Col(nil, pi.dim-1, &ev)
+		}
+	}
+
+	// [e] unit length
+
+	for i := range rows {
+		pi.vecs.SetRow(i, pi.finish(pi.vecs.RawRowView(i)))
+	}
+
+	Msg.PEEK(fmt.Sprintf(MSG, len(pi.spans), pi.lines, time.Now().Sub(start).Seconds()))
+	return &pi
+}
+
+// average - the weighted average of the vectors of some tokens; nil if none of them is in the model
+func (pi *passageindex) average(tokens []string) []float64 {
+	v := make([]float64, pi.dim)
+	n := 0
+	for _, t := range tokens {
+		wv, ok := pi.wordvecs[t]
+		if !ok {
+			continue
+		}
+		wt := 1.0
+		if pi.weighting == "sif" {
+			wt = vv.VECTORPASSAGESIF / (vv.VECTORPASSAGESIF + pi.freq[t])
+		}
+		floats.AddScaled(v, wt, wv)
+		n++
+	}
+	if n == 0 {
+		return nil
+	}
+	floats.Scale(1/float64(n), v)
+	return v
+}
+
+// finish - remove the common component (if any) and scale to unit length
+func (pi *passageindex) finish(v []float64) []float64 {
+	if pi.common != nil {
+		floats.AddScaled(v, -floats.Dot(v, pi.common), pi.common)
+	}
+	if nm := floats.Norm(v, 2); nm != 0 {
+		floats.Scale(1/nm, v)
+	}
+	return v
+}
+
+// embed - a query vector for some tokens; nil if none of them is in the model
+func (pi *passageindex) embed(tokens []string) []float64 {
+	if pi.vecs == nil {
+		return nil
+	}
+	v := pi.average(tokens)
+	if v == nil {
+		return nil
+	}
+	return pi.finish(v)
+}
+
+// nearest - the k passages with the highest cosine similarity to a unit-length query vector
+func (pi *passageindex) nearest(q []float64, k int, skip func(passagespan) bool) []passagehit {
+	scores := mat.NewVecDense(len(pi.spans), nil)
+	scores.MulVec(pi.vecs, mat.NewVecDense(pi.dim, q))
+
+	// keep the best k in descending order: most scores will lose to the kth best and cost a single comparison
+	var best []passagehit
+	for i := range pi.spans {
+		s := scores.AtVec(i)
+		if len(best) == k && s <= best[k-1].score {
+			continue
+		}
+		if skip(pi.spans[i]) {
+			continue
+		}
+		j := len(best)
+		if j < k {
+			best = append(best, passagehit{})
+		} else {
+			j = k - 1
+		}
+		for j > 0 && best[j-1].score < s {
+			best[j] = best[j-1]
+			j--
+		}
+		best[j] = passagehit{span: pi.spans[i], score: s}
+	}
+	return best
+}
+
+// passagewords - the words of a line as buildtextblock() sees them
+func passagewords(l *str.DbWorkline) []string {
+	var wds []string
+	for _, w := range l.AccentedSlice() {
+		wds = append(wds, gen.UVσςϲ(gen.SwapAcuteForGrave(w)))
+	}
+	return wds
+}
+
+// passagemapper - the token that the text prep would put into the model in place of a word: see HeadwordChooser();
+// the text prep keeps an unparsed word as it is; "" for a stop word
+func passagemapper(textprep string, words []string) func(string) string {
+	choose := HeadwordChooser(words, textprep)
+	stops := getstopset()
+	return func(w string) string {
+		t := choose(w)
+		if t == "" {
+			t = w
+		}
+		if _, s := stops[t]; s {
+			return ""
+		}
+		return t
+	}
+}
+
+// passagetokens - words into the tokens of the text prep
+func passagetokens(words []string, textprep string) []string {
+	hw := passagemapper(textprep, words)
+	var toks []string
+	for _, w := range words {
+		if t := hw(w); t != "" {
+			toks = append(toks, t)
+		}
+	}
+	return toks
+}
+
+// passagequerylines - "index/gr0012/001/345" into the passage around that line
+func passagequerylines(locus string) ([]str.DbWorkline, error) {
+	const (
+		FAIL1 = "cannot parse the passage '%s'"
+		FAIL2 = "no such work: %s"
+		FAIL3 = "no such line: %s"
+	)
+
+	elem := strings.Split(strings.TrimPrefix(locus, "index/"), "/")
+	if len(elem) != 3 {
+		return nil, fmt.Errorf(FAIL1, locus)
+	}
+
+	ln, err := strconv.Atoi(elem[2])
+	if err != nil {
+		return nil, fmt.Errorf(FAIL1, locus)
+	}
+
+	k := fmt.Sprintf("%sw%s", elem[0], elem[1])
+	if _, ok := mps.AllWorks[k]; !ok {
+		return nil, fmt.Errorf(FAIL2, k)
+	}
+
+	wlb := db.SimpleContextGrabber(elem[0], ln, vv.VECTORPASSAGELINES/2)
+
+	var trimmed []str.DbWorkline
+	for _, l := range wlb.Lines {
+		if l.WkUID == k {
+			trimmed = append(trimmed, l)
+		}
+	}
+
+	if len(trimmed) == 0 {
+		return nil, fmt.Errorf(FAIL3, locus)
+	}
+	return trimmed, nil
+}
+
+// passagelines - the lines of a passage
+func passagelines(ps passagespan) []str.DbWorkline {
+	half := (ps.last - ps.first + 1) / 2
+	au := (&str.DbWorkline{WkUID: ps.wkuid}).AuID()
+	wlb := db.SimpleContextGrabber(au, ps.first+half, half+1)
+
+	var pl []str.DbWorkline
+	for _, l := range wlb.Lines {
+		if l.WkUID == ps.wkuid && l.TbIndex >= ps.first && l.TbIndex <= ps.last {
+			pl = append(pl, l)
+		}
+	}
+	return pl
+}
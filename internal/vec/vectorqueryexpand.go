@@ -0,0 +1,109 @@
+//    HipparchiaGoServer
+//    Copyright: E Gunderson 2022-24
+//    License: GNU GENERAL PUBLIC LICENSE 3
+//        (see LICENSE in the top level directory of the distribution)
+
+package vec
+
+import (
+	"fmt"
+	"github.com/e-gun/HipparchiaGoServer/internal/base/gen"
+	"github.com/e-gun/HipparchiaGoServer/internal/base/str"
+	"github.com/e-gun/HipparchiaGoServer/internal/mps"
+	sr "github.com/e-gun/HipparchiaGoServer/internal/search"
+	"github.com/e-gun/HipparchiaGoServer/internal/vlt"
+	"github.com/e-gun/wego/pkg/search"
+	"github.com/labstack/echo/v4"
+	"strings"
+)
+
+// ExpandedLemmaSearch - a special case for RtSearch(): search for a lemma *and* its nearest semantic neighbors
+func ExpandedLemmaSearch(c echo.Context, srch str.SearchStruct) error {
+	// the neighbors come from the same model that NeighborsSearch() would graph; each neighbor that is also a
+	// headword becomes one more item on a word list: see ConfigureWordListSearch(); every hit is then labeled with
+	// the item that found it and that item's similarity to the original lemma
+
+	const (
+		SUMM    = `Sought »<span class="sought">%s</span>« and %d of its nearest neighbors (model type: <code>%s</code>; text prep: <code>%s</code>)`
+		LABEL   = "%s (%.4f)"
+		SELF    = "%s (query)"
+		FAIL    = "ExpandedLemmaSearch() failed to produce a Searcher"
+		NONE    = "ExpandedLemmaSearch() found no neighbors for '%s'"
+		SKIPPED = "ExpandedLemmaSearch() skipping neighbor '%s': not a known headword"
+		MQMEG   = `Querying the model`
+		SMSG    = `Searching for the neighbors`
+	)
+
+	c.Response().After(func() { Msg.LogPaths("ExpandedLemmaSearch()") })
+	sess := srch.StoredSession
+	lemma := srch.LemmaOne
+
+	// [a] find the neighbors
+
+	// the words in the model have different formation rules from the hints supplied...
+	term := gen.RestoreInitialVJ(strings.ToLower(lemma))
+	embs := fetchorgenerateembeddings(c, srch)
+
+	vlt.WSInfo.UpdateVProgMsg <- vlt.WSSIKVs{srch.ID, MQMEG}
+
+	var neighbors search.Neighbors
+	searcher, err := search.New(embs...)
+	if err != nil {
+		Msg.FYI(FAIL)
+	} else {
+		neighbors, err = searcher.SearchInternal(term, neighborcount(sess))
+		if err != nil {
+			Msg.FYI(fmt.Sprintf(NONE, term))
+		}
+	}
+
+	// [b] turn the neighbors into a headword list
+
+	labels := map[string]string{lemma: fmt.Sprintf(SELF, lemma)}
+	items := []string{lemma}
+	for _, n := range neighbors {
+		if _, ok := mps.AllLemm[n.Word]; !ok {
+			// e.g., the "unparsed" text prep yields forms and not headwords
+			Msg.TMI(fmt.Sprintf(SKIPPED, n.Word))
+			continue
+		}
+		if _, ok := labels[n.Word]; ok {
+			continue
+		}
+		labels[n.Word] = fmt.Sprintf(LABEL, n.Word, n.Similarity)
+		items = append(items, n.Word)
+	}
+
+	// [c] search
+
+	vlt.WSInfo.UpdateVProgMsg <- vlt.WSSIKVs{srch.ID, SMSG}
+
+	sr.ConfigureWordListSearch(&srch, items, true)
+	srch.InitSum = fmt.Sprintf(SUMM, lemma, len(items)-1, sess.VecModeler, sess.VecTextPrep)
+	sr.SearchAndInsertResults(&srch)
+
+	// [d] tag, label, and format
+
+	sr.SortResults(&srch)
+	hits, counts := sr.TagWordListHits(&srch)
+	if len(hits) > srch.CurrentLimit {
+		hits = hits[0:srch.CurrentLimit]
+		srch.Results.ResizeTo(srch.CurrentLimit)
+	}
+
+	for i := range hits {
+		for j, w := range hits[i].Items {
+			hits[i].Items[j] = labels[w]
+		}
+	}
+
+	for i := range counts {
+		counts[i].Item = labels[counts[i].Item]
+	}
+
+	soj := sr.FormatWordListResults(&srch, hits, counts)
+	soj.Title = fmt.Sprintf("Neighbors of '%s'", lemma)
+
+	vlt.WSInfo.Del <- srch.WSID
+	return gen.JSONresponse(c, soj)
+}
@@ -66,11 +66,11 @@ import (
 //}
 
 type BagWithLocus struct {
-	Loc         string
-	Bag         string
-	ModifiedBag string
-	LDAScore    float64
-	Workline    str.DbWorkline
+	Loc         string         `json:"loc"`
+	Bag         string         `json:"bag"`
+	ModifiedBag string         `json:"modbag"`
+	LDAScore    float64        `json:"-"`
+	Workline    str.DbWorkline `json:"-"`
 }
 
 func (b *BagWithLocus) GetWL() {
@@ -82,60 +82,90 @@ func (b *BagWithLocus) GetWL() {
 	b.Workline = db.GrabOneLine(tb[1][0:vv.LENGTHOFAUTHORID], ln)
 }
 
-// LDASearch - search via Latent Dirichlet Allocation
+// LDASearch - search via Latent Dirichlet Allocation (or one of the other topic modelers: see topicmodel())
 func LDASearch(c echo.Context, srch str.SearchStruct) error {
 	const (
-		LDAMSG = `Building LDA model for the current selections`
+		LDAMSG = `Building %s model for the current selections`
 		ESM1   = "Preparing the text for modeling"
 		ESM2   = "Building topic models"
 		ESM3   = "Building the graph (please be patient this can be very slow...)"
+		ESM4   = "Loading the stored %s model for the current selections"
+		ESM5   = "Building the topic profiles"
+		PROFS  = "%s; %d topics; documents: %s; text prep: %s"
 	)
 	c.Response().After(func() { Msg.LogPaths("LDASearch()") })
 
 	se := srch.StoredSession
-	ntopics := se.LDAtopics
-	if ntopics < 1 {
-		ntopics = vv.LDATOPICS
-	}
+	modeler, ntopics := sessiontopicmodel(se)
+	unit := sessiontopicunit(se)
 
-	var vs str.SearchStruct
+	// [a] a stored model for this selection and these settings? (the bot's search already knows what it searched)
+
+	fs := srch
 	if srch.ID != "ldamodelbot()" {
-		vs = search.SessionIntoBulkSearch(c, lnch.Config.VectorMaxlines)
-		vlt.WSInfo.UpdateRemain <- vlt.WSSIKVi{vs.WSID, 1}
-		vlt.WSInfo.UpdateSummMsg <- vlt.WSSIKVs{vs.WSID, LDAMSG}
-		vlt.WSInfo.UpdateVProgMsg <- vlt.WSSIKVs{vs.WSID, fmt.Sprintf(ESM1)}
-	} else {
-		vs = srch
+		sl := search.SessionIntoSearchlist(se)
+		fs.SearchIn = sl.Inc
+		fs.SearchEx = sl.Excl
 	}
+	fp := FingerprintLDAVectorSearch(fs, modeler, unit, ntopics)
 
-	bags := ldapreptext(se.VecTextPrep, &vs)
+	var vs str.SearchStruct
+	var bags []BagWithLocus
+	var vectoriser *nlp.CountVectoriser
+	var docsOverTopics, topicsOverWords mat.Matrix
 
-	corpus := make([]string, len(bags))
-	for i := 0; i < len(bags); i++ {
-		corpus[i] = bags[i].ModifiedBag
+	var tm storedtopicmodel
+	if VectorDBCheckLDA(fp) {
+		tm = VectorDBFetchLDA(fp)
 	}
 
-	stops := gen.StringMapKeysIntoSlice(getstopset())
-	vectoriser := nlp.NewCountVectoriser(stops...)
+	if !tm.empty() {
+		vs = srch
+		topicmodelcancellation(&vs)
+		vlt.WSInfo.UpdateSummMsg <- vlt.WSSIKVs{vs.WSID, fmt.Sprintf(ESM4, strings.ToUpper(modeler))}
+		bags, vectoriser, docsOverTopics, topicsOverWords = tm.unpack()
+	} else {
+		// [b] no: build one
 
-	vlt.WSInfo.UpdateSummMsg <- vlt.WSSIKVs{vs.WSID, fmt.Sprintf(ESM2)}
+		if srch.ID != "ldamodelbot()" {
+			vs = search.SessionIntoBulkSearch(c, lnch.Config.VectorMaxlines)
+			vlt.WSInfo.UpdateRemain <- vlt.WSSIKVi{vs.WSID, 1}
+			vlt.WSInfo.UpdateSummMsg <- vlt.WSSIKVs{vs.WSID, fmt.Sprintf(LDAMSG, strings.ToUpper(modeler))}
+			vlt.WSInfo.UpdateVProgMsg <- vlt.WSSIKVs{vs.WSID, fmt.Sprintf(ESM1)}
+		} else {
+			vs = srch
+		}
 
-	// consider building TESTITERATIONS models and making a table for each
-	var dot mat.Matrix
-	var tables []string
+		bags = ldapreptext(se.VecTextPrep, unit, &vs)
 
-	// a chance to bail if you hit RtResetSession() in time
-	if lnch.Config.SelfTest == 0 && !lnch.Config.VectorBot && !vlt.AllSessions.IsInVault(vs.User) {
-		// mm("LDASearch() aborting: RtResetSession switched user to "+vs.User, MSGFYI)
-		return gen.JSONresponse(c, str.SearchOutputJSON{})
-	}
+		stops := gen.StringMapKeysIntoSlice(getstopset())
+		vectoriser = nlp.NewCountVectoriser(stops...)
 
-	docsOverTopics, topicsOverWords, ok := ldamodel(ntopics, corpus, vectoriser, &vs)
-	if !ok {
-		return gen.JSONresponse(c, str.SearchOutputJSON{})
+		vlt.WSInfo.UpdateSummMsg <- vlt.WSSIKVs{vs.WSID, fmt.Sprintf(ESM2)}
+
+		// a chance to bail if you hit RtResetSession() in time
+		if lnch.Config.SelfTest == 0 && !lnch.Config.VectorBot && !vlt.AllSessions.IsInVault(vs.User) {
+			// mm("LDASearch() aborting: RtResetSession switched user to "+vs.User, MSGFYI)
+			return gen.JSONresponse(c, str.SearchOutputJSON{})
+		}
+
+		var ok bool
+		docsOverTopics, topicsOverWords, ok = topicmodel(modeler, ntopics, bagcorpus(bags), vectoriser, &vs)
+		if !ok {
+			return gen.JSONresponse(c, str.SearchOutputJSON{})
+		}
+
+		perp := topicperplexity(docsOverTopics, topicsOverWords, vectoriser, bagcorpus(bags))
+		VectorDBAddLDA(fp, packtopicmodel(modeler, se.VecTextPrep, unit, ntopics, bags, vectoriser, docsOverTopics, topicsOverWords, perp))
 	}
 
-	tables = append(tables, ldatopicsummary(ntopics, topicsOverWords, vectoriser, docsOverTopics))
+	corpus := bagcorpus(bags)
+
+	// consider building TESTITERATIONS models and making a table for each
+	var dot mat.Matrix
+	var tables []string
+
+	tables = append(tables, ldatopicsummary(modeler, ntopics, topicsOverWords, vectoriser, docsOverTopics, corpus))
 	tables = append(tables, ldatopsentences(ntopics, bags, corpus, docsOverTopics))
 	dot = docsOverTopics
 
@@ -149,6 +179,12 @@ func LDASearch(c echo.Context, srch str.SearchStruct) error {
 		img = ldaplot(vs.Context, se.LDA2D, ntopics, incl, se.VecTextPrep, dot, bags)
 	}
 
+	// the topic profiles of the works: cheap enough to draw whether or not the graph was asked for
+	vlt.WSInfo.UpdateSummMsg <- vlt.WSSIKVs{vs.WSID, ESM5}
+	pp, total := topicprofiles(unit, bags, docsOverTopics)
+	set := fmt.Sprintf(PROFS, topicmodelername(modeler), ntopics, topicunitname(unit), se.VecTextPrep)
+	img = img + topicheatmap(incl, set, pp, total) + topicdendrogram(incl, set, pp, total)
+
 	soj := str.SearchOutputJSON{
 		Title:         "",
 		Searchsummary: "",
@@ -163,9 +199,69 @@ func LDASearch(c echo.Context, srch str.SearchStruct) error {
 	return gen.JSONresponse(c, soj)
 }
 
-// ldapreptext - prepare the WorkLineBundle of a SearchStruct for lda analysis
-func ldapreptext(bagger string, vs *str.SearchStruct) []BagWithLocus {
+// bagcorpus - the modified bags are what the models see
+func bagcorpus(bags []BagWithLocus) []string {
+	corpus := make([]string, len(bags))
+	for i := 0; i < len(bags); i++ {
+		corpus[i] = bags[i].ModifiedBag
+	}
+	return corpus
+}
 
+// ldapreptext - prepare the WorkLineBundle of a SearchStruct for lda analysis: cut it into documents of the chosen
+// unit (see ldaunitbags()) and then run each through the chosen text prep
+func ldapreptext(bagger string, unit string, vs *str.SearchStruct) []BagWithLocus {
+	var thebags []BagWithLocus
+	if unit == "sentence" {
+		thebags = ldasentencebags(vs)
+	} else {
+		thebags = ldaunitbags(unit, vs)
+	}
+
+	allwords := make(map[string]bool, len(thebags))
+	for i := 0; i < len(thebags); i++ {
+		ww := strings.Split(thebags[i].Bag, " ")
+		for j := 0; j < len(ww); j++ {
+			allwords[ww[j]] = true
+		}
+	}
+
+	slicedwords := gen.StringMapKeysIntoSlice(allwords)
+	// catching resets
+	if lnch.Config.SelfTest == 0 && !lnch.Config.VectorBot && !vlt.AllSessions.IsInVault(vs.User) {
+		return []BagWithLocus{}
+	}
+
+	morphmapdbm := db.ArrayToGetRequiredMorphObjects(slicedwords) // map[string]DbMorphology
+	morphmapstrslc := buildmorphmapstrslc(slicedwords, morphmapdbm)
+
+	switch bagger {
+	case "unparsed":
+		thebags = ldaunmodifiedbagging(thebags)
+	case "yoked":
+		yokedmap := buildyokedparsemap(morphmapstrslc)
+		thebags = ldayokedbagging(thebags, yokedmap)
+	case "montecarlo":
+		mcm := buildmontecarloparsemap(morphmapstrslc)
+		thebags = ldamontecarlobagging(thebags, mcm)
+	case "contextual":
+		thebags = ldacontextualbagging(thebags, morphmapstrslc)
+	default:
+		// winner
+		winnermap := buildwinnertakesallparsemap(morphmapstrslc)
+		thebags = ldawinnerbagging(thebags, winnermap)
+	}
+
+	// catching resets
+	if lnch.Config.SelfTest == 0 && !lnch.Config.VectorBot && !vlt.AllSessions.IsInVault(vs.User) {
+		return []BagWithLocus{}
+	}
+
+	return thebags
+}
+
+// ldasentencebags - the lines of the selection cut into documents of SentencesPerBag sentences; cf. ldaunitbags()
+func ldasentencebags(vs *str.SearchStruct) []BagWithLocus {
 	var sb strings.Builder
 	preallocate := vv.CHARSPERLINE * vs.Results.Len() // NB: a long line has 60 chars
 	sb.Grow(preallocate)
@@ -208,9 +304,7 @@ func ldapreptext(bagger string, vs *str.SearchStruct) []BagWithLocus {
 	var first string
 	var last string
 
-	const tagger = `⊏(.*?)⊐`
-	const notachar = `[^\sa-zα-ωϲῥἀἁἂἃἄἅἆἇᾀᾁᾂᾃᾄᾅᾆᾇᾲᾳᾴᾶᾷᾰᾱὰάἐἑἒἓἔἕὲέἰἱἲἳἴἵἶἷὶίῐῑῒΐῖῗὀὁὂὃὄὅόὸὐὑὒὓὔὕὖὗϋῠῡῢΰῦῧύὺᾐᾑᾒᾓᾔᾕᾖᾗῂῃῄῆῇἤἢἥἣὴήἠἡἦἧὠὡὢὣὤὥὦὧᾠᾡᾢᾣᾤᾥᾦᾧῲῳῴῶῷώὼ]`
-	re := regexp.MustCompile(tagger)
+	re := regexp.MustCompile(LDATAGGER)
 
 	cfg := ldavecconfig()
 
@@ -229,7 +323,7 @@ func ldapreptext(bagger string, vs *str.SearchStruct) []BagWithLocus {
 		var sl BagWithLocus
 		sl.Loc = first
 		sl.Bag = strings.TrimSpace(strings.ToLower(parcel))
-		sl.Bag = stripper(sl.Bag, []string{tagger, notachar})
+		sl.Bag = stripper(sl.Bag, []string{LDATAGGER, LDANOTACHAR})
 
 		thebags = append(thebags, sl)
 
@@ -239,43 +333,6 @@ func ldapreptext(bagger string, vs *str.SearchStruct) []BagWithLocus {
 		//{line/lt0959w014/34506  uitulus sic namque minatur qui nondum gerit in tenera iam cornua fronte sic dammae fugiunt pugnant uirtute leones et morsu canis et caudae sic scorpios ictu concussisque leuis pinnis sic euolat ales }
 	}
 
-	allwords := make(map[string]bool, len(thebags))
-	for i := 0; i < len(thebags); i++ {
-		ww := strings.Split(thebags[i].Bag, " ")
-		for j := 0; j < len(ww); j++ {
-			allwords[ww[j]] = true
-		}
-	}
-
-	slicedwords := gen.StringMapKeysIntoSlice(allwords)
-	// catching resets
-	if lnch.Config.SelfTest == 0 && !lnch.Config.VectorBot && !vlt.AllSessions.IsInVault(vs.User) {
-		return []BagWithLocus{}
-	}
-
-	morphmapdbm := db.ArrayToGetRequiredMorphObjects(slicedwords) // map[string]DbMorphology
-	morphmapstrslc := buildmorphmapstrslc(slicedwords, morphmapdbm)
-
-	switch bagger {
-	case "unparsed":
-		thebags = ldaunmodifiedbagging(thebags)
-	case "yoked":
-		yokedmap := buildyokedparsemap(morphmapstrslc)
-		thebags = ldayokedbagging(thebags, yokedmap)
-	case "montecarlo":
-		mcm := buildmontecarloparsemap(morphmapstrslc)
-		thebags = ldamontecarlobagging(thebags, mcm)
-	default:
-		// winner
-		winnermap := buildwinnertakesallparsemap(morphmapstrslc)
-		thebags = ldawinnerbagging(thebags, winnermap)
-	}
-
-	// catching resets
-	if lnch.Config.SelfTest == 0 && !lnch.Config.VectorBot && !vlt.AllSessions.IsInVault(vs.User) {
-		return []BagWithLocus{}
-	}
-
 	return thebags
 }
 
@@ -325,6 +382,24 @@ func ldamontecarlobagging(thebags []BagWithLocus, montecarlo map[string]hwguesse
 	return thebags
 }
 
+// ldacontextualbagging - lda headwords chosen by context text bagger; what is learned from one bag serves all of them
+func ldacontextualbagging(thebags []BagWithLocus, parsemap map[string]map[string]bool) []BagWithLocus {
+	seqs := make([][]string, len(thebags))
+	for i := 0; i < len(thebags); i++ {
+		seqs[i] = strings.Split(thebags[i].Bag, " ")
+	}
+
+	hc := buildcontextualparsemap(parsemap, seqs)
+
+	stops := getstopset()
+	for i := 0; i < len(thebags); i++ {
+		var b strings.Builder
+		contextualstring(&b, seqs[i], hc, stops)
+		thebags[i].ModifiedBag = b.String()
+	}
+	return thebags
+}
+
 // ldamodel - build the lda model for the corpus
 func ldamodel(topics int, corpus []string, vectoriser *nlp.CountVectoriser, s *str.SearchStruct) (mat.Matrix, mat.Matrix, bool) {
 	const (
@@ -370,7 +445,9 @@ func ldamodel(topics int, corpus []string, vectoriser *nlp.CountVectoriser, s *s
 // ldatopsentences - generate html table reporting sentences most associated with each topic
 func ldatopsentences(ntopics int, thebags []BagWithLocus, corpus []string, docsOverTopics mat.Matrix) string {
 	const (
-		NTH = 2
+		NTH      = 2
+		MAXWORDS = 60 // a whole work or a chunk of words is too much to show
+		ELLIPSIS = " …"
 
 		FULLTABLE = `
 	<table class="ldasentences"><tbody>
@@ -455,7 +532,11 @@ func ldatopsentences(ntopics int, thebags []BagWithLocus, corpus []string, docsO
 		wl := w.Workline
 		au := stripbold.Replace(mps.AllAuthors[wl.AuID()].IDXname)
 		cit := fmt.Sprintf(tp, au, mps.AllWorks[wl.WkUID].Title, wl.Citation())
-		r := fmt.Sprintf(TABLEELEM, i+1, w.LDAScore, cit, w.Bag)
+		bag := w.Bag
+		if ww := strings.Fields(bag); len(ww) > MAXWORDS {
+			bag = strings.Join(ww[:MAXWORDS], " ") + ELLIPSIS
+		}
+		r := fmt.Sprintf(TABLEELEM, i+1, w.LDAScore, cit, bag)
 		tablecolumn = append(tablecolumn, r)
 	}
 
@@ -474,8 +555,8 @@ func ldatopsentences(ntopics int, thebags []BagWithLocus, corpus []string, docsO
 	return tableout
 }
 
-// ldatopicsummary - html table that reports on top words and topic weights in the model
-func ldatopicsummary(ntopics int, topicsOverWords mat.Matrix, vectoriser *nlp.CountVectoriser, docsOverTopics mat.Matrix) string {
+// ldatopicsummary - html table that reports on top words, topic weights, and topic coherence in the model
+func ldatopicsummary(modeler string, ntopics int, topicsOverWords mat.Matrix, vectoriser *nlp.CountVectoriser, docsOverTopics mat.Matrix, corpus []string) string {
 	const (
 		TOPN = 8
 		NTH  = 2
@@ -488,15 +569,19 @@ func ldatopicsummary(ntopics int, topicsOverWords mat.Matrix, vectoriser *nlp.Co
 
 		TABLETOP = `
     <tr class="vectorrow">
-        <td class="vectorrank" colspan = "4">Topic model of selection via Latent Dirichlet Allocation</td>
+        <td class="vectorrank" colspan = "5">Topic model of selection via %s</td>
     </tr>
 	<tr class="vectorrow">
 		<td class="vectorrank">Topic</td>
 		<td class="vectorrank">Top %d words associated with each topic</td>
 		<td class="vectorrank"># of sentences with topic N as their dominant topic</td>
 		<td class="vectorrank">scaled total accumulated weight of each topic</td>
+		<td class="vectorrank">coherence of the top %d words</td>
 	</tr>
-    %s`
+    %s
+    <tr class="vectorrow">
+        <td class="vectorrank small" colspan = "5">mean coherence: %.3f (UMass: higher is more coherent; compare models of the same selection only)</td>
+    </tr>`
 
 		TABLEROW = `
 	<tr class="%s">%s
@@ -506,10 +591,12 @@ func ldatopicsummary(ntopics int, topicsOverWords mat.Matrix, vectoriser *nlp.Co
 		<td class="vectorrank">%d</td>
 		<td class="vectorsent">%s</td>
 		<td class="vectorsent">%d (%.2f%%)</td>
-		<td class="vectorsent">%.2f%%</td>`
+		<td class="vectorsent">%.2f%%</td>
+		<td class="vectorsent">%.3f</td>`
 	)
 
 	tops := ldasortedtopics(ntopics, topicsOverWords, vectoriser)
+	coherence := topiccoherence(topicwords(topicsOverWords, vectoriser, vv.LDACOHERENCETOPN), vectoriser, corpus)
 	docspertopic := ldadocpertopic(ntopics, docsOverTopics)
 	docsbyweight := ldadocbyweight(ntopics, docsOverTopics)
 
@@ -530,7 +617,7 @@ func ldatopicsummary(ntopics int, topicsOverWords mat.Matrix, vectoriser *nlp.Co
 			ww[i] = ts[i].W
 		}
 		data := strings.Join(ww, ", ")
-		r := fmt.Sprintf(TABLEELEM, topic+1, data, docspertopic[topic], float64(docspertopic[topic])/float64(dc)*100, docsbyweight[topic]*100)
+		r := fmt.Sprintf(TABLEELEM, topic+1, data, docspertopic[topic], float64(docspertopic[topic])/float64(dc)*100, docsbyweight[topic]*100, coherence[topic])
 		tablecolumn = append(tablecolumn, r)
 	}
 
@@ -543,7 +630,15 @@ func ldatopicsummary(ntopics int, topicsOverWords mat.Matrix, vectoriser *nlp.Co
 		tablerows = append(tablerows, fmt.Sprintf(TABLEROW, rn, tablecolumn[i]))
 	}
 
-	tableout := fmt.Sprintf(TABLETOP, topn, strings.Join(tablerows, "\n"))
+	mean := 0.0
+	for _, ch := range coherence {
+		mean += ch
+	}
+	if len(coherence) > 0 {
+		mean = mean / float64(len(coherence))
+	}
+
+	tableout := fmt.Sprintf(TABLETOP, topicmodelername(modeler), topn, vv.LDACOHERENCETOPN, strings.Join(tablerows, "\n"), mean)
 	tableout = fmt.Sprintf(FULLTABLE, tableout)
 	return tableout
 }
@@ -564,7 +659,13 @@ func ldasortedtopics(ntopics int, topicsOverWords mat.Matrix, vectoriser *nlp.Co
 		top = ntopics
 	}
 
+	return topicwords(topicsOverWords, vectoriser, top)
+}
+
+// topicwords - the n most significant words for each topic
+func topicwords(topicsOverWords mat.Matrix, vectoriser *nlp.CountVectoriser, n int) map[int][]topicsorter {
 	tr, tc := topicsOverWords.Dims()
+	n = min(n, tc)
 
 	vocab := make([]string, len(vectoriser.Vocabulary))
 	for k, v := range vectoriser.Vocabulary {
@@ -583,7 +684,7 @@ func ldasortedtopics(ntopics int, topicsOverWords mat.Matrix, vectoriser *nlp.Co
 		sort.Slice(tss, func(i, j int) bool {
 			return tss[i].V > tss[j].V
 		})
-		tops[topic] = tss[0:top]
+		tops[topic] = tss[0:n]
 	}
 	return tops
 }
@@ -719,6 +820,11 @@ func ldaplot(ctx context.Context, graph2d bool, ntopics int, incl string, bagger
 // CLEANING
 //
 
+const (
+	LDATAGGER   = `⊏(.*?)⊐`
+	LDANOTACHAR = `[^\sa-zα-ωϲῥἀἁἂἃἄἅἆἇᾀᾁᾂᾃᾄᾅᾆᾇᾲᾳᾴᾶᾷᾰᾱὰάἐἑἒἓἔἕὲέἰἱἲἳἴἵἶἷὶίῐῑῒΐῖῗὀὁὂὃὄὅόὸὐὑὒὓὔὕὖὗϋῠῡῢΰῦῧύὺᾐᾑᾒᾓᾔᾕᾖᾗῂῃῄῆῇἤἢἥἣὴήἠἡἦἧὠὡὢὣὤὥὦὧᾠᾡᾢᾣᾤᾥᾦᾧῲῳῴῶῷώὼ]`
+)
+
 // stripper - delete each in a list of items from a string
 func stripper(item string, purge []string) string {
 	for i := 0; i < len(purge); i++ {
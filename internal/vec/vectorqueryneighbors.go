@@ -41,6 +41,9 @@ func NeighborsSearch(c echo.Context, srch str.SearchStruct) error {
     %s
     <tr class="vectorrow">
         <td class="vectorrank small" colspan = "7">(model type: <code>%s</code>; text prep: <code>%s</code>)</td>
+    </tr>
+    <tr class="vectorrow">
+        <td class="vectorrank small" colspan = "7">export this model: <a href="/vect/export/w2vtxt">word2vec text</a> &middot; <a href="/vect/export/w2vbin">word2vec binary</a> &middot; <a href="/vect/export/glove">GloVe</a> &middot; <a href="/vect/export/meta">settings</a></td>
     </tr>
 	</tbody></table>
 	<hr>`
@@ -61,6 +64,13 @@ func NeighborsSearch(c echo.Context, srch str.SearchStruct) error {
 	c.Response().After(func() { Msg.LogPaths("NeighborsSearch()") })
 	sess := srch.StoredSession
 
+	// maybe this is not a neighbors search at all: "a ~ b", "b - a + c", "a | b | c"
+	for _, q := range []string{srch.Seeking, srch.LemmaOne} {
+		if vq, ok := ParseVectorRelationQuery(q); ok {
+			return RelationsSearch(c, srch, vq)
+		}
+	}
+
 	term := srch.LemmaOne
 	if term == "" {
 		// JS not supposed to let this happen, but...
@@ -131,35 +141,9 @@ func FingerprintNNVectorSearch(srch str.SearchStruct) string {
 
 	// unless you sort, you do not get repeatable results with a md5sum of srch.SearchIn if you look at "all latin"
 
-	var fp []string
-
 	// [1] start with the searchlist + the stoplists + VecTextPrep (which are all collections of strings + one string)
 
-	// includes
-	fp = append(fp, srch.SearchIn.AuGenres...)
-	fp = append(fp, srch.SearchIn.WkGenres...)
-	fp = append(fp, srch.SearchIn.AuLocations...)
-	fp = append(fp, srch.SearchIn.WkLocations...)
-	fp = append(fp, srch.SearchIn.Authors...)
-	fp = append(fp, srch.SearchIn.Works...)
-	fp = append(fp, srch.SearchIn.Passages...)
-
-	// excludes
-	fp = append(fp, srch.SearchEx.AuGenres...)
-	fp = append(fp, srch.SearchEx.WkGenres...)
-	fp = append(fp, srch.SearchEx.AuLocations...)
-	fp = append(fp, srch.SearchEx.WkLocations...)
-	fp = append(fp, srch.SearchEx.Authors...)
-	fp = append(fp, srch.SearchEx.Works...)
-	fp = append(fp, srch.SearchEx.Passages...)
-
-	// stops
-	fp = append(fp, readstopconfig("greek")...)
-	fp = append(fp, readstopconfig("latin")...)
-
-	// one last item...
-	fp = append(fp, srch.VecTextPrep)
-	slices.Sort(fp)
+	fp := fingerprintselection(srch)
 
 	f1, e1 := json.Marshal(fp)
 
@@ -169,6 +153,11 @@ func FingerprintNNVectorSearch(srch str.SearchStruct) string {
 	var e2 error
 
 	switch srch.VecModeler {
+	case "imported":
+		// there are no settings to record: the model came from somewhere else; see RtVectorImport()
+		ff, ee := json.Marshal(srch.VecModeler)
+		f2 = ff
+		e2 = ee
 	case "glove":
 		ff, ee := json.Marshal(glovevectorconfig())
 		f2 = ff
@@ -199,3 +188,41 @@ func FingerprintNNVectorSearch(srch str.SearchStruct) string {
 
 	return m
 }
+
+// fingerprintselection - the searchlist + the stoplists + VecTextPrep, sorted: the part of a fingerprint that every kind
+// of stored model shares
+func fingerprintselection(srch str.SearchStruct) []string {
+	var fp []string
+
+	// includes
+	fp = append(fp, srch.SearchIn.AuGenres...)
+	fp = append(fp, srch.SearchIn.WkGenres...)
+	fp = append(fp, srch.SearchIn.AuLocations...)
+	fp = append(fp, srch.SearchIn.WkLocations...)
+	fp = append(fp, srch.SearchIn.DcLocations...)
+	fp = append(fp, srch.SearchIn.Authors...)
+	fp = append(fp, srch.SearchIn.Works...)
+	fp = append(fp, srch.SearchIn.Passages...)
+
+	// excludes
+	fp = append(fp, srch.SearchEx.AuGenres...)
+	fp = append(fp, srch.SearchEx.WkGenres...)
+	fp = append(fp, srch.SearchEx.AuLocations...)
+	fp = append(fp, srch.SearchEx.WkLocations...)
+	fp = append(fp, srch.SearchEx.DcLocations...)
+	fp = append(fp, srch.SearchEx.Authors...)
+	fp = append(fp, srch.SearchEx.Works...)
+	fp = append(fp, srch.SearchEx.Passages...)
+
+	// stops
+	fp = append(fp, readstopconfig("greek")...)
+	fp = append(fp, readstopconfig("latin")...)
+
+	// one last item... (plus its revision if the text that it yields has changed: older models cannot be reused)
+	fp = append(fp, srch.VecTextPrep)
+	if r, ok := textpreprevisions[srch.VecTextPrep]; ok {
+		fp = append(fp, r)
+	}
+	slices.Sort(fp)
+	return fp
+}
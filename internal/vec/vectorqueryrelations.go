@@ -0,0 +1,356 @@
+//    HipparchiaGoServer
+//    Copyright: E Gunderson 2022-24
+//    License: GNU GENERAL PUBLIC LICENSE 3
+//        (see LICENSE in the top level directory of the distribution)
+
+package vec
+
+import (
+	"fmt"
+	"github.com/e-gun/HipparchiaGoServer/internal/base/gen"
+	"github.com/e-gun/HipparchiaGoServer/internal/base/str"
+	sr "github.com/e-gun/HipparchiaGoServer/internal/search"
+	"github.com/e-gun/HipparchiaGoServer/internal/vlt"
+	"github.com/e-gun/HipparchiaGoServer/internal/vv"
+	"github.com/e-gun/wego/pkg/embedding"
+	"github.com/e-gun/wego/pkg/embedding/embutil"
+	"github.com/e-gun/wego/pkg/search"
+	"github.com/e-gun/wego/pkg/search/searchutil"
+	"github.com/labstack/echo/v4"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+//
+// SIMILARITY, ANALOGY, AND ODD-ONE-OUT QUERIES AGAINST A NEIGHBORS MODEL
+//
+
+// the search box (with the vector search option on) or the "q" of "/vect/query/:id" can hold:
+//	"a ~ b"           similarity of a and b; "a ~ b ~ c ..." is every pair
+//	"b - a + c"       analogy: a is to b as c is to ?; in general any sum of words: "+" is optional ("b - a c")
+//	"a | b | c | d"   which of these does not belong?
+// anything else is a word whose neighbors you want: see NeighborsSearch()
+
+// VectorRelationQuery - a parsed similarity, analogy, or odd-one-out request
+type VectorRelationQuery struct {
+	Kind     string   `json:"kind"` // "similarity", "analogy", or "oddoneout"
+	Query    string   `json:"query"`
+	Words    []string `json:"words"`
+	Positive []string `json:"positive,omitempty"`
+	Negative []string `json:"negative,omitempty"`
+}
+
+// VectorPair - the similarity of two words
+type VectorPair struct {
+	A          string  `json:"a"`
+	B          string  `json:"b"`
+	Similarity float64 `json:"similarity"`
+}
+
+// VectorScore - a word and a score
+type VectorScore struct {
+	Rank       int     `json:"rank"`
+	Word       string  `json:"word"`
+	Similarity float64 `json:"similarity"`
+}
+
+// VectorRelations - the answer to a VectorRelationQuery
+type VectorRelations struct {
+	VectorRelationQuery
+	Modeler  string        `json:"modeler"`
+	TextPrep string        `json:"textprep"`
+	Missing  []string      `json:"missing"`
+	Pairs    []VectorPair  `json:"pairs,omitempty"`
+	Results  []VectorScore `json:"results,omitempty"`
+	Odd      string        `json:"odd,omitempty"`
+}
+
+// ParseVectorRelationQuery - is this a similarity, analogy, or odd-one-out request? if not it is a neighbors request
+func ParseVectorRelationQuery(q string) (VectorRelationQuery, bool) {
+	q = strings.TrimSpace(sr.RestoreWhiteSpace(q))
+	vq := VectorRelationQuery{Query: q}
+
+	clean := func(w string) string {
+		return gen.RestoreInitialVJ(strings.ToLower(strings.TrimSpace(w)))
+	}
+
+	split := func(sep string) []string {
+		var ww []string
+		for _, w := range strings.Split(q, sep) {
+			if cw := clean(w); cw != "" {
+				ww = append(ww, cw)
+			}
+		}
+		return ww
+	}
+
+	switch {
+	case strings.Contains(q, "~"):
+		vq.Kind = "similarity"
+		vq.Words = split("~")
+		return vq, len(vq.Words) > 1
+	case strings.Contains(q, "|"):
+		vq.Kind = "oddoneout"
+		vq.Words = split("|")
+		return vq, len(vq.Words) > 2
+	}
+
+	// "-" and "+" only count as operators when they stand alone: "re-pono" is a word
+	ff := strings.Fields(q)
+	if !strings.Contains(" "+q+" ", " - ") || len(ff) < 3 {
+		return vq, false
+	}
+
+	vq.Kind = "analogy"
+	neg := false
+	for _, f := range ff {
+		switch f {
+		case "-":
+			neg = true
+		case "+":
+			neg = false
+		default:
+			w := clean(f)
+			vq.Words = append(vq.Words, w)
+			if neg {
+				vq.Negative = append(vq.Negative, w)
+			} else {
+				vq.Positive = append(vq.Positive, w)
+			}
+			neg = false
+		}
+	}
+
+	return vq, len(vq.Positive) > 0 && len(vq.Negative) > 0
+}
+
+// RelationsQuery - answer a VectorRelationQuery with a set of embeddings
+func RelationsQuery(embs embedding.Embeddings, vq VectorRelationQuery, k int) VectorRelations {
+	vr := VectorRelations{VectorRelationQuery: vq}
+
+	found := make(map[string]embedding.Embedding)
+	var present []string
+	for _, w := range vq.Words {
+		if e, ok := embs.Find(w); ok {
+			found[w] = e
+			present = append(present, w)
+		} else {
+			vr.Missing = append(vr.Missing, w)
+		}
+	}
+
+	cos := func(a string, b string) float64 {
+		return searchutil.Cosine(found[a].Vector, found[b].Vector, found[a].Norm, found[b].Norm)
+	}
+
+	switch vq.Kind {
+	case "similarity":
+		for i := 0; i < len(present); i++ {
+			for j := i + 1; j < len(present); j++ {
+				vr.Pairs = append(vr.Pairs, VectorPair{A: present[i], B: present[j], Similarity: cos(present[i], present[j])})
+			}
+		}
+	case "oddoneout":
+		if len(present) < 3 {
+			break
+		}
+		// the mean similarity of each word to all of the others: the lowest does not belong
+		for _, a := range present {
+			t := 0.0
+			for _, b := range present {
+				if a != b {
+					t += cos(a, b)
+				}
+			}
+			vr.Results = append(vr.Results, VectorScore{Word: a, Similarity: t / float64(len(present)-1)})
+		}
+		sort.SliceStable(vr.Results, func(i, j int) bool { return vr.Results[i].Similarity > vr.Results[j].Similarity })
+		for i := range vr.Results {
+			vr.Results[i].Rank = i + 1
+		}
+		vr.Odd = vr.Results[len(vr.Results)-1].Word
+	case "analogy":
+		if len(vr.Missing) > 0 || len(embs) == 0 {
+			break
+		}
+		// 3CosAdd: sum the unit vectors; then look for the nearest words that are not part of the query
+		q := make([]float64, embs[0].Dim)
+		add := func(w string, sign float64) {
+			e := found[w]
+			for i, v := range e.Vector {
+				if e.Norm != 0 {
+					q[i] += sign * v / e.Norm
+				}
+			}
+		}
+		for _, w := range vq.Positive {
+			add(w, 1)
+		}
+		for _, w := range vq.Negative {
+			add(w, -1)
+		}
+
+		s := search.Searcher{Items: embs}
+		nn, err := s.Search(embedding.Embedding{Vector: q, Norm: embutil.Norm(q)}, k, vq.Words...)
+		if err != nil {
+			Msg.FYI("RelationsQuery() failed to search the model")
+			break
+		}
+		for _, n := range nn {
+			if n.Word != "" && !math.IsNaN(n.Similarity) {
+				vr.Results = append(vr.Results, VectorScore{Rank: int(n.Rank), Word: n.Word, Similarity: n.Similarity})
+			}
+		}
+	}
+
+	return vr
+}
+
+// RelationsSearch - a special case for NeighborsSearch() where the search box held a similarity, analogy, or
+// odd-one-out request
+func RelationsSearch(c echo.Context, srch str.SearchStruct, vq VectorRelationQuery) error {
+	const (
+		NTH      = 3
+		THETABLE = `
+	<table class="vectortable"><tbody>
+    <tr class="vectorrow">
+        <td class="vectorrank" colspan = "4">%s</td>
+    </tr>
+	<tr class="vectorrow">
+		%s
+	</tr>
+    %s
+    %s
+    <tr class="vectorrow">
+        <td class="vectorrank small" colspan = "4">(model type: <code>%s</code>; text prep: <code>%s</code>)</td>
+    </tr>
+	</tbody></table>
+	<hr>`
+		HEADCELL = `<td class="vectorrank">%s</td>`
+		WORD     = `<vectorheadword id="%s">%s</vectorheadword>`
+		PAIRROW  = `
+	<tr class="%s">
+		<td class="vectorword">%s</td>
+		<td class="vectorword">%s</td>
+		<td class="vectorscore">%.4f</td>
+	</tr>`
+		SCOREROW = `
+	<tr class="%s">
+		<td class="vectorrank">%d</td>
+		<td class="vectorword">%s</td>
+		<td class="vectorscore">%.4f</td>
+	</tr>`
+		MISSING = `<tr class="vectorrow"><td class="vectorrank small" colspan = "4">not in the model: %s</td></tr>`
+		SIMHEAD = "Similarity of »<span class=\"colorhighlight\">%s</span>«"
+		ANAHEAD = "»<span class=\"colorhighlight\">%s</span>« is to »<span class=\"colorhighlight\">%s</span>« as »<span class=\"colorhighlight\">%s</span>« is to ..."
+		SUMHEAD = "Nearest neighbors of »<span class=\"colorhighlight\">%s</span>«"
+		ODDHEAD = "»<span class=\"colorhighlight\">%s</span>« does not belong with %s"
+		NOODD   = "Which does not belong? (at least three of the words need to be in the model)"
+		ODDMARK = `<span class="red">%s</span>`
+		NOTHING = `<tr class="vectorrow"><td class="vectorrank small" colspan = "4">(no results)</td></tr>`
+		TITLE   = "Vectors: '%s'"
+	)
+
+	c.Response().After(func() { Msg.LogPaths("RelationsSearch()") })
+	sess := srch.StoredSession
+
+	embs := fetchorgenerateembeddings(c, srch)
+	vr := RelationsQuery(embs, vq, neighborcount(sess))
+
+	rowclass := func(i int) string {
+		if i%NTH == 0 {
+			return "nthrow"
+		}
+		return "vectorrow"
+	}
+
+	word := func(w string) string {
+		return fmt.Sprintf(WORD, w, w)
+	}
+
+	var head, cols string
+	var rows []string
+	switch vr.Kind {
+	case "similarity":
+		head = fmt.Sprintf(SIMHEAD, strings.Join(vr.Words, "« ~ »"))
+		cols = fmt.Sprintf(HEADCELL, "Word") + fmt.Sprintf(HEADCELL, "Word") + fmt.Sprintf(HEADCELL, "Similarity")
+		for i, p := range vr.Pairs {
+			rows = append(rows, fmt.Sprintf(PAIRROW, rowclass(i), word(p.A), word(p.B), p.Similarity))
+		}
+	case "oddoneout":
+		head = NOODD
+		if vr.Odd != "" {
+			var others []string
+			for _, r := range vr.Results {
+				if r.Word != vr.Odd {
+					others = append(others, "»"+r.Word+"«")
+				}
+			}
+			head = fmt.Sprintf(ODDHEAD, vr.Odd, strings.Join(others, ", "))
+		}
+		cols = fmt.Sprintf(HEADCELL, "Rank") + fmt.Sprintf(HEADCELL, "Word") + fmt.Sprintf(HEADCELL, "Mean similarity to the others")
+		for i, r := range vr.Results {
+			w := word(r.Word)
+			if r.Word == vr.Odd {
+				w = fmt.Sprintf(ODDMARK, w)
+			}
+			rows = append(rows, fmt.Sprintf(SCOREROW, rowclass(i), r.Rank, w, r.Similarity))
+		}
+	default:
+		// "analogy"
+		if len(vr.Negative) == 1 && len(vr.Positive) == 2 {
+			head = fmt.Sprintf(ANAHEAD, vr.Negative[0], vr.Positive[0], vr.Positive[1])
+		} else {
+			head = fmt.Sprintf(SUMHEAD, vr.Query)
+		}
+		cols = fmt.Sprintf(HEADCELL, "Rank") + fmt.Sprintf(HEADCELL, "Word") + fmt.Sprintf(HEADCELL, "Similarity")
+		for i, r := range vr.Results {
+			rows = append(rows, fmt.Sprintf(SCOREROW, rowclass(i), r.Rank, word(r.Word), r.Similarity))
+		}
+	}
+
+	if len(rows) == 0 {
+		rows = append(rows, NOTHING)
+	}
+
+	miss := ""
+	if len(vr.Missing) > 0 {
+		miss = fmt.Sprintf(MISSING, strings.Join(vr.Missing, ", "))
+	}
+
+	out := fmt.Sprintf(THETABLE, head, cols, strings.Join(rows, "\n"), miss, sess.VecModeler, sess.VecTextPrep)
+
+	soj := str.SearchOutputJSON{
+		Title:         fmt.Sprintf(TITLE, vr.Query),
+		Searchsummary: "",
+		Found:         out,
+		Image:         "",
+		JS:            vv.VECTORJS,
+	}
+
+	vlt.WSInfo.Del <- srch.WSID
+	return gen.JSONresponse(c, soj)
+}
+
+// RelationsJSON - the JSON API version of RelationsSearch(): "/vect/query/5e1d3a7f?q=rex - vir + femina"
+func RelationsJSON(c echo.Context, srch str.SearchStruct, q string) error {
+	const (
+		FAIL = "not a similarity, analogy, or odd-one-out query: %s"
+	)
+
+	vq, ok := ParseVectorRelationQuery(q)
+	if !ok {
+		vlt.WSInfo.Del <- srch.WSID
+		return c.String(http.StatusBadRequest, fmt.Sprintf(FAIL, q))
+	}
+
+	embs := fetchorgenerateembeddings(c, srch)
+	vr := RelationsQuery(embs, vq, neighborcount(srch.StoredSession))
+	vr.Modeler = srch.VecModeler
+	vr.TextPrep = srch.VecTextPrep
+
+	vlt.WSInfo.Del <- srch.WSID
+	return c.JSONPretty(http.StatusOK, vr, vv.JSONINDENT)
+}
@@ -0,0 +1,249 @@
+//    HipparchiaGoServer
+//    Copyright: E Gunderson 2022-24
+//    License: GNU GENERAL PUBLIC LICENSE 3
+//        (see LICENSE in the top level directory of the distribution)
+
+package vec
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/e-gun/HipparchiaGoServer/internal/base/str"
+	"github.com/e-gun/HipparchiaGoServer/internal/db"
+	"github.com/e-gun/HipparchiaGoServer/internal/lnch"
+	sr "github.com/e-gun/HipparchiaGoServer/internal/search"
+	"github.com/e-gun/HipparchiaGoServer/internal/vv"
+	"github.com/jackc/pgx/v5"
+	"strings"
+	"time"
+)
+
+//
+// THE REGISTRY: what is in vv.VECTORTABLENAMENN?
+//
+
+// the registry is keyed to the fingerprint and is filled in from two directions: GenerateVectEmbeddings() (or
+// RtVectorImport()) knows what went into a model; VectorDBAddNN() knows when it was stored; the size of a model
+// is not kept here: it is the "vectorsize" of its row in vv.VECTORTABLENAMENN
+
+// models stored before there was a registry will list with their size but nothing else
+
+const (
+	REGISTRYSELECT = `
+			SELECT n.fingerprint, COALESCE(r.selection, ''), COALESCE(r.modeler, ''), COALESCE(r.textprep, ''),
+				COALESCE(r.settings, ''), COALESCE(r.lines, 0), COALESCE(r.buildsecs, 0)::float8, r.built, r.lastused,
+				n.vectorsize
+			FROM %s n LEFT JOIN %s r ON n.fingerprint = r.fingerprint`
+)
+
+// NNRegistryEntry - what we know about one stored model
+type NNRegistryEntry struct {
+	Fingerprint string     `json:"fingerprint"`
+	Selection   string     `json:"selection"`
+	Modeler     string     `json:"modeler"`
+	TextPrep    string     `json:"textprep"`
+	Settings    string     `json:"settings"`
+	Lines       int        `json:"lines"`
+	BuildSecs   float64    `json:"buildseconds"`
+	Built       *time.Time `json:"built"`
+	LastUsed    *time.Time `json:"lastused"`
+	Bytes       int        `json:"bytes"`
+}
+
+// VectorDBInitRegistry - initialize vv.VECTORTABLENAMEREG
+func VectorDBInitRegistry() {
+	const (
+		CREATE = `
+			CREATE TABLE %s
+			(
+			  fingerprint character(32) PRIMARY KEY,
+			  selection   text,
+			  modeler     text,
+			  textprep    text,
+			  settings    text,
+			  lines       int,
+			  buildsecs   real,
+			  built       timestamp,
+			  lastused    timestamp
+			)`
+		EXISTS = "already exists"
+	)
+	ex := fmt.Sprintf(CREATE, vv.VECTORTABLENAMEREG)
+	_, err := db.SQLPool.Exec(context.Background(), ex)
+	if err != nil {
+		m := err.Error()
+		if !strings.Contains(m, EXISTS) {
+			dbi.EC(err)
+		}
+	} else {
+		Msg.FYI("VectorDBInitRegistry(): success")
+	}
+}
+
+// VectorRegistryNoteBuild - record what went into the model with this fingerprint
+func VectorRegistryNoteBuild(fp string, s str.SearchStruct, modeler string, lines int, took time.Duration) {
+	const (
+		UPS = `
+			INSERT INTO %s
+				(fingerprint, selection, modeler, textprep, settings, lines, buildsecs, built, lastused)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $8)
+			ON CONFLICT (fingerprint) DO UPDATE SET
+				selection = EXCLUDED.selection, modeler = EXCLUDED.modeler, textprep = EXCLUDED.textprep,
+				settings = EXCLUDED.settings, lines = EXCLUDED.lines, buildsecs = EXCLUDED.buildsecs,
+				built = EXCLUDED.built, lastused = EXCLUDED.lastused`
+	)
+
+	set, err := json.Marshal(NNModelSettings(modeler))
+	dbi.EC(err)
+
+	sel := sr.InclusionOverview(&s, s.StoredSession.Inclusions)
+	now := time.Now()
+
+	_, err = db.SQLPool.Exec(context.Background(), fmt.Sprintf(UPS, vv.VECTORTABLENAMEREG), fp, sel, modeler,
+		s.VecTextPrep, string(set), lines, took.Seconds(), now)
+	dbi.EC(err)
+}
+
+// registrystored - make sure that a freshly stored model has an entry; then enforce the caps
+func registrystored(fp string) {
+	const (
+		UPS = `
+			INSERT INTO %s (fingerprint, lastused) VALUES ($1, $2)
+			ON CONFLICT (fingerprint) DO UPDATE SET lastused = EXCLUDED.lastused`
+	)
+
+	_, err := db.SQLPool.Exec(context.Background(), fmt.Sprintf(UPS, vv.VECTORTABLENAMEREG), fp, time.Now())
+	dbi.EC(err)
+
+	if lnch.Config.VectorCapModels > 0 || lnch.Config.VectorCapMB > 0 {
+		VectorRegistryEvict(lnch.Config.VectorCapModels, lnch.Config.VectorCapMB, fp)
+	}
+}
+
+// registryused - a model was fetched: it is now the most recently used
+func registryused(fp string) {
+	const (
+		UPD = `UPDATE %s SET lastused = $1 WHERE fingerprint = $2`
+	)
+
+	_, err := db.SQLPool.Exec(context.Background(), fmt.Sprintf(UPD, vv.VECTORTABLENAMEREG), time.Now(), fp)
+	dbi.EC(err)
+}
+
+// VectorRegistryList - every stored model, most recently used first; models without a registry entry come last
+func VectorRegistryList() []NNRegistryEntry {
+	const (
+		Q   = REGISTRYSELECT + ` ORDER BY r.lastused DESC NULLS LAST`
+		DNE = "does not exist"
+	)
+
+	q := fmt.Sprintf(Q, vv.VECTORTABLENAMENN, vv.VECTORTABLENAMEREG)
+	foundrows, err := db.SQLPool.Query(context.Background(), q)
+	if err != nil {
+		m := err.Error()
+		if strings.Contains(m, DNE) {
+			VectorDBInitNN()
+		} else {
+			dbi.EC(err)
+		}
+		return []NNRegistryEntry{}
+	}
+
+	entries, err := pgx.CollectRows(foundrows, pgx.RowToStructByPos[NNRegistryEntry])
+	dbi.EC(err)
+
+	return entries
+}
+
+// VectorRegistryEvict - drop the least recently used models until there are no more than maxmodels of them and they
+// take up no more than maxmb; a cap of 0 is no cap; "keep" is never dropped; returns the number dropped
+func VectorRegistryEvict(maxmodels int, maxmb int, keep string) int {
+	const (
+		MSG = "VectorRegistryEvict() dropped %d model(s) to stay within the caps (models: %d; MB: %d)"
+	)
+
+	entries := VectorRegistryList()
+
+	maxbytes := maxmb * 1024 * 1024
+	count, size := 0, 0
+	for _, e := range entries {
+		if e.Fingerprint == keep {
+			count++
+			size += e.Bytes
+		}
+	}
+
+	dropped := 0
+	for _, e := range entries {
+		if e.Fingerprint == keep {
+			continue
+		}
+		if (maxmodels > 0 && count+1 > maxmodels) || (maxmb > 0 && size+e.Bytes > maxbytes) {
+			VectorDBDeleteNN(e.Fingerprint)
+			dropped++
+			continue
+		}
+		count++
+		size += e.Bytes
+	}
+
+	if dropped > 0 {
+		Msg.NOTE(fmt.Sprintf(MSG, dropped, maxmodels, maxmb))
+	}
+	return dropped
+}
+
+// VectorRegistryReport - print the registry to the console
+func VectorRegistryReport(priority int) {
+	const (
+		HEAD = "%-32s  %8s  %8s  %-7s  %-10s  %-16s  %s"
+		LINE = "%-32s  %7dK  %8d  %-7s  %-10s  %-16s  %s"
+		TAIL = "%d model(s); %dMB"
+		TF   = "2006-01-02 15:04"
+		UNK  = "?"
+	)
+
+	entries := VectorRegistryList()
+
+	orunk := func(s string) string {
+		if s == "" {
+			return UNK
+		}
+		return s
+	}
+
+	Msg.Emit(fmt.Sprintf(HEAD, "fingerprint", "size", "lines", "modeler", "textprep", "last used", "selection"), priority)
+	total := 0
+	for _, e := range entries {
+		lu := UNK
+		if e.LastUsed != nil {
+			lu = e.LastUsed.Format(TF)
+		}
+		Msg.Emit(fmt.Sprintf(LINE, e.Fingerprint, e.Bytes/1024, e.Lines, orunk(e.Modeler), orunk(e.TextPrep), lu,
+			orunk(e.Selection)), priority)
+		total += e.Bytes
+	}
+	Msg.Emit(fmt.Sprintf(TAIL, len(entries), total/1024/1024), priority)
+}
+
+// VectorRegistryLookup - the registry entry for one stored model
+func VectorRegistryLookup(fp string) (NNRegistryEntry, bool) {
+	const (
+		Q = REGISTRYSELECT + ` WHERE n.fingerprint = $1`
+	)
+
+	q := fmt.Sprintf(Q, vv.VECTORTABLENAMENN, vv.VECTORTABLENAMEREG)
+	foundrow, err := db.SQLPool.Query(context.Background(), q, fp)
+	if err != nil {
+		// the tables are not there yet: there is nothing to find
+		return NNRegistryEntry{}, false
+	}
+
+	e, err := pgx.CollectOneRow(foundrow, pgx.RowToStructByPos[NNRegistryEntry])
+	if err != nil {
+		// "no rows in result set"
+		return NNRegistryEntry{}, false
+	}
+	return e, true
+}
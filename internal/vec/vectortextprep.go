@@ -12,6 +12,7 @@ import (
 	"github.com/e-gun/HipparchiaGoServer/internal/base/str"
 	"github.com/e-gun/HipparchiaGoServer/internal/db"
 	"github.com/e-gun/HipparchiaGoServer/internal/vv"
+	"math"
 	"math/rand"
 	"os"
 	"sort"
@@ -29,11 +30,47 @@ import (
 // note that building these can be quite slow when you send 1m lines into the modeler
 // it might be possible to speed them up, but they are still <25% of the model building time so...
 
+// textpreprevisions - a text prep whose output has changed gets a revision: it goes into the fingerprint so that a
+// model stored before the change is not mistaken for one built after it; see fingerprintselection()
+var textpreprevisions = map[string]string{
+	"montecarlo": "montecarlo-r2", // hwguesser.guess() walks the cumulative weights in order
+}
+
 type hwguesser struct {
 	total int
 	words map[int]string
 }
 
+// guess - a weighted guess at one of the headwords: see buildmontecarloparsemap()
+func (g hwguesser) guess() string {
+	if len(g.words) == 0 {
+		return ""
+	}
+
+	if g.total == 0 {
+		// just grab the first one
+		for _, v := range g.words {
+			return v
+		}
+	}
+
+	// the words are keyed to their cumulative weights: the first key past the guess wins; a map is not walked in
+	// order, so the keys have to be sorted first or else a heavier word can be passed over for a lighter one
+	kk := make([]int, 0, len(g.words))
+	for k := range g.words {
+		kk = append(kk, k)
+	}
+	sort.Ints(kk)
+
+	r := rand.Intn(g.total)
+	for _, k := range kk {
+		if r < k {
+			return g.words[k]
+		}
+	}
+	return g.words[kk[len(kk)-1]]
+}
+
 // buildmontecarloparsemap
 func buildmontecarloparsemap(parsemap map[string]map[string]bool) map[string]hwguesser {
 	// turn a list of sentences into a list of headwords; here we figure out the chances of any given homonym
@@ -192,13 +229,261 @@ func buildyokedparsemap(parsemap map[string]map[string]bool) map[string]string {
 	return yoked
 }
 
+// hwcontext - headwords chosen by the company that a word keeps: see buildcontextualparsemap()
+type hwcontext struct {
+	cands    map[string][]string           // form -> its possible headwords, the most common first
+	logprior map[string]float64            // headword -> log of its corpus count
+	pf       map[string]float64            // feature -> its share of all of the features in the training text
+	cooc     map[string]map[string]float64 // headword -> feature -> count
+	seen     map[string]float64            // headword -> count of all of the features seen with it
+	stops    map[string]struct{}
+}
+
+// buildcontextualparsemap - learn which headword an ambiguous form belongs to from the words around it
+func buildcontextualparsemap(parsemap map[string]map[string]bool, seqs [][]string) hwcontext {
+	// "winner" always sends "esse" to "sum"; "contextual" will send it to "edo" if it keeps company with "cibus" and
+	// "panis"; the evidence comes from the forms that are not ambiguous: "edit" and "comedunt" can only be "edo", and
+	// so the words around them say what an "edo" passage looks like (Yarowsky 1995)
+
+	// [a] figure out all headwords in use
+
+	allheadwords := make(map[string]bool)
+	for i := range parsemap {
+		for k := range parsemap[i] {
+			allheadwords[k] = true
+		}
+	}
+
+	// [b] generate scoremap and assign scores to each of the headwords
+
+	scoremap := db.FetchHeadwordCounts(allheadwords)
+
+	// [c] lower everything: parsemap is left as it was since the other parsemappers lower it for themselves
+
+	counts := make(map[string]int, len(scoremap))
+	for k, v := range scoremap {
+		counts[strings.ToLower(k)] += v
+	}
+
+	lcparsemap := make(map[string]map[string]bool, len(parsemap))
+	for i := range parsemap {
+		lc := strings.ToLower(i)
+		if lcparsemap[lc] == nil {
+			lcparsemap[lc] = make(map[string]bool)
+		}
+		for k := range parsemap[i] {
+			lcparsemap[lc][strings.ToLower(k)] = true
+		}
+	}
+
+	return trainhwcontext(lcparsemap, counts, seqs, getstopset())
+}
+
+// trainhwcontext - the [d]-[f] of buildcontextualparsemap(): the counts are in hand and everything is lowercase
+func trainhwcontext(parsemap map[string]map[string]bool, counts map[string]int, seqs [][]string, stops map[string]struct{}) hwcontext {
+	const (
+		ROUNDS    = 2   // the first round learns from the unambiguous forms; later rounds add the confident guesses
+		CONFIDENT = 2.0 // the log-odds by which the best headword has to beat the runner-up to count as a confident guess
+	)
+
+	hc := hwcontext{
+		cands:    make(map[string][]string, len(parsemap)),
+		logprior: make(map[string]float64),
+		stops:    stops,
+	}
+
+	// [d] the candidates for each form, the most common first; the candidates of the ambiguous forms are the only
+	// headwords whose company needs to be kept track of
+
+	needed := make(map[string]bool)
+	for f, hh := range parsemap {
+		var cc []string
+		for h := range hh {
+			cc = append(cc, h)
+			hc.logprior[h] = math.Log(float64(counts[h] + 1))
+		}
+		// ties go to the alphabetically first headword so that the same text always yields the same choices
+		sort.Slice(cc, func(i, j int) bool {
+			if counts[cc[i]] != counts[cc[j]] {
+				return counts[cc[i]] > counts[cc[j]]
+			}
+			return cc[i] < cc[j]
+		})
+		hc.cands[f] = cc
+		if len(cc) > 1 {
+			for _, h := range cc {
+				needed[h] = true
+			}
+		}
+	}
+
+	// [e] the bootstrap: count the features around each token whose headword is known; then guess at the others and
+	// keep the confident guesses for the next round
+
+	known := make([][]string, len(seqs))
+	for s, seq := range seqs {
+		known[s] = make([]string, len(seq))
+		for i, w := range seq {
+			if cc := hc.cands[w]; len(cc) == 1 {
+				known[s][i] = cc[0]
+			}
+		}
+	}
+
+	for r := 0; r < ROUNDS; r++ {
+		hc.pf = make(map[string]float64)
+		hc.cooc = make(map[string]map[string]float64)
+		hc.seen = make(map[string]float64)
+
+		total := 0.0
+		for s, seq := range seqs {
+			for i := range seq {
+				h := known[s][i]
+				ff := hc.features(seq, i, known[s])
+				for _, f := range ff {
+					hc.pf[f]++
+					total++
+				}
+				if h == "" || !needed[h] {
+					continue
+				}
+				if hc.cooc[h] == nil {
+					hc.cooc[h] = make(map[string]float64)
+				}
+				for _, f := range ff {
+					hc.cooc[h][f]++
+					hc.seen[h]++
+				}
+			}
+		}
+
+		for f := range hc.pf {
+			hc.pf[f] = hc.pf[f] / total
+		}
+
+		if r == ROUNDS-1 {
+			break
+		}
+
+		for s, seq := range seqs {
+			for i, w := range seq {
+				if known[s][i] != "" || len(hc.cands[w]) < 2 {
+					continue
+				}
+				if h, margin := hc.best(hc.cands[w], hc.features(seq, i, known[s])); margin >= CONFIDENT {
+					known[s][i] = h
+				}
+			}
+		}
+	}
+
+	return hc
+}
+
+// features - the headwords of the words around seq[i]; a headword that is not known yet is the most common candidate
+func (hc hwcontext) features(seq []string, i int, known []string) []string {
+	const (
+		WINDOW = 5
+	)
+
+	var ff []string
+	for j := i - WINDOW; j <= i+WINDOW; j++ {
+		if j < 0 || j == i || j >= len(seq) {
+			continue
+		}
+		f := ""
+		if known != nil {
+			f = known[j]
+		}
+		if f == "" {
+			if cc := hc.cands[seq[j]]; len(cc) > 0 {
+				f = cc[0]
+			}
+		}
+		if _, s := hc.stops[f]; f != "" && !s {
+			ff = append(ff, f)
+		}
+	}
+	return ff
+}
+
+// best - the likeliest of the candidates given the features and the margin by which it beat the runner-up
+func (hc hwcontext) best(cands []string, ff []string) (string, float64) {
+	// naive Bayes with each P(f|h) shrunk toward P(f): a headword that has never been seen in context scores its
+	// prior alone; score(h) = log P(h) + Σ log(P(f|h) / P(f))
+
+	const (
+		SHRINK = 10.0
+	)
+
+	top, second := math.Inf(-1), math.Inf(-1)
+	winner := cands[0]
+	for _, h := range cands {
+		sc := hc.logprior[h]
+		for _, f := range ff {
+			p := hc.pf[f]
+			if p == 0 {
+				continue
+			}
+			sc += math.Log((hc.cooc[h][f] + SHRINK*p) / ((hc.seen[h] + SHRINK) * p))
+		}
+		if sc > top {
+			top, second, winner = sc, top, h
+		} else if sc > second {
+			second = sc
+		}
+	}
+	return winner, top - second
+}
+
+// resolve - the headword of each word of the sequence; a word that was never parsed stays as it is
+func (hc hwcontext) resolve(seq []string) []string {
+	hh := make([]string, len(seq))
+	for i, w := range seq {
+		cc := hc.cands[w]
+		switch len(cc) {
+		case 0:
+			hh[i] = w
+		case 1:
+			hh[i] = cc[0]
+		default:
+			hh[i], _ = hc.best(cc, hc.features(seq, i, nil))
+		}
+	}
+	return hh
+}
+
+// discourse - the headword most often chosen for each form in the sequences: "one sense per discourse" (Gale, Church,
+// & Yarowsky 1992); for when the words have to be mapped one at a time: see HeadwordChooser()
+func (hc hwcontext) discourse(seqs [][]string) map[string]string {
+	tally := make(map[string]map[string]int)
+	for _, seq := range seqs {
+		for i, h := range hc.resolve(seq) {
+			if tally[seq[i]] == nil {
+				tally[seq[i]] = make(map[string]int)
+			}
+			tally[seq[i]][h]++
+		}
+	}
+
+	sense := make(map[string]string, len(tally))
+	for w, hh := range tally {
+		mx := 0
+		for h, n := range hh {
+			if n > mx || (n == mx && h < sense[w]) {
+				sense[w], mx = h, n
+			}
+		}
+	}
+	return sense
+}
+
 //
 // buildtextblock() HELPERS
 //
 
 // flatstring - helper for buildtextblock() to generate unmodified text
-func flatstring(sb *strings.Builder, slicedwords []string) {
-	stops := getstopset()
+func flatstring(sb *strings.Builder, slicedwords []string, stops map[string]struct{}) {
 	for i := 0; i < len(slicedwords); i++ {
 		// drop skipwords
 		_, s := stops[slicedwords[i]]
@@ -238,24 +523,8 @@ func winnerstring(sb *strings.Builder, slicedwords []string, winnermap map[strin
 func montecarlostring(sb *strings.Builder, slicedwords []string, guessermap map[string]hwguesser, stops map[string]struct{}) {
 	var w string
 	for i := 0; i < len(slicedwords); i++ {
-		w = ""
 		// pick a word...
-		mc := guessermap[slicedwords[i]]
-		if mc.total > 0 {
-			g := rand.Intn(mc.total)
-			for k, v := range mc.words {
-				if g < k {
-					w = v
-					break
-				}
-			}
-		} else {
-			// just grab the first one
-			for _, v := range mc.words {
-				w = v
-				break
-			}
-		}
+		w = guessermap[slicedwords[i]].guess()
 
 		if w == "" {
 			w = slicedwords[i]
@@ -270,6 +539,20 @@ func montecarlostring(sb *strings.Builder, slicedwords []string, guessermap map[
 	}
 }
 
+// contextualstring - helper for buildtextblock() to generate substitutions chosen by context
+func contextualstring(sb *strings.Builder, slicedwords []string, hc hwcontext, stops map[string]struct{}) {
+	hh := hc.resolve(slicedwords)
+	for i := 0; i < len(hh); i++ {
+		// drop skipwords
+		_, s := stops[hh[i]]
+		if s {
+			continue
+		} else {
+			sb.WriteString(hh[i] + " ")
+		}
+	}
+}
+
 //
 // STOPWORDS
 //
@@ -385,3 +668,78 @@ func getstopset() map[string]struct{} {
 	ss := append(gs, ls...)
 	return gen.ToSet(ss)
 }
+
+// StopSet - the Greek and Latin stop words for use outside of the vector code: see RtNgramMaker()
+func StopSet() map[string]struct{} {
+	return getstopset()
+}
+
+// WinnerHeadwords - map each word onto the most common of its possible headwords; unparsed words are absent
+func WinnerHeadwords(words []string) map[string]string {
+	words = gen.Unique(words)
+	morphmapdbm := db.ArrayToGetRequiredMorphObjects(words)
+	return buildwinnertakesallparsemap(buildmorphmapstrslc(words, morphmapdbm))
+}
+
+// HeadwordChooser - textprepstring() one word at a time: map words onto one of their headwords via the given text
+// prep; a montecarlo guess is made afresh with every call; "contextual" needs the words in order and gives each form
+// the headword that it was most often given in them (see ContextualHeadwords() for the word by word choice);
+// "unparsed" yields the word itself; otherwise unparsed words yield ""
+func HeadwordChooser(words []string, textprep string) func(string) string {
+	if textprep == "unparsed" {
+		return func(w string) string { return w }
+	}
+
+	seq := words
+	words = gen.Unique(words)
+	morphmapdbm := db.ArrayToGetRequiredMorphObjects(words)
+	parsemap := buildmorphmapstrslc(words, morphmapdbm)
+
+	// buildmorphmapstrslc() maps an unparsed word onto itself: that is not a headword
+	unparsed := make(map[string]struct{})
+	for _, w := range words {
+		if _, ok := morphmapdbm[w]; !ok {
+			unparsed[w] = struct{}{}
+		}
+	}
+
+	var choose func(string) string
+	switch textprep {
+	case "montecarlo":
+		mcm := buildmontecarloparsemap(parsemap)
+		choose = func(w string) string { return mcm[w].guess() }
+	case "yoked":
+		yokedmap := buildyokedparsemap(parsemap)
+		choose = func(w string) string { return yokedmap[w] }
+	case "contextual":
+		// one word at a time has no context: "one sense per discourse"
+		sense := buildcontextualparsemap(parsemap, [][]string{seq}).discourse([][]string{seq})
+		choose = func(w string) string { return sense[w] }
+	default: // "winner"
+		winnermap := buildwinnertakesallparsemap(parsemap)
+		choose = func(w string) string { return winnermap[w] }
+	}
+
+	return func(w string) string {
+		if _, ok := unparsed[w]; ok {
+			return ""
+		}
+		return choose(w)
+	}
+}
+
+// ContextualHeadwords - the headword of each word of the sequence as chosen by the words around it; unparsed words
+// yield ""; see buildcontextualparsemap()
+func ContextualHeadwords(words []string) []string {
+	uw := gen.Unique(words)
+	morphmapdbm := db.ArrayToGetRequiredMorphObjects(uw)
+	hc := buildcontextualparsemap(buildmorphmapstrslc(uw, morphmapdbm), [][]string{words})
+
+	hh := hc.resolve(words)
+	for i, w := range words {
+		if _, ok := morphmapdbm[w]; !ok {
+			hh[i] = ""
+		}
+	}
+	return hh
+}
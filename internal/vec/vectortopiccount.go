@@ -0,0 +1,268 @@
+//    HipparchiaGoServer
+//    Copyright: E Gunderson 2022-24
+//    License: GNU GENERAL PUBLIC LICENSE 3
+//        (see LICENSE in the top level directory of the distribution)
+
+package vec
+
+import (
+	"fmt"
+	"github.com/e-gun/HipparchiaGoServer/internal/base/gen"
+	"github.com/e-gun/HipparchiaGoServer/internal/base/str"
+	"github.com/e-gun/HipparchiaGoServer/internal/lnch"
+	sr "github.com/e-gun/HipparchiaGoServer/internal/search"
+	"github.com/e-gun/HipparchiaGoServer/internal/vlt"
+	"github.com/e-gun/HipparchiaGoServer/internal/vv"
+	"github.com/e-gun/nlp"
+	"github.com/labstack/echo/v4"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"gonum.org/v1/gonum/mat"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//
+// HOW MANY TOPICS?
+//
+
+// fit the selection once for each number of topics in a range; score each fit by perplexity and by coherence; and
+// recommend the most coherent; every fit is stored (see VectorDBAddLDA()), so that LDASearch() can open the recommended
+// model at once and so that a second pass over the same range costs next to nothing
+
+// perplexity always falls as K rises (more topics fit the same text more closely): it is reported, but the recommendation
+// rests on coherence
+
+// topiccountscore - one K in the range
+type topiccountscore struct {
+	k          int
+	perplexity float64
+	coherence  float64
+	stored     bool
+}
+
+// ParseTopicRange - "4-16-2" into [4 6 8 10 12 14 16]; "4-16" steps by 1
+func ParseTopicRange(r string) ([]int, error) {
+	const (
+		FAIL1 = "cannot parse the range '%s': use 'low-high' or 'low-high-step'"
+		FAIL2 = "the range '%s' has to lie between 2 and %d"
+	)
+
+	elem := strings.Split(strings.TrimSpace(r), "-")
+	if len(elem) < 2 || len(elem) > 3 {
+		return nil, fmt.Errorf(FAIL1, r)
+	}
+
+	nn := []int{0, 0, 1}
+	for i, e := range elem {
+		n, err := strconv.Atoi(strings.TrimSpace(e))
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf(FAIL1, r)
+		}
+		nn[i] = n
+	}
+
+	lo, hi, step := nn[0], nn[1], nn[2]
+	if lo > hi {
+		lo, hi = hi, lo
+	}
+
+	if lo < 2 || hi > vv.LDAMAXTOPICS {
+		return nil, fmt.Errorf(FAIL2, r, vv.LDAMAXTOPICS)
+	}
+
+	var ks []int
+	for k := lo; k <= hi; k += step {
+		ks = append(ks, k)
+	}
+	return ks, nil
+}
+
+// TopicCountSearch - fit a range of K for the selection and recommend one
+func TopicCountSearch(c echo.Context, srch str.SearchStruct, ks []int) error {
+	const (
+		SUMM = `
+		<div id="searchsummary">Choosing the number of topics for %s<br>
+			%d models (%s; K = %d to %d); %d of them were already stored<br>
+			recommended: <span class="colorhighlight">%d topics</span> (the highest mean coherence)<br>
+			<span class="small">(set "LDA topics to generate" to %d and run a topic model search to open the stored model)</span><br>
+			<span class="small">(documents: %s; text prep: <code>%s</code>)</span><br>
+			<span class="small">(%ss)</span><br>
+		</div>
+		`
+		TBL = `
+		<table class="vectortable"><tbody>
+		<tr class="vectorrow">
+			<td class="vectorrank">Topics</td>
+			<td class="vectorrank">Perplexity</td>
+			<td class="vectorrank">Mean coherence</td>
+			<td class="vectorrank">&nbsp;</td>
+		</tr>
+		%s
+		<tr class="vectorrow">
+			<td class="vectorrank small" colspan = "4">perplexity: lower fits the text more closely; coherence (UMass): higher means that the top words of a topic keep company with one another</td>
+		</tr>
+		</tbody></table>`
+		TBLRW = `
+		<tr class="%s">
+			<td class="vectorrank">%d</td>
+			<td class="vectorscore">%s</td>
+			<td class="vectorscore">%.3f</td>
+			<td class="vectorword">%s</td>
+		</tr>`
+		BEST  = `<span class="colorhighlight">recommended</span>`
+		MSG1  = "Preparing the text for modeling"
+		MSG2  = "Building %s model %d of %d (%d topics)"
+		MSG3  = "Loading stored %s model %d of %d (%d topics)"
+		NOK   = `<div id="searchsummary">Choosing the number of topics needs a range of topic counts</div>`
+		FAIL  = `<div id="searchsummary">Failed to model topics for %d topics</div>`
+		EMPTY = `<div id="searchsummary">There is nothing to model in the current selection</div>`
+		NTH   = 3
+	)
+
+	c.Response().After(func() { Msg.LogPaths("TopicCountSearch()") })
+
+	start := time.Now()
+	m := message.NewPrinter(language.English)
+	user := vlt.ReadUUIDCookie(c)
+	sess := vlt.AllSessions.GetSess(user)
+
+	bye := func(s string) error {
+		vlt.WSInfo.Del <- srch.WSID
+		return gen.JSONresponse(c, str.SearchOutputJSON{Searchsummary: s})
+	}
+
+	if len(ks) == 0 {
+		return bye(NOK)
+	}
+
+	modeler, _ := sessiontopicmodel(sess)
+	unit := sessiontopicunit(sess)
+	textprep := sess.VecTextPrep
+	name := strings.ToUpper(modeler)
+
+	sl := sr.SessionIntoSearchlist(sess)
+	fs := srch
+	fs.SearchIn = sl.Inc
+	fs.SearchEx = sl.Excl
+
+	// the text is only prepared if some K has not been stored yet
+
+	var vs str.SearchStruct
+	var bags []BagWithLocus
+	prepared := false
+
+	prep := func() {
+		vs = sr.SessionIntoBulkSearch(c, lnch.Config.VectorMaxlines)
+		vlt.WSInfo.UpdateSummMsg <- vlt.WSSIKVs{srch.WSID, MSG1}
+		bags = ldapreptext(textprep, unit, &vs)
+		prepared = true
+	}
+
+	// [a] the models
+
+	var scores []topiccountscore
+	for i, k := range ks {
+		fp := FingerprintLDAVectorSearch(fs, modeler, unit, k)
+
+		var tm storedtopicmodel
+		if VectorDBCheckLDA(fp) {
+			tm = VectorDBFetchLDA(fp)
+		}
+
+		sc := topiccountscore{k: k, stored: !tm.empty()}
+
+		var vectoriser *nlp.CountVectoriser
+		var docsOverTopics, topicsOverWords mat.Matrix
+		var kbags []BagWithLocus
+
+		if sc.stored {
+			vlt.WSInfo.UpdateSummMsg <- vlt.WSSIKVs{srch.WSID, fmt.Sprintf(MSG3, name, i+1, len(ks), k)}
+			kbags, vectoriser, docsOverTopics, topicsOverWords = tm.unpack()
+			sc.perplexity = tm.Perplexity
+		} else {
+			if !prepared {
+				prep()
+			}
+			if len(bags) == 0 {
+				return bye(EMPTY)
+			}
+
+			vlt.WSInfo.UpdateSummMsg <- vlt.WSSIKVs{srch.WSID, fmt.Sprintf(MSG2, name, i+1, len(ks), k)}
+			kbags = bags
+			vectoriser = nlp.NewCountVectoriser(gen.StringMapKeysIntoSlice(getstopset())...)
+
+			var ok bool
+			docsOverTopics, topicsOverWords, ok = topicmodel(modeler, k, bagcorpus(bags), vectoriser, &vs)
+			if !ok {
+				return bye(fmt.Sprintf(FAIL, k))
+			}
+
+			sc.perplexity = topicperplexity(docsOverTopics, topicsOverWords, vectoriser, bagcorpus(bags))
+			VectorDBAddLDA(fp, packtopicmodel(modeler, textprep, unit, k, bags, vectoriser, docsOverTopics, topicsOverWords, sc.perplexity))
+		}
+
+		coh := topiccoherence(topicwords(topicsOverWords, vectoriser, vv.LDACOHERENCETOPN), vectoriser, bagcorpus(kbags))
+		for _, ch := range coh {
+			sc.coherence += ch
+		}
+		if len(coh) > 0 {
+			sc.coherence = sc.coherence / float64(len(coh))
+		}
+
+		scores = append(scores, sc)
+	}
+
+	// [b] the recommendation
+
+	best := 0
+	already := 0
+	for i, sc := range scores {
+		if sc.coherence > scores[best].coherence {
+			best = i
+		}
+		if sc.stored {
+			already++
+		}
+	}
+
+	// [c] the table
+
+	var trr strings.Builder
+	for i, sc := range scores {
+		rn := "vectorrow"
+		if i%NTH == 0 {
+			rn = "nthrow"
+		}
+		note := "&nbsp;"
+		if i == best {
+			note = BEST
+		}
+		trr.WriteString(fmt.Sprintf(TBLRW, rn, sc.k, m.Sprintf("%.1f", sc.perplexity), sc.coherence, note))
+	}
+
+	htm := fmt.Sprintf(TBL, trr.String())
+
+	io := sr.InclusionOverview(&fs, sess.Inclusions)
+	el := fmt.Sprintf("%.2f", time.Now().Sub(start).Seconds())
+	k := scores[best].k
+	sum := fmt.Sprintf(SUMM, io, len(scores), topicmodelername(modeler), ks[0], ks[len(ks)-1], already, k, k, topicunitname(unit), textprep, el)
+
+	if lnch.Config.ZapLunates {
+		htm = gen.DeLunate(htm)
+		sum = gen.DeLunate(sum)
+	}
+
+	soj := str.SearchOutputJSON{
+		Title:         "How many topics?",
+		Searchsummary: sum,
+		Found:         htm,
+		Image:         "",
+		JS:            "",
+	}
+
+	vlt.WSInfo.Del <- srch.WSID
+	vlt.WSInfo.Del <- vs.ID
+	return gen.JSONresponse(c, soj)
+}
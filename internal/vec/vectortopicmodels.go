@@ -0,0 +1,349 @@
+//    HipparchiaGoServer
+//    Copyright: E Gunderson 2022-24
+//    License: GNU GENERAL PUBLIC LICENSE 3
+//        (see LICENSE in the top level directory of the distribution)
+
+package vec
+
+import (
+	"context"
+	"fmt"
+	"github.com/e-gun/HipparchiaGoServer/internal/base/str"
+	"github.com/e-gun/HipparchiaGoServer/internal/search"
+	"github.com/e-gun/HipparchiaGoServer/internal/vlt"
+	"github.com/e-gun/HipparchiaGoServer/internal/vv"
+	"github.com/e-gun/nlp"
+	"gonum.org/v1/gonum/mat"
+	"math"
+	"math/rand"
+	"time"
+)
+
+//
+// TOPIC MODELS OTHER THAN LDA
+//
+
+// every modeler hands LDASearch() the same pair of matrices that ldamodel() does: docsOverTopics (topics x docs) and
+// topicsOverWords (topics x words); and all of the values are non-negative so that ldatopicsummary(), ldatopsentences(),
+// and ldaplot() can treat the output of each modeler alike
+
+// NMF is done by hand: e-gun/nlp does not offer it
+
+var topicmodelernames = map[string]string{
+	"lda": "Latent Dirichlet Allocation",
+	"nmf": "Non-negative Matrix Factorization",
+	"lsa": "Latent Semantic Analysis",
+}
+
+// nonzeroer - the sparse matrices of e-gun/sparse can all do this
+type nonzeroer interface {
+	DoNonZero(fn func(i, j int, v float64))
+}
+
+// topicmodel - build the model for the corpus with whichever modeler the session asked for
+func topicmodel(modeler string, topics int, corpus []string, vectoriser *nlp.CountVectoriser, s *str.SearchStruct) (mat.Matrix, mat.Matrix, bool) {
+	switch modeler {
+	case "nmf":
+		return nmfmodel(topics, corpus, vectoriser, s)
+	case "lsa":
+		return lsamodel(topics, corpus, vectoriser, s)
+	default:
+		return ldamodel(topics, corpus, vectoriser, s)
+	}
+}
+
+// topicmodelcancellation - give the search a context that RtResetSession() can cancel; cf. ldamodel()
+func topicmodelcancellation(s *str.SearchStruct) context.Context {
+	search.InsertNewContextIntoSS(s)
+	si := vlt.WSFetchSrchInfo(s.WSID)
+	si.CancelFnc = s.CancelFnc
+	vlt.WSInfo.InsertInfo <- si
+	return s.Context
+}
+
+// nmfmodel - build a non-negative matrix factorization of the tf-idf weighted term-document matrix
+func nmfmodel(topics int, corpus []string, vectoriser *nlp.CountVectoriser, s *str.SearchStruct) (mat.Matrix, mat.Matrix, bool) {
+	const (
+		FAIL = "Failed to model topics for documents"
+		EPS  = 1e-9
+		SEED = 1 // a fixed seed: the same selection yields the same topics
+	)
+
+	blank := mat.NewDense(1, 1, nil)
+	ctx := topicmodelcancellation(s)
+	cfg := ldavecconfig()
+
+	// [a] V: terms x docs
+
+	pipeline := nlp.NewPipeline(vectoriser, nlp.NewTfidfTransformer())
+	v, err := pipeline.FitTransform(corpus...)
+	if err != nil {
+		Msg.FYI(FAIL)
+		return blank, blank, false
+	}
+
+	nz, ok := v.(nonzeroer)
+	if !ok {
+		Msg.FYI(FAIL)
+		return blank, blank, false
+	}
+
+	type cell struct {
+		i, j int
+		v    float64
+	}
+
+	var cells []cell
+	total := 0.0
+	nz.DoNonZero(func(i, j int, x float64) {
+		cells = append(cells, cell{i, j, x})
+		total += x
+	})
+
+	m, n := v.Dims()
+	if len(cells) == 0 || m == 0 || n == 0 {
+		Msg.FYI(FAIL)
+		return blank, blank, false
+	}
+
+	// [b] V ≈ WH where W is terms x topics and H is topics x docs; random start scaled to the mean of V
+
+	rnd := rand.New(rand.NewSource(SEED))
+	scale := math.Sqrt(total / float64(m*n) / float64(topics))
+	fill := func(r, c int) *mat.Dense {
+		d := mat.NewDense(r, c, nil)
+		for i := 0; i < r; i++ {
+			for j := 0; j < c; j++ {
+				d.Set(i, j, scale*rnd.Float64()+EPS)
+			}
+		}
+		return d
+	}
+
+	w := fill(m, topics)
+	h := fill(topics, n)
+
+	// [c] the multiplicative updates of Lee & Seung (2001): H ← H∘(WᵀV)/(WᵀWH); W ← W∘(VHᵀ)/(WHHᵀ)
+
+	var wtw, wtwh, hht, whht mat.Dense
+	wtv := mat.NewDense(topics, n, nil)
+	vht := mat.NewDense(m, topics, nil)
+
+	start := time.Now()
+	for it := 0; it < cfg.LDAIterations; it++ {
+		select {
+		case <-ctx.Done():
+			Msg.FYI(FAIL)
+			return blank, blank, false
+		default:
+		}
+
+		wtv.Zero()
+		for _, c := range cells {
+			for t := 0; t < topics; t++ {
+				wtv.Set(t, c.j, wtv.At(t, c.j)+w.At(c.i, t)*c.v)
+			}
+		}
+		wtw.Mul(w.T(), w)
+		wtwh.Mul(&wtw, h)
+		h.Apply(func(t, j int, x float64) float64 {
+			return x * wtv.At(t, j) / (wtwh.At(t, j) + EPS)
+		}, h)
+
+		vht.Zero()
+		for _, c := range cells {
+			for t := 0; t < topics; t++ {
+				vht.Set(c.i, t, vht.At(c.i, t)+c.v*h.At(t, c.j))
+			}
+		}
+		hht.Mul(h, h.T())
+		whht.Mul(w, &hht)
+		w.Apply(func(i, t int, x float64) float64 {
+			return x * vht.At(i, t) / (whht.At(i, t) + EPS)
+		}, w)
+	}
+	Msg.PEEK(fmt.Sprintf("nmfmodel() %d iterations required %.3fs", cfg.LDAIterations, time.Now().Sub(start).Seconds()))
+
+	// [d] topic proportions for each doc; word weights for each topic
+
+	var topicsOverWords mat.Dense
+	topicsOverWords.CloneFrom(w.T())
+	normalizerows(&topicsOverWords)
+	normalizecolumns(h)
+
+	return h, &topicsOverWords, true
+}
+
+// lsamodel - build a latent semantic analysis (truncated SVD) of the tf-idf weighted term-document matrix
+func lsamodel(topics int, corpus []string, vectoriser *nlp.CountVectoriser, s *str.SearchStruct) (mat.Matrix, mat.Matrix, bool) {
+	const (
+		FAIL = "Failed to model topics for documents"
+	)
+
+	blank := mat.NewDense(1, 1, nil)
+	ctx := topicmodelcancellation(s)
+
+	svd := nlp.NewTruncatedSVD(topics)
+	pipeline := nlp.NewPipeline(vectoriser, nlp.NewTfidfTransformer(), svd)
+
+	start := time.Now()
+	dt, err := pipeline.FitTransform(corpus...)
+	if err != nil || ctx.Err() != nil {
+		Msg.FYI(FAIL)
+		return blank, blank, false
+	}
+	Msg.PEEK(fmt.Sprintf("lsamodel() SVD required %.3fs", time.Now().Sub(start).Seconds()))
+
+	var docsOverTopics, topicsOverWords mat.Dense
+	docsOverTopics.CloneFrom(dt)
+	topicsOverWords.CloneFrom(svd.Components.T())
+
+	// the sign of a singular vector is arbitrary: orient each dimension so that its heaviest word counts for it and
+	// not against it; then keep only the positive side of each dimension so that it reads like a topic
+
+	tr, tc := topicsOverWords.Dims()
+	_, dc := docsOverTopics.Dims()
+	for t := 0; t < tr; t++ {
+		heaviest := 0.0
+		for wd := 0; wd < tc; wd++ {
+			if math.Abs(topicsOverWords.At(t, wd)) > math.Abs(heaviest) {
+				heaviest = topicsOverWords.At(t, wd)
+			}
+		}
+		if heaviest < 0 {
+			for wd := 0; wd < tc; wd++ {
+				topicsOverWords.Set(t, wd, -topicsOverWords.At(t, wd))
+			}
+			for d := 0; d < dc; d++ {
+				docsOverTopics.Set(t, d, -docsOverTopics.At(t, d))
+			}
+		}
+	}
+
+	positive := func(_, _ int, x float64) float64 { return math.Max(x, 0) }
+	topicsOverWords.Apply(positive, &topicsOverWords)
+	docsOverTopics.Apply(positive, &docsOverTopics)
+
+	normalizerows(&topicsOverWords)
+	normalizecolumns(&docsOverTopics)
+
+	return &docsOverTopics, &topicsOverWords, true
+}
+
+// normalizerows - make each row of the matrix sum to 1 (unless it sums to 0)
+func normalizerows(d *mat.Dense) {
+	r, _ := d.Dims()
+	for i := 0; i < r; i++ {
+		row := d.RawRowView(i)
+		sum := 0.0
+		for _, x := range row {
+			sum += x
+		}
+		if sum > 0 {
+			for j := range row {
+				row[j] = row[j] / sum
+			}
+		}
+	}
+}
+
+// normalizecolumns - make each column of the matrix sum to 1 (unless it sums to 0)
+func normalizecolumns(d *mat.Dense) {
+	r, c := d.Dims()
+	for j := 0; j < c; j++ {
+		sum := 0.0
+		for i := 0; i < r; i++ {
+			sum += d.At(i, j)
+		}
+		if sum > 0 {
+			for i := 0; i < r; i++ {
+				d.Set(i, j, d.At(i, j)/sum)
+			}
+		}
+	}
+}
+
+//
+// COHERENCE
+//
+
+// topiccoherence - the UMass coherence (Mimno et al. 2011) of the top words of each topic: the mean over every pair
+// of top words of log((D(wᵢ, wⱼ) + 1) / D(wⱼ)) where D() counts the docs that hold the word(s) and wⱼ outranks wᵢ;
+// the scores are usually < 0 and higher is more coherent
+func topiccoherence(tops map[int][]topicsorter, vectoriser *nlp.CountVectoriser, corpus []string) []float64 {
+	coherence := make([]float64, len(tops))
+
+	tdm, err := vectoriser.Transform(corpus...)
+	if err != nil {
+		return coherence
+	}
+
+	nz, ok := tdm.(nonzeroer)
+	if !ok {
+		return coherence
+	}
+
+	// [a] which docs hold each of the top words?
+
+	needed := make(map[int]bool)
+	for _, ts := range tops {
+		for _, t := range ts {
+			if i, ok := vectoriser.Vocabulary[t.W]; ok {
+				needed[i] = true
+			}
+		}
+	}
+
+	docs := make(map[int]map[int]bool, len(needed))
+	nz.DoNonZero(func(i, j int, v float64) {
+		if needed[i] {
+			if docs[i] == nil {
+				docs[i] = make(map[int]bool)
+			}
+			docs[i][j] = true
+		}
+	})
+
+	both := func(a, b int) int {
+		ct := 0
+		for d := range docs[a] {
+			if docs[b][d] {
+				ct++
+			}
+		}
+		return ct
+	}
+
+	// [b] score each topic
+
+	for topic := 0; topic < len(tops); topic++ {
+		var idx []int
+		for _, t := range tops[topic] {
+			if i, ok := vectoriser.Vocabulary[t.W]; ok && len(docs[i]) > 0 {
+				idx = append(idx, i)
+			}
+		}
+
+		pairs := 0
+		sum := 0.0
+		for i := 1; i < len(idx); i++ {
+			for j := 0; j < i; j++ {
+				sum += math.Log(float64(both(idx[i], idx[j])+1) / float64(len(docs[idx[j]])))
+				pairs++
+			}
+		}
+		if pairs > 0 {
+			coherence[topic] = sum / float64(pairs)
+		}
+	}
+
+	return coherence
+}
+
+// topicmodelername - "Latent Dirichlet Allocation", etc.
+func topicmodelername(modeler string) string {
+	if n, ok := topicmodelernames[modeler]; ok {
+		return n
+	}
+	return topicmodelernames[vv.LDAMODELER]
+}
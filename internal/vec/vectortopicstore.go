@@ -0,0 +1,378 @@
+//    HipparchiaGoServer
+//    Copyright: E Gunderson 2022-24
+//    License: GNU GENERAL PUBLIC LICENSE 3
+//        (see LICENSE in the top level directory of the distribution)
+
+package vec
+
+import (
+	"crypto/md5"
+	"encoding/json"
+	"fmt"
+	"github.com/e-gun/HipparchiaGoServer/internal/base/gen"
+	"github.com/e-gun/HipparchiaGoServer/internal/base/str"
+	"github.com/e-gun/HipparchiaGoServer/internal/lnch"
+	sr "github.com/e-gun/HipparchiaGoServer/internal/search"
+	"github.com/e-gun/HipparchiaGoServer/internal/vlt"
+	"github.com/e-gun/HipparchiaGoServer/internal/vv"
+	"github.com/e-gun/nlp"
+	"github.com/labstack/echo/v4"
+	"gonum.org/v1/gonum/mat"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+//
+// STORED TOPIC MODELS
+//
+
+// a fitted model goes into vv.VECTORTABLENAMELDA (see VectorDBAddLDA()) with everything that LDASearch() needs to redraw
+// it without refitting: the bags, the vocabulary, and the two matrices; the same model can then classify new text by
+// "folding in" the text with the word weights of each topic held fixed (see topicfoldin())
+
+// storedtopicmodel - what vv.VECTORTABLENAMELDA holds
+type storedtopicmodel struct {
+	Modeler    string         `json:"modeler"`
+	TextPrep   string         `json:"textprep"`
+	Unit       string         `json:"unit"`
+	Topics     int            `json:"topics"` // the number asked for
+	Rows       int            `json:"rows"`   // the number delivered: LSA cannot deliver more than min(words, docs)
+	Vocabulary map[string]int `json:"vocabulary"`
+	Words      []float64      `json:"words"` // topicsOverWords, row by row
+	Docs       []float64      `json:"docs"`  // docsOverTopics, row by row
+	Bags       []BagWithLocus `json:"bags"`
+	Perplexity float64        `json:"perplexity"`
+}
+
+// packtopicmodel - a fitted model into a storedtopicmodel
+func packtopicmodel(modeler string, textprep string, unit string, topics int, bags []BagWithLocus, vectoriser *nlp.CountVectoriser,
+	docsOverTopics mat.Matrix, topicsOverWords mat.Matrix, perplexity float64) storedtopicmodel {
+	rows, _ := topicsOverWords.Dims()
+	return storedtopicmodel{
+		Modeler:    modeler,
+		TextPrep:   textprep,
+		Unit:       unit,
+		Topics:     topics,
+		Rows:       rows,
+		Vocabulary: vectoriser.Vocabulary,
+		Words:      mat.DenseCopyOf(topicsOverWords).RawMatrix().Data,
+		Docs:       mat.DenseCopyOf(docsOverTopics).RawMatrix().Data,
+		Bags:       bags,
+		Perplexity: perplexity,
+	}
+}
+
+// empty - nothing (usable) was stored
+func (tm storedtopicmodel) empty() bool {
+	return tm.Rows == 0 || len(tm.Bags) == 0 || len(tm.Vocabulary) == 0 ||
+		len(tm.Words) != tm.Rows*len(tm.Vocabulary) || len(tm.Docs) != tm.Rows*len(tm.Bags)
+}
+
+// unpack - a storedtopicmodel into what LDASearch() works with
+func (tm storedtopicmodel) unpack() ([]BagWithLocus, *nlp.CountVectoriser, mat.Matrix, mat.Matrix) {
+	// the vectoriser has to tokenize new text exactly as it did the old text
+	vectoriser := nlp.NewCountVectoriser(gen.StringMapKeysIntoSlice(getstopset())...)
+	vectoriser.Vocabulary = tm.Vocabulary
+
+	docsOverTopics := mat.NewDense(tm.Rows, len(tm.Bags), tm.Docs)
+	topicsOverWords := mat.NewDense(tm.Rows, len(tm.Vocabulary), tm.Words)
+	return tm.Bags, vectoriser, docsOverTopics, topicsOverWords
+}
+
+// FingerprintLDAVectorSearch - cf. FingerprintNNVectorSearch(): the selection and the text prep plus the topic modeler,
+// the document unit, the number of topics, and the LDA configuration
+func FingerprintLDAVectorSearch(srch str.SearchStruct, modeler string, unit string, topics int) string {
+	const (
+		MSG1 = "LDASearch() fingerprint: "
+		FAIL = "FingerprintLDAVectorSearch() failed to Marshal"
+	)
+
+	f1, e1 := json.Marshal(fingerprintselection(srch))
+
+	// neither of these changes the model
+	cfg := ldavecconfig()
+	cfg.Goroutines = 0
+	cfg.MaxLDAGraphSize = 0
+
+	settings := struct {
+		Modeler string
+		Unit    string
+		Topics  int
+		Config  LDAConfig
+	}{modeler, unit, topics, cfg}
+
+	f2, e2 := json.Marshal(settings)
+
+	if e1 != nil || e2 != nil {
+		Msg.MAND(FAIL)
+		os.Exit(1)
+	}
+
+	m := fmt.Sprintf("%x", md5.Sum(append(f1, f2...)))
+	Msg.TMI(MSG1 + m)
+	return m
+}
+
+// termcounts - run the corpus through the vectoriser and report each nonzero count: term, doc, count
+func termcounts(vectoriser *nlp.CountVectoriser, corpus []string, fn func(term int, doc int, ct float64)) {
+	tdm, err := vectoriser.Transform(corpus...)
+	if err != nil {
+		return
+	}
+	if nz, ok := tdm.(nonzeroer); ok {
+		nz.DoNonZero(fn)
+	}
+}
+
+// topicperplexity - exp(-Σ log p(w|d) / N) for the corpus under the model where p(w|d) = Σₖ θₖd φₖw; every modeler is
+// scored the same way, but note that the score falls as the number of topics rises
+func topicperplexity(docsOverTopics mat.Matrix, topicsOverWords mat.Matrix, vectoriser *nlp.CountVectoriser, corpus []string) float64 {
+	const (
+		FLOOR = 1e-12 // LSA and NMF can assign a word no weight at all
+	)
+
+	tr, _ := topicsOverWords.Dims()
+
+	loglik := 0.0
+	n := 0.0
+	termcounts(vectoriser, corpus, func(term int, doc int, ct float64) {
+		p := 0.0
+		for t := 0; t < tr; t++ {
+			p += docsOverTopics.At(t, doc) * topicsOverWords.At(t, term)
+		}
+		loglik += ct * math.Log(math.Max(p, FLOOR))
+		n += ct
+	})
+
+	if n == 0 {
+		return 0
+	}
+	return math.Exp(-loglik / n)
+}
+
+// topicfoldin - the topic mixture of a new doc under a fitted model: EM over θ with φ held fixed
+func topicfoldin(counts map[int]float64, topicsOverWords mat.Matrix) []float64 {
+	const (
+		ITER = 100
+		TOL  = 1e-6
+	)
+
+	tr, _ := topicsOverWords.Dims()
+	theta := make([]float64, tr)
+	for t := range theta {
+		theta[t] = 1 / float64(tr)
+	}
+
+	next := make([]float64, tr)
+	for it := 0; it < ITER; it++ {
+		for t := range next {
+			next[t] = 0
+		}
+		total := 0.0
+		for w, ct := range counts {
+			p := 0.0
+			for t := 0; t < tr; t++ {
+				p += theta[t] * topicsOverWords.At(t, w)
+			}
+			if p == 0 {
+				continue
+			}
+			for t := 0; t < tr; t++ {
+				r := ct * theta[t] * topicsOverWords.At(t, w) / p
+				next[t] += r
+				total += r
+			}
+		}
+		if total == 0 {
+			return theta
+		}
+
+		change := 0.0
+		for t := 0; t < tr; t++ {
+			change += math.Abs(next[t]/total - theta[t])
+			theta[t] = next[t] / total
+		}
+		if change < TOL {
+			break
+		}
+	}
+	return theta
+}
+
+// TopicClassifySearch - the topic mixture of a passage or a bag of words under the stored topic model of the selection
+func TopicClassifySearch(c echo.Context, srch str.SearchStruct, locus string, bag string) error {
+	const (
+		SUMM = `
+		<div id="searchsummary">The topics of %s<br>
+			in the stored model of %s (%s; %d topics)<br>
+			%d of its %d words are in the model<br>
+			<span class="small">(documents: %s; text prep: <code>%s</code>)</span><br>
+			<span class="small">(%ss)</span><br>
+		</div>
+		`
+		TBL = `
+		<table class="vectortable"><tbody>
+		<tr class="vectorrow">
+			<td class="vectorrank">Topic</td>
+			<td class="vectorrank">Share</td>
+			<td class="vectorrank">Top words associated with the topic</td>
+		</tr>
+		%s
+		</tbody></table>`
+		TBLRW = `
+		<tr class="%s">
+			<td class="vectorrank">%d</td>
+			<td class="vectorscore">%.2f%%</td>
+			<td class="vectorsent">%s</td>
+		</tr>`
+		QPSG     = `<browser id="%s"><span class="foundauthor">%s</span>,&nbsp;<span class="foundwork">%s</span>: <span class="foundlocus">%s</span></browser>`
+		QBAG     = `»%s«`
+		SHOWN    = 0.01 // topics with less of a share than this are left out of the table
+		NOQUERY  = `<div id="searchsummary">Topic classification needs a passage or some words</div>`
+		NOMODEL  = `<div id="searchsummary">There is no stored %s model with %d topics for this selection and this text prep: run a topic model search first</div>`
+		FAIL     = `<div id="searchsummary">Topic classification could not use the query: %s</div>`
+		EMPTYQRY = "none of its words are in the model"
+		NTH      = 3
+	)
+
+	c.Response().After(func() { Msg.LogPaths("TopicClassifySearch()") })
+
+	start := time.Now()
+	user := vlt.ReadUUIDCookie(c)
+	sess := vlt.AllSessions.GetSess(user)
+
+	bye := func(s string) error {
+		vlt.WSInfo.Del <- srch.WSID
+		return gen.JSONresponse(c, str.SearchOutputJSON{Searchsummary: s})
+	}
+
+	if locus == "" && strings.TrimSpace(bag) == "" {
+		return bye(NOQUERY)
+	}
+
+	// [a] the model: the one that LDASearch() would use
+
+	modeler, ntopics := sessiontopicmodel(sess)
+	unit := sessiontopicunit(sess)
+
+	sl := sr.SessionIntoSearchlist(sess)
+	fs := srch
+	fs.SearchIn = sl.Inc
+	fs.SearchEx = sl.Excl
+	fp := FingerprintLDAVectorSearch(fs, modeler, unit, ntopics)
+
+	if !VectorDBCheckLDA(fp) {
+		return bye(fmt.Sprintf(NOMODEL, strings.ToUpper(modeler), ntopics))
+	}
+
+	tm := VectorDBFetchLDA(fp)
+	if tm.empty() {
+		return bye(fmt.Sprintf(NOMODEL, strings.ToUpper(modeler), ntopics))
+	}
+	_, vectoriser, _, topicsOverWords := tm.unpack()
+
+	// [b] the query: the same tokens that the text prep would have put into the model
+
+	var qwords []string
+	var qdesc string
+
+	if locus != "" {
+		ql, err := passagequerylines(locus)
+		if err != nil {
+			return bye(fmt.Sprintf(FAIL, err.Error()))
+		}
+		for i := range ql {
+			qwords = append(qwords, passagewords(&ql[i])...)
+		}
+		f := ql[0]
+		qdesc = fmt.Sprintf(QPSG, f.BuildHyperlink(), sr.DbWlnMyAu(&f).Shortname, sr.DbWlnMyWk(&f).Title, f.Citation())
+	} else {
+		bag = gen.UVσςϲ(strings.ToLower(bag))
+		for _, w := range strings.Fields(bag) {
+			qwords = append(qwords, gen.SwapAcuteForGrave(w))
+		}
+		qdesc = fmt.Sprintf(QBAG, strings.Join(strings.Fields(bag), " "))
+	}
+
+	toks := passagetokens(qwords, tm.TextPrep)
+
+	counts := make(map[int]float64)
+	known := 0
+	termcounts(vectoriser, []string{strings.Join(toks, " ")}, func(term int, _ int, ct float64) {
+		counts[term] = ct
+		known += int(ct)
+	})
+
+	if known == 0 {
+		return bye(fmt.Sprintf(FAIL, EMPTYQRY))
+	}
+
+	theta := topicfoldin(counts, topicsOverWords)
+
+	// [c] the table: the topics in order of their share
+
+	tops := ldasortedtopics(tm.Rows, topicsOverWords, vectoriser)
+
+	order := make([]int, len(theta))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return theta[order[i]] > theta[order[j]] })
+
+	var trr strings.Builder
+	for i, t := range order {
+		if theta[t] < SHOWN {
+			break
+		}
+		ww := make([]string, len(tops[t]))
+		for j := range tops[t] {
+			ww[j] = tops[t][j].W
+		}
+		rn := "vectorrow"
+		if i%NTH == 0 {
+			rn = "nthrow"
+		}
+		trr.WriteString(fmt.Sprintf(TBLRW, rn, t+1, theta[t]*100, strings.Join(ww, ", ")))
+	}
+
+	htm := fmt.Sprintf(TBL, trr.String())
+
+	// [d] the summary
+
+	io := sr.InclusionOverview(&fs, sess.Inclusions)
+	el := fmt.Sprintf("%.2f", time.Now().Sub(start).Seconds())
+	sum := fmt.Sprintf(SUMM, qdesc, io, topicmodelername(tm.Modeler), tm.Rows, known, len(toks), topicunitname(tm.Unit), tm.TextPrep, el)
+
+	if lnch.Config.ZapLunates {
+		htm = gen.DeLunate(htm)
+		sum = gen.DeLunate(sum)
+	}
+
+	soj := str.SearchOutputJSON{
+		Title:         "Topics",
+		Searchsummary: sum,
+		Found:         htm,
+		Image:         "",
+		JS:            fmt.Sprintf(vv.BROWSERJS, "browser"),
+	}
+
+	vlt.WSInfo.Del <- srch.WSID
+	return gen.JSONresponse(c, soj)
+}
+
+// sessiontopicmodel - the modeler and the number of topics that the session asks for
+func sessiontopicmodel(se str.ServerSession) (string, int) {
+	ntopics := se.LDAtopics
+	if ntopics < 1 {
+		ntopics = vv.LDATOPICS
+	}
+
+	modeler := se.LDAModeler
+	if _, ok := topicmodelernames[modeler]; !ok {
+		modeler = vv.LDAMODELER
+	}
+	return modeler, ntopics
+}
@@ -0,0 +1,384 @@
+//    HipparchiaGoServer
+//    Copyright: E Gunderson 2022-24
+//    License: GNU GENERAL PUBLIC LICENSE 3
+//        (see LICENSE in the top level directory of the distribution)
+
+package vec
+
+import (
+	"fmt"
+	"github.com/e-gun/HipparchiaGoServer/internal/base/gen"
+	"github.com/e-gun/HipparchiaGoServer/internal/base/str"
+	"github.com/e-gun/HipparchiaGoServer/internal/mps"
+	"github.com/e-gun/HipparchiaGoServer/internal/vv"
+	"github.com/go-echarts/go-echarts/v2/charts"
+	"github.com/go-echarts/go-echarts/v2/opts"
+	"gonum.org/v1/gonum/mat"
+	"math"
+	"sort"
+	"strings"
+)
+
+//
+// TOPIC MODEL DOCUMENTS
+//
+
+// by default a topic model "document" is SentencesPerBag sentences (see ldasentencebags()); but the documents can
+// also be whole works, the units of a work at some citation level (the books of the Iliad are "level1"), or chunks of
+// about ChunkWords words that never cross from one work into another (see ldaunitbags())
+
+// whatever the unit, each work gets a topic profile (the mean topic mixture of its documents): the profiles are drawn
+// as a heatmap of works x topics (see topicheatmap()) and the works are clustered by the similarity of their profiles
+// (see topicdendrogram()); if the selection holds only one work, its documents stand in for the works
+
+var topicunitnames = map[string]string{
+	"sentence": "sentences",
+	"work":     "whole works",
+	"level1":   "citation level 1",
+	"level2":   "citation level 2",
+	"level3":   "citation level 3",
+	"chunk":    "chunks of %d words",
+}
+
+// topicprofile - one row of the heatmap
+type topicprofile struct {
+	label string
+	docs  int
+	mix   []float64
+}
+
+// sessiontopicunit - the document unit that the session asks for
+func sessiontopicunit(se str.ServerSession) string {
+	if _, ok := topicunitnames[se.LDAUnit]; ok {
+		return se.LDAUnit
+	}
+	return vv.LDAUNIT
+}
+
+// topicunitname - "whole works", "chunks of 1000 words", etc.
+func topicunitname(unit string) string {
+	n, ok := topicunitnames[unit]
+	if !ok {
+		return topicunitnames[vv.LDAUNIT]
+	}
+	if unit == "chunk" {
+		return fmt.Sprintf(n, ldavecconfig().ChunkWords)
+	}
+	return n
+}
+
+// ldaunitbags - the lines of the selection grouped into whole works, citation units, or chunks of words; cf. ldasentencebags()
+func ldaunitbags(unit string, vs *str.SearchStruct) []BagWithLocus {
+	const (
+		LOC = "line/%s/%d"
+	)
+
+	cfg := ldavecconfig()
+
+	// [a] the lines of each work in order
+
+	bywork := make(map[string][]str.DbWorkline)
+	var order []string
+
+	rr := vs.Results.YieldAll()
+	for r := range rr {
+		if _, ok := bywork[r.WkUID]; !ok {
+			order = append(order, r.WkUID)
+		}
+		bywork[r.WkUID] = append(bywork[r.WkUID], r)
+	}
+
+	// [b] cut each work into units
+
+	level := 0
+	if strings.HasPrefix(unit, "level") {
+		level = int(unit[len(unit)-1] - '0')
+	}
+
+	// the citation of a line at the chosen level and above: "-1" for the levels that a work does not use
+	citeat := func(l *str.DbWorkline) string {
+		var cc []string
+		for i := str.NUMBEROFCITATIONLEVELS - 1; i >= level; i-- {
+			cc = append(cc, l.LvlVal(i))
+		}
+		return strings.Join(cc, ".")
+	}
+
+	var units [][]str.DbWorkline
+	for _, u := range order {
+		ll := bywork[u]
+		sort.Slice(ll, func(i, j int) bool { return ll[i].TbIndex < ll[j].TbIndex })
+
+		switch unit {
+		case "work":
+			units = append(units, ll)
+		case "chunk":
+			start, prev, ct := 0, -1, 0
+			for i := range ll {
+				ct += len(strings.Fields(ll[i].Stripped))
+				if ct >= cfg.ChunkWords {
+					units = append(units, ll[start:i+1])
+					prev, start, ct = start, i+1, 0
+				}
+			}
+			if start < len(ll) {
+				// a short remnant joins the chunk before it
+				if ct < cfg.ChunkWords/2 && prev >= 0 {
+					units[len(units)-1] = ll[prev:]
+				} else {
+					units = append(units, ll[start:])
+				}
+			}
+		default:
+			start := 0
+			for i := 1; i <= len(ll); i++ {
+				if i == len(ll) || citeat(&ll[i]) != citeat(&ll[start]) {
+					units = append(units, ll[start:i])
+					start = i
+				}
+			}
+		}
+	}
+
+	// [c] clean each unit the way ldasentencebags() cleans a sentence
+
+	strip := []string{`&nbsp;`, `- `, `<.*?>`}
+
+	var thebags []BagWithLocus
+	var sb strings.Builder
+	for _, ll := range units {
+		sb.Reset()
+		for i := range ll {
+			sb.WriteString(ll[i].MarkedUp)
+			sb.WriteString(" ")
+		}
+
+		txt := stripper(sb.String(), strip)
+		txt = makesubstitutions(txt)
+		txt = gen.SwapAcuteForGrave(txt)
+		txt = stripper(strings.ToLower(txt), []string{LDANOTACHAR})
+
+		var sl BagWithLocus
+		sl.Loc = fmt.Sprintf(LOC, ll[0].WkUID, ll[0].TbIndex)
+		sl.Bag = strings.Join(strings.Fields(txt), " ")
+		if sl.Bag != "" {
+			thebags = append(thebags, sl)
+		}
+	}
+
+	return thebags
+}
+
+//
+// TOPIC PROFILES
+//
+
+// topicprofiles - the topic profile of each work in the model; or, if there is only one work, of each of its documents
+// (unless they are sentences: there would be too many of them to draw); the number of rows is capped: the heatmap and
+// the dendrogram both say so when rows were dropped
+func topicprofiles(unit string, bags []BagWithLocus, docsOverTopics mat.Matrix) ([]topicprofile, int) {
+	const (
+		UNIT = "%s [%s]"
+	)
+
+	ntopics, ndocs := docsOverTopics.Dims()
+	if ndocs > len(bags) {
+		ndocs = len(bags)
+	}
+
+	mix := func(docs []int) []float64 {
+		m := make([]float64, ntopics)
+		for _, d := range docs {
+			for t := 0; t < ntopics; t++ {
+				m[t] += docsOverTopics.At(t, d)
+			}
+		}
+		sum := 0.0
+		for _, v := range m {
+			sum += v
+		}
+		if sum > 0 {
+			for t := range m {
+				m[t] = m[t] / sum
+			}
+		}
+		return m
+	}
+
+	// [a] which docs belong to which work?
+
+	bywork := make(map[string][]int)
+	var order []string
+	for d := 0; d < ndocs; d++ {
+		tb := strings.Split(bags[d].Loc, "/")
+		if len(tb) < 3 {
+			continue
+		}
+		if _, ok := bywork[tb[1]]; !ok {
+			order = append(order, tb[1])
+		}
+		bywork[tb[1]] = append(bywork[tb[1]], d)
+	}
+
+	worklabel := func(u string) string {
+		if w, ok := mps.AllWorks[u]; ok {
+			return mps.DbWkMyAu(w).Shortname + ", " + w.Title
+		}
+		return u
+	}
+
+	// [b] one row per work; or one row per document of the only work
+
+	var pp []topicprofile
+	var total int
+	switch {
+	case len(order) > 1:
+		total = len(order)
+		for _, u := range order {
+			if len(pp) == vv.LDAMAXHEATROWS {
+				break
+			}
+			pp = append(pp, topicprofile{label: worklabel(u), docs: len(bywork[u]), mix: mix(bywork[u])})
+		}
+	case len(order) == 1 && unit != "sentence":
+		docs := bywork[order[0]]
+		total = len(docs)
+		for _, d := range docs {
+			if len(pp) == vv.LDAMAXHEATROWS {
+				break
+			}
+			b := bags[d]
+			b.GetWL()
+			pp = append(pp, topicprofile{label: fmt.Sprintf(UNIT, worklabel(order[0]), b.Workline.Citation()), docs: 1, mix: mix([]int{d})})
+		}
+	}
+
+	return pp, total
+}
+
+// topichellinger - the Hellinger distance between two topic profiles: 0 if they are the same; 1 if they share nothing
+func topichellinger(p []float64, q []float64) float64 {
+	bc := 0.0
+	for i := 0; i < len(p) && i < len(q); i++ {
+		bc += math.Sqrt(p[i] * q[i])
+	}
+	return math.Sqrt(math.Max(0, 1-bc))
+}
+
+// topicheatmap - the topic profiles as a heatmap of works x topics
+func topicheatmap(incl string, set string, pp []topicprofile, total int) string {
+	const (
+		TITLE    = "Topic profiles of %s"
+		SHOWN    = "%s (the first %d of %d rows)"
+		SAVEFILE = "lda_heatmap"
+		LIGHT    = 96
+	)
+
+	if len(pp) == 0 {
+		return ""
+	}
+
+	ntopics := len(pp[0].mix)
+
+	topics := make([]string, ntopics)
+	for t := 0; t < ntopics; t++ {
+		topics[t] = fmt.Sprintf("%d", t+1)
+	}
+
+	// the first profile goes on top
+	labels := make([]string, len(pp))
+	var data []opts.HeatMapData
+	mx := 0.0
+	for i, p := range pp {
+		r := len(pp) - 1 - i
+		labels[r] = p.label
+		for t, v := range p.mix {
+			data = append(data, opts.HeatMapData{Name: p.label, Value: [3]interface{}{t, r, math.Round(v*1000) / 1000}})
+			mx = math.Max(mx, v)
+		}
+	}
+
+	if total > len(pp) {
+		set = fmt.Sprintf(SHOWN, set, len(pp), total)
+	}
+
+	wd, ht := getvecchrtwdht()
+
+	hm := charts.NewHeatMap()
+	hm.SetGlobalOptions(
+		charts.WithTitleOpts(getcharttitleopts(fmt.Sprintf(TITLE, incl), set)),
+		charts.WithInitializationOpts(opts.Initialization{Width: wd, Height: ht}),
+		charts.WithTooltipOpts(getcharttooltip()),
+		charts.WithToolboxOpts(getcharttoolboxopts(SAVEFILE)),
+		charts.WithGridOpts(opts.Grid{Left: "30%", Bottom: "15%"}),
+		charts.WithXAxisOpts(opts.XAxis{Name: "topic", Type: "category", Data: topics, SplitArea: &opts.SplitArea{Show: true}}),
+		charts.WithYAxisOpts(opts.YAxis{Type: "category", Data: labels, SplitArea: &opts.SplitArea{Show: true}, AxisLabel: &opts.AxisLabel{Show: true}}),
+		charts.WithVisualMapOpts(opts.VisualMap{
+			Calculable: true,
+			Min:        0,
+			Max:        float32(mx),
+			Show:       true,
+			Right:      "0",
+			Top:        "center",
+			InRange:    &opts.VisualMapInRange{Color: []string{fmthsl(DOTHUE, DOTSAT, LIGHT), fmthsl(DOTHUE, DOTSAT, DOTLUM)}},
+		}),
+	)
+
+	hm.AddSeries("topics", data)
+
+	return customheatmaphtmlandjs(hm)
+}
+
+// topicdendrogram - average linkage (UPGMA) clustering of the topic profiles by Hellinger distance drawn as a tree;
+// cf. stylodendrogram(); the clustering is only as wide as the heatmap: see topicprofiles()
+func topicdendrogram(incl string, set string, pp []topicprofile, total int) string {
+	const (
+		TITLE    = "Topic similarity dendrogram of %s"
+		SHOWN    = "%s (the first %d of %d rows)"
+		SAVEFILE = "lda_dendrogram"
+		MINROWS  = 3
+	)
+
+	if len(pp) < MINROWS {
+		return ""
+	}
+
+	if total > len(pp) {
+		set = fmt.Sprintf(SHOWN, set, len(pp), total)
+	}
+
+	labels := make([]string, len(pp))
+	dist := mat.NewSymDense(len(pp), nil)
+	for i := range pp {
+		labels[i] = pp[i].label
+		for j := i + 1; j < len(pp); j++ {
+			dist.SetSym(i, j, topichellinger(pp[i].mix, pp[j].mix))
+		}
+	}
+	root := upgmatree(labels, dist)
+
+	wd, ht := getvecchrtwdht()
+
+	tree := charts.NewTree()
+	tree.SetGlobalOptions(
+		charts.WithTitleOpts(getcharttitleopts(fmt.Sprintf(TITLE, incl), set)),
+		charts.WithInitializationOpts(opts.Initialization{Width: wd, Height: ht}),
+		charts.WithTooltipOpts(getcharttooltip()),
+		charts.WithToolboxOpts(getcharttoolboxopts(SAVEFILE)),
+	)
+
+	tree.AddSeries("topics", []opts.TreeData{*root},
+		charts.WithTreeOpts(opts.TreeChart{
+			Layout:           "orthogonal",
+			Orient:           "LR",
+			InitialTreeDepth: -1,
+			Roam:             true,
+			Label:            &opts.Label{Show: true, Position: "left"},
+			Leaves:           &opts.TreeLeaves{Label: &opts.Label{Show: true, Position: "right"}},
+			Right:            "30%",
+		}),
+		getchartseriesstyle(0),
+	)
+
+	return customtreehtmlandjs(tree)
+}
@@ -80,6 +80,7 @@ func MakeDefaultSession(id string) str.ServerSession {
 	s.Earliest = vv.MINDATESTR
 	s.Latest = vv.MAXDATESTR
 	s.SortHitsBy = vv.SORTBY
+	s.KWICSort = vv.KWICSORT
 	s.HitContext = vv.DEFAULTLINESOFCONTEXT
 	s.BrowseCtx = lnch.Config.BrowserCtx
 	s.SearchScope = vv.DEFAULTPROXIMITYSCOPE
@@ -87,6 +88,16 @@ func MakeDefaultSession(id string) str.ServerSession {
 	s.LoginName = "Anonymous"
 	s.VocScansion = lnch.Config.VocabScans
 	s.VocByCount = lnch.Config.VocabByCt
+	s.NgramSize = vv.NGRAMSIZE
+	s.NgramFreq = vv.NGRAMMINFREQ
+	s.NgramLines = true
+	s.ReuseSize = vv.REUSESIZE
+	s.ReuseLemma = true
+	s.StyloSample = vv.STYLOSAMPLE
+	s.StyloMFW = vv.STYLOMFW
+	s.StyloGraph = vv.STYLOGRAPH
+	s.KeyRef = vv.KEYNESSREF
+	s.KeyLemma = true
 	s.VecGraphExt = lnch.Config.VectorWebExt
 	s.VecNeighbCt = lnch.Config.VectorNeighb
 	s.VecNNSearch = false
@@ -94,6 +105,8 @@ func MakeDefaultSession(id string) str.ServerSession {
 	s.VecTextPrep = lnch.Config.VectorTextPrep
 	s.VecLDASearch = false
 	s.LDA2D = true
+	s.LDAModeler = vv.LDAMODELER
+	s.LDAUnit = vv.LDAUNIT
 
 	if lnch.Config.Authenticate {
 		AllAuthorized.Register(id, false)
@@ -21,7 +21,8 @@ const (
 
 	AVGWORDSPERLINE      = 8 // hard coding a suspect assumption
 	BLACKANDWHITE        = false
-	CHARSPERLINE         = 60 // used by vector to preallocate memory: set it closer to a max than a real average
+	CHARSPERLINE         = 60  // used by vector to preallocate memory: set it closer to a max than a real average
+	COLLOCATESTOSHOW     = 100 // the html table is trimmed; the JSON and CSV exports are not
 	CONFIGLOCATION       = "."
 	CONFIGALTAPTH        = "%s/.config/" // %s = os.UserHomeDir()
 	CONFIGAUTH           = "hgs-users.json"
@@ -52,12 +53,24 @@ const (
 	DEFAULTPSQLPORT          = 5432
 	DEFAULTPSQLDB            = "hipparchiaDB"
 	DEFAULTQUERYSYNTAX       = "~"
+	DIACHRONICMINLINES       = 1000 // a period with less text than this does not get a model
+	DIACHRONICPERIODS        = "archaic:-850:-480,classical:-479:-323,hellenistic:-322:-31,imperial:-30:300"
+	DIACHRONICSHIFTS         = 40
+	DISPERSIONTOGRAPH        = 40     // the hit map draws at most this many works: those with the most hits
 	FIRSTSEARCHLIM           = 750000 // 149570 lines in Cicero (lt0474); all 485 forms of »δείκνυμι« will pass 50k
 	FONTSETTING              = "Noto"
 	GENRESTOCOUNT            = 5
 	HDBFOLDER                = "hDB"
 	INCERTADATE              = 2500
 	JSONINDENT               = "  "
+	KEYNESSLLMIN             = 3.84 // log-likelihood critical value for p < .05 at one degree of freedom
+	KEYNESSMINFREQ           = 3
+	KEYNESSREF               = "corpus" // or "stored"
+	KEYNESSTOGRAPH           = 25
+	KEYNESSTOSHOW            = 150
+	KEYNESSVOCABCACHE        = 4     // corpus vocabularies kept in memory: see FetchCorpusVocabulary()
+	KWICSORT                 = "hit" // or "l1", "l2", "r1", "r2"
+	KWICWORDS                = 6     // words of context on either side of a KWIC node
 	LENGTHOFAUTHORID         = 6
 	LENGTHOFWORKID           = 3
 	LDATOPICS                = 8
@@ -70,6 +83,12 @@ const (
 	LDAPERPEVALFRQ           = 10
 	LDAPERPTOL               = 1e-2
 	LDAMAXGRAPHLINES         = 30000
+	LDAMODELER               = "lda"      // or "nmf", "lsa"
+	LDATOPICRANGE            = "4-16-2"   // low-high-step for TopicCountSearch()
+	LDAUNIT                  = "sentence" // or "work", "level1", "level2", "level3", "chunk"
+	LDACHUNKWORDS            = 1000
+	LDAMAXHEATROWS           = 60
+	LDACOHERENCETOPN         = 10 // top words per topic for the coherence scores: the same for every K
 	MAXBROWSERCONTEXT        = 60
 	MAXDATE                  = 1500
 	MAXDATESTR               = "1500"
@@ -85,20 +104,35 @@ const (
 	MAXSEARCHTOTAL           = 4     // note that vectors and two-part searches generate subsearches and kick your total active search count over the number of "clicked" searches from RtSearch()
 	MAXTEXTLINEGENERATION    = 40000 // euripides is 33517 lines, sophocles is 15729, cicero is 149570, e.g.; jQuery slows exponentially as lines increase
 	MAXVOCABLINEGENERATION   = 1     // this is a multiplier for Config.MaxText; the browser does not get overwhelmed by these lists
+	MAXWORDLISTITEMS         = 1000  // cap on the size of an uploaded word list: see RtSearchWordList()
 	MAXTITLELENGTH           = 110
 	MINBROWSERWIDTH          = 90
 	MINDATE                  = -850
 	MINDATESTR               = "-850"
 	MINORGENREWTCAP          = 250
 	NESTEDLEMMASIZE          = 543
+	NGRAMMAX                 = 6 // the longest n-gram that RtNgramMaker() will build
+	NGRAMMIN                 = 2
+	NGRAMMINFREQ             = 2
+	NGRAMSIZE                = 3
+	NGRAMSTOSHOW             = 250
 	NUMBEROFCITATIONLEVELS   = 6
 	ORDERBY                  = "index"
 	POLLEVERYNTABLES         = 34 // 3455 is the max number of tables in a search...
+	REUSEMAXSRCHITS          = 25 // an n-gram found more often than this in the source is a formula and not an allusion
+	REUSESIZE                = 2
+	REUSETOSHOW              = 100
 	SERVEDFROMHOST           = "127.0.0.1"
 	SERVEDFROMPORT           = 8000
 	SIMULTANEOUSSEARCHES     = 3 // cap on the number of db connections at (S * Config.WorkerCount)
 	SHOWCITATIONEVERYNLINES  = 10
 	SORTBY                   = "shortname"
+	STYLOGRAPH               = "scatter"
+	STYLOMAXSAMPLE           = 20000
+	STYLOMFW                 = 150 // Burrows' Delta on the 150 most frequent words
+	STYLOMINSAMPLE           = 250
+	STYLOSAMPLE              = 2000
+	STYLOZETAWORDS           = 25
 	TEMPTABLETHRESHOLD       = 100 // if a table requires N "between" clauses, build a temptable instead to gather the needed lines
 	TERMINATIONS             = `(\s|\.|\]|\<|⟩|’|”|\!|,|:|;|\?|·|$)`
 	TICKERISACTIVE           = false
@@ -112,12 +146,22 @@ const (
 	VECTORNEIGHBORSMIN       = 4
 	VECTORTABLENAMENN        = "semantic_vectors_nn"
 	VECTORTABLENAMELDA       = "semantic_vectors_lda"
-	VECTORMAXLINES           = 1000000 // 964403 lines will get you all of Latin
+	VECTORTABLENAMEREG       = "semantic_vectors_registry"
+	VECTORCAPMB              = 0 // 0 is "no cap"; see VectorRegistryEvict()
+	VECTORCAPMODELS          = 0
+	VECTORIMPORTMAX          = 512 << 20 // the largest set of embeddings that RtVectorImport() will read
+	VECTORMAXLINES           = 1000000   // 964403 lines will get you all of Latin
 	VECTORMODELDEFAULT       = "w2v"
+	VECTORPASSAGECACHE       = 4     // how many passage indices MoreLikeThisSearch() keeps in memory
+	VECTORPASSAGELINES       = 4     // a "passage" for MoreLikeThisSearch() is this many lines of one work
+	VECTORPASSAGESIF         = 0.001 // the "a" of the SIF weight a/(a + p(w))
+	VECTORPASSAGESTOSHOW     = 25
+	VECTORPASSAGEWEIGHT      = "sif" // or "mean"
 	VECTORTEXTPREPDEFAULT    = "winner"
 	VECTROWEBEXTDEFAULT      = false
 	VOCABSCANSION            = false
 	VOCABBYCOUNT             = false
+	WORDCOUNTINITIALS        = `abcdefghijklmnopqrstuvwxyzαβψδεφγηιξκλμνοπρτυωχθζϲ` // the "wordcounts_" tables; everything else is in "wordcounts_0"
 	WRITEPERMS               = 0644
 	WSPOLLINGPAUSE           = 10000000 * 10 // 10000000 * 10 = every .1s
 
@@ -181,6 +181,39 @@ const (
         document.getElementById('browserclickscriptholder').appendChild(browserclickscript);
     }`
 
+	LEMMAFORMJS = `
+		$('lemmaform').click( function() {
+			$('#lemmatasearchform').val('');
+			$('#lemmatasearchform').hide();
+			$('#wordsearchform').show();
+			$('#wordsearchform').val(' ' + this.id + ' ');
+			$('#executesearch').click();
+		});`
+
+	COLLOCATESJS = `
+		$('.collocsort').click( function() {
+			let col = parseInt(this.getAttribute('data-col'));
+			let tbody = $('#collocatestable tbody');
+			let rows = tbody.find('tr').get();
+			let asc = $(this).hasClass('sortdesc');
+			$('.collocsort').removeClass('sortdesc sortasc');
+			$(this).addClass(asc ? 'sortasc' : 'sortdesc');
+			rows.sort(function(a, b) {
+				let x = $(a).children('td').eq(col);
+				let y = $(b).children('td').eq(col);
+				let r = 0;
+				if (col === 0) {
+					r = x.text().localeCompare(y.text());
+				} else {
+					r = parseFloat(x.attr('data-val')) - parseFloat(y.attr('data-val'));
+				}
+				return asc ? r : -r;
+			});
+			$.each(rows, function(i, row) { tbody.append(row); });
+		});`
+
+	COLLOCEXPORT = `export: <a href="%s" target="_blank">JSON</a> &middot; <a href="%s">CSV</a>`
+
 	AUTHHTML = `    
 	<div id="currentuser" class="unobtrusive">
         <span id="userid" class="user">{{index . "user" }}</span>
@@ -16,6 +16,7 @@ var (
 	TheLanguages  = []string{"greek", "latin"}
 	ServableFonts = map[string]str.FontTempl{"Noto": NotoFont, "Roboto": RobotoFont, "Fira": FiraFont} // cf rt-embhcss.go
 	LaunchTime    = time.Now()
+	CoverageBands = []int{100, 250, 500, 1000, 2000, 5000, 10000} // see RtCoverage()
 )
 
 var (
@@ -44,13 +44,17 @@ const (
    C1-pgC0 C2{string}C0 supply full PostgreSQL credentials C4(*)C0
    C1-qC0           quiet startup: suppress copyright notice
    C1-rlC0          reload the database tables; data will be read from: "C3{{.dbf}}C0" in "C3{{.cwd}}C0"
-   C1-rvC0          reset the stored semantic vector table
+   C1-rvC0          reset the stored semantic vector tables: the models, their registry, and the stored LDA results
    C1-saC0 C2{string}C0 server IP address [C6currentC0: C3{{.host}}C0]
    C1-spC0 C2{num}C0    server port [C6currentC0: C3{{.port}}C0]
    C1-stC0          run the self-test suite at vv; repeat the flag to iterate: e.g., "C1-st -stC0" will run twice
    C1-tkC0          turn on the uptime UptimeTicker [unavailable if OS is Windows]
    C1-uiC0 C2{string}C0 unacceptable input characters [C6currentC0: C3{{.badchars}}C0]
    C1-vC0           print version info and exit
+   C1-vdC0 C2{string}C0 delete the stored vector model with this fingerprint; repeat the flag to delete more than one
+   C1-vlC0          list the stored vector models and exit
+   C1-vmC0 C2{num}C0    keep at most this many stored vector models; the least recently used go first [C6currentC0: C3{{.vcapmodels}}C0]
+   C1-vsC0 C2{num}C0    keep the stored vector models under this many MB; the least recently used go first [C6currentC0: C3{{.vcapmb}}C0]
    C1-vvC0          print full version info and exit
    C1-wcC0 C2{int}C0    number of workers [C1cpu_countC0 is C3{{.cpus}}C0][C6currentC0: C3{{.workers}}C0]
    C1-zlC0          zap lunate sigmas and replace them with C1σ/ςC0
@@ -20,6 +20,7 @@ import (
 	"github.com/e-gun/HipparchiaGoServer/web"
 	"github.com/pkg/profile"
 	_ "net/http/pprof"
+	"os"
 	"runtime"
 	"sync"
 	"time"
@@ -39,6 +40,7 @@ func main() {
 		MSG3 = "corpus maps built"
 		MSG4 = "unnested lemma map built (%d items)"
 		MSG5 = "nested lemma map built"
+		MSG6 = "%d documents indexed: map[string]DbDocument"
 		SUMM = "C3initialization took %.3fsC0"
 		QUIT = "to stop the server press Control-C or close this window"
 	)
@@ -121,6 +123,21 @@ func main() {
 
 	go msg.Ticker(vv.TICKERDELAY)
 
+	// the vector registry needs to exist before [3] starts storing models; "-vl" wants nothing but the registry
+
+	if lnch.Config.ResetVectors {
+		vec.VectorDBReset()
+	}
+
+	if !lnch.Config.VectorsDisabled || lnch.Config.VectorList {
+		vec.VectorDBInitRegistry()
+	}
+
+	if lnch.Config.VectorList {
+		vec.VectorRegistryReport(mm.MSGMAND)
+		os.Exit(0)
+	}
+
 	//
 	// [3] concurrent loading of the core data
 	//
@@ -141,9 +158,13 @@ func main() {
 		msg.Timer("A2", fmt.Sprintf(MSG2, len(mps.AllAuthors)), start, previous)
 		previous = time.Now()
 
+		mps.AllDocs = mps.ActiveDocumentMapper()
+		msg.Timer("A3", fmt.Sprintf(MSG6, len(mps.AllDocs)), start, previous)
+		previous = time.Now()
+
 		// full up WkCorpusMap, AuCorpusMap, ...
 		mps.RePopulateGlobalMaps()
-		msg.Timer("A3", MSG3, start, previous)
+		msg.Timer("A4", MSG3, start, previous)
 	}(&awaiting)
 
 	awaiting.Add(1)
@@ -165,8 +186,18 @@ func main() {
 	go func(awaiting *sync.WaitGroup) {
 		defer awaiting.Done()
 		if lnch.Config.ResetVectors {
-			vec.VectorDBReset()
-		} else if lnch.Config.LogLevel >= mm.MSGNOTE {
+			return
+		}
+
+		for _, fp := range lnch.Config.VectorDelete {
+			vec.VectorDBDeleteNN(fp)
+		}
+
+		if lnch.Config.VectorCapModels > 0 || lnch.Config.VectorCapMB > 0 {
+			vec.VectorRegistryEvict(lnch.Config.VectorCapModels, lnch.Config.VectorCapMB, "")
+		}
+
+		if lnch.Config.LogLevel >= mm.MSGNOTE {
 			vec.VectorDBCountNN(mm.MSGNOTE)
 		}
 	}(&awaiting)
@@ -140,6 +140,7 @@ func StartEchoServer() {
 	e.GET("/hints/workgenre/:null", RtWkGenreHints)  //
 	e.GET("/hints/authlocation/:null", RtAuLocHints) //
 	e.GET("/hints/worklocation/:null", RtWkLocHints) //
+	e.GET("/hints/doclocation/:null", RtDocLocHints) // "u: /hints/doclocation/_?term=att"
 	e.GET("/hints/lemmata/:null", RtLemmaHints)      // "u: /hints/lemmata/_?term=dol"
 
 	//
@@ -162,8 +163,9 @@ func StartEchoServer() {
 	// [j] searching ("rt-search.go")
 	//
 
-	e.GET("/srch/vv/:id", RtSearchConfirm) // "GET /srch/vv/1f8f1d22 HTTP/1.1"
-	e.GET("/srch/exec/:id", RtSearch)      // "GET /srch/exec/1f8f1d22?skg=dolor HTTP/1.1"
+	e.GET("/srch/vv/:id", RtSearchConfirm)     // "GET /srch/vv/1f8f1d22 HTTP/1.1"
+	e.GET("/srch/exec/:id", RtSearch)          // "GET /srch/exec/1f8f1d22?skg=dolor HTTP/1.1"
+	e.POST("/srch/list/:id", RtSearchWordList) // "POST /srch/list/1f8f1d22?fmt=json HTTP/1.1"
 
 	//
 	// [k] selection ("rt-selection.go")
@@ -172,6 +174,7 @@ func StartEchoServer() {
 	e.GET("/selection/make/:locus", RtSelectionMake)   // "GET /selection/make/_?auth=gr7000 HTTP/1.1"
 	e.GET("/selection/clear/:locus", RtSelectionClear) // "GET /selection/clear/auselections/0 HTTP/1.1"
 	e.GET("/selection/fetch", RtSelectionFetch)        // "GET /selection/fetch HTTP/1.1"
+	e.GET("/selection/store/:null", RtSelectionStore)  // "GET /selection/store/_?clear=t HTTP/1.1"
 
 	//
 	// [l] set options ("rt-setoptions.go")
@@ -183,9 +186,19 @@ func StartEchoServer() {
 	// [m] text, vocab, and index ("rt-textmaker.go", "rt-lexica.go", "rt-vocab.go")
 	//
 
-	e.GET("/text/make/:null", RtTextMaker) // "u: /text/make/_"
-	e.GET("/text/index/:id", RtIndexMaker) // "u: /text/index/a26ec16c"
-	e.GET("/text/vocab/:id", RtVocabMaker) // "u: /text/vocab/ee068d29"
+	e.GET("/text/make/:null", RtTextMaker)          // "u: /text/make/_"
+	e.GET("/text/index/:id", RtIndexMaker)          // "u: /text/index/a26ec16c"
+	e.GET("/text/vocab/:id", RtVocabMaker)          // "u: /text/vocab/ee068d29"
+	e.GET("/text/ngrams/:id", RtNgramMaker)         // "u: /text/ngrams/5f0c3a71"
+	e.GET("/text/reuse/:id", RtTextReuse)           // "u: /text/reuse/9c41e0d2"
+	e.GET("/text/stylometry/:id", RtStylometry)     // "u: /text/stylometry/0b7d21ae"
+	e.GET("/text/keyness/:id", RtKeyness)           // "u: /text/keyness/61d0e3fa"
+	e.GET("/text/vocabcompare/:id", RtVocabCompare) // "u: /text/vocabcompare/d4e1b7a2"
+	e.GET("/text/coverage/:id", RtCoverage)         // "u: /text/coverage/7a3c90e1"
+	e.GET("/text/dispersion/:id", RtDispersion)     // "u: /text/dispersion/5e1d3a7f?w=λόγοϲ&lem=no"
+	e.GET("/text/annotated/:id", RtAnnotatedExport) // "u: /text/annotated/2b6e0f4c?dis=winner&fmt=conllu"
+	e.POST("/text/parsepasted/:id", RtParsePasted)  // "POST /text/parsepasted/5d0c9e2a?out=index" with the text to parse
+	e.POST("/text/coverage/:id", RtCoverage)        // "POST /text/coverage/7a3c90e1" with an "already known" list
 
 	//
 	// [n] websocket ("rt-websocket.go")
@@ -216,7 +229,16 @@ func StartEchoServer() {
 	// [q] vectors ("vectorqueryneighbors.go")
 	// pseudo-route RtVectors in vectorqueryneighbors.go is called by RtSearch() if the current session has VecNNSearch set to true
 
-	e.GET("/vbot/:typeandselection", RtVectorBot) // only the goroutine running the vectorbot is supposed to request this
+	e.GET("/vbot/:typeandselection", RtVectorBot)  // only the goroutine running the vectorbot is supposed to request this
+	e.GET("/vect/export/:fmt", RtVectorExport)     // "u: /vect/export/w2vbin" or "u: /vect/export/meta?fp=0ba1a79c..."
+	e.POST("/vect/import", RtVectorImport)         // "POST /vect/import" with word2vec or GloVe embeddings
+	e.GET("/vect/registry/:cmd", RtVectorRegistry) // list, delete, evict: only the host itself is supposed to request this
+	e.GET("/vect/query/:id", RtVectorQuery)        // "u: /vect/query/5e1d3a7f?q=rex ~ imperator"
+	e.GET("/vect/diachronic/:id", RtDiachronic)    // "u: /vect/diachronic/5e1d3a7f?w=λόγοϲ&p=archaic:-850:-480,classical:-479:-323"
+	e.GET("/vect/compare/:id", RtVectorCompare)    // "u: /vect/compare/5e1d3a7f?w=virtus"
+	e.GET("/vect/similar/:id", RtMoreLikeThis)     // "u: /vect/similar/5e1d3a7f?p=index/gr0012/001/345" or "...?q=ira furor"
+	e.GET("/vect/topics/:id", RtTopicClassify)     // "u: /vect/topics/5e1d3a7f?p=index/gr0012/001/345" or "...?q=ira furor"
+	e.GET("/vect/topiccount/:id", RtTopicCount)    // "u: /vect/topiccount/5e1d3a7f?k=4-16-2"
 
 	// next will do nothing if Config is not requesting these
 	go debug.RunSelfTests()
@@ -430,11 +430,16 @@ $('#provenanceautocomplete').autocomplete({
     source: '/hints/worklocation/_'
     });
 
+$('#doclocautocomplete').autocomplete({
+    source: '/hints/doclocation/_'
+    });
+
 $('#pickgenrebutton').click( function() {
         let genre = $('#genresautocomplete').val();
         let wkgenre = $('#workgenresautocomplete').val();
         let loc = $('#locationsautocomplete').val();
         let prov = $('#provenanceautocomplete').val();
+        let docloc = $('#doclocautocomplete').val();
 
         if (genre !== undefined && genre !== '') {
             $.getJSON('/selection/make/_?genre=' + genre, function (selectiondata) {
@@ -456,6 +461,11 @@ $('#pickgenrebutton').click( function() {
                 reloadselections(selectiondata);
              });
         }
+        if (docloc !== undefined && docloc !== '') {
+            $.getJSON('/selection/make/_?docloc=' + docloc, function (selectiondata) {
+                reloadselections(selectiondata);
+             });
+        }
         clearmany(categoryautofills);
         $('#searchlistcontents').hide();
     });
@@ -467,6 +477,7 @@ $('#excludegenrebutton').click( function() {
         let wkgenre = $('#workgenresautocomplete').val();
         let loc = $('#locationsautocomplete').val();
         let prov = $('#provenanceautocomplete').val();
+        let docloc = $('#doclocautocomplete').val();
 
         if (genre !== undefined && genre !== '') {
             $.getJSON('/selection/make/_?genre=' + genre +'&exclude=t', function (selectiondata) {
@@ -489,6 +500,11 @@ $('#excludegenrebutton').click( function() {
                 reloadselections(selectiondata);
              });
         }
+        if (docloc !== undefined && docloc !== '') {
+            $.getJSON('/selection/make/_?docloc=' + docloc +'&exclude=t', function (selectiondata) {
+                reloadselections(selectiondata);
+             });
+        }
         clearmany(categoryautofills);
         $('#searchlistcontents').hide();
     });
@@ -20,9 +20,11 @@ function loadoptions() {
             'bracketsquare': $('#bracketsquare'),
             'christiancorpus': $('#christiancorpus'),
             'collapseattic': $('#collapseattic'),
+            'collocates': $('#collocates'),
             'cosdistbylineorword': $('#cosdistbylineorword'),
             'cosdistbysentence': $('#cosdistbysentence'),
             'debughtml': $('#debughtml'),
+            'docdates': $('#docdates'),
             'debugdb': $('#debugdb'),
             'debuglex': $('#debuglex'),
             'debugparse': $('#debugparse'),
@@ -32,7 +34,10 @@ function loadoptions() {
             'inscriptioncorpus': $('#inscriptioncorpus'),
             'isldasearch': $('#isldasearch'),
             'isvectorsearch': $('#isvectorsearch'),
+            'kwic': $('#kwic'),
+            'vecexpand': $('#vecexpand'),
             'latincorpus': $('#latincorpus'),
+            'lemmaforms': $('#lemmaforms'),
             'morphdialects': $('#morphdialects'),
             'morphduals': $('#morphduals'),
             'morphemptyrows': $('#morphemptyrows'),
@@ -42,7 +47,13 @@ function loadoptions() {
             'morphpcpls': $('#morphpcpls'),
             'morphtables': $('#morphtables'),
             'nearestneighborsquery': $('#nearestneighborsquery'),
+            'ngramlemma': $('#ngramlemma'),
+            'ngramlines': $('#ngramlines'),
+            'ngramsentences': $('#ngramsentences'),
+            'ngramstops': $('#ngramstops'),
             'papyruscorpus': $('#papyruscorpus'),
+            'reuselemma': $('#reuselemma'),
+            'keylemma': $('#keylemma'),
             'phrasesummary': $('#phrasesummary'),
             'principleparts': $('#principleparts'),
             'quotesummary': $('#quotesummary'),
@@ -126,6 +137,11 @@ function loadoptions() {
             'browsercontext': $('#browserspinner'),
             'neighborcount': $('#neighborcount'),
             'ldatopiccount': $('#ldatopiccount'),
+            'ngramsize': $('#ngramsize'),
+            'ngramfreq': $('#ngramfreq'),
+            'reusesize': $('#reusesize'),
+            'stylosample': $('#stylosample'),
+            'stylomfw': $('#stylomfw'),
         };
 
         Object.keys(setspinnervalues).forEach(function(key) {
@@ -135,6 +151,9 @@ function loadoptions() {
         $('#sortresults').val(data.sortorder);
         $('#sortresults').selectmenu('refresh');
 
+        $('#kwicsort').val(data.kwicsort);
+        $('#kwicsort').selectmenu('refresh');
+
         $('#fontchoice').val(data.fontchoice);
         $('#fontchoice').selectmenu('refresh');
 
@@ -144,6 +163,18 @@ function loadoptions() {
         $('#vtextprep').val(data.vtextprep);
         $('#vtextprep').selectmenu('refresh');
 
+        $('#stylograph').val(data.stylograph);
+        $('#stylograph').selectmenu('refresh');
+
+        $('#keyref').val(data.keyref);
+        $('#keyref').selectmenu('refresh');
+
+        $('#ldamodeler').val(data.ldamodeler);
+        $('#ldamodeler').selectmenu('refresh');
+
+        $('#ldaunit').val(data.ldaunit);
+        $('#ldaunit').selectmenu('refresh');
+
     });
 }
 
@@ -269,7 +300,7 @@ $('#fewerchoicesbutton').click(function(){
     $('#morechoicesbutton').show();
     $('#fewerchoicesbutton').hide();
     let ids = Array('#fewerchoices', '#genresautocomplete', '#workgenresautocomplete', '#locationsautocomplete',
-        '#provenanceautocomplete', '#pickgenre', '#excludegenre', '#genreinfo', '#genrelistcontents', '#edts',
+        '#provenanceautocomplete', '#doclocautocomplete', '#pickgenre', '#excludegenre', '#genreinfo', '#genrelistcontents', '#edts',
         '#ldts', '#spuriacheckboxes');
     hidemany(ids);
     });
@@ -278,7 +309,7 @@ $('#morechoicesbutton').click(function(){
     $('#morechoicesbutton').hide();
     $('#fewerchoicesbutton').show();
     const ids = Array('#fewerchoices', '#genresautocomplete', '#workgenresautocomplete', '#locationsautocomplete',
-        '#provenanceautocomplete', '#pickgenre', '#excludegenre', '#genreinfo', '#edts', '#ldts', '#spuriacheckboxes');
+        '#provenanceautocomplete', '#doclocautocomplete', '#pickgenre', '#excludegenre', '#genreinfo', '#edts', '#ldts', '#spuriacheckboxes');
     // showmany(ids);
     let toshow = Array().concat(categoryautofills, extrasearchcriteria, genreselectbuttons);
     showmany(toshow);
@@ -310,6 +341,23 @@ function lookupifenterkeypressed(e) {
     }
 }
 
+$('#pastetoggle').click(function(){ $('#pastedtextpanel').toggle(); });
+
+function parsepastedtext(out) {
+    $('#searchsummary').html('');
+    $('#displayresults').html('');
+    $('#vectorgraphing').html('');
+    let searchid = generateId(8);
+    let url = '/text/parsepasted/' + searchid + '?out=' + out;
+    $.post(url, { pasted: $('#pastedtext').val() }, function (returnedtext) {
+        loadintodisplayresults(returnedtext);
+    }, 'json');
+}
+
+$('#parsepasted').click(function(){ parsepastedtext('parse'); });
+$('#indexpasted').click(function(){ parsepastedtext('index'); });
+$('#vocabpasted').click(function(){ parsepastedtext('vocab'); });
+
 $('#lexicalsearch').click(function(){
     lexsrch();
 });
@@ -383,6 +431,17 @@ $(function() {
 });
 
 
+$('#kwicsort').selectmenu({ width: 120});
+
+$(function() {
+        $('#kwicsort').selectmenu({
+            change: function() {
+                let result = $('#kwicsort').val();
+                setoptions('kwicsort', String(result));
+            }
+        });
+});
+
 $('#fontchoice').selectmenu({ width: 120});
 $(function() {
         $('#fontchoice').selectmenu({
@@ -405,6 +464,28 @@ $(function() {
     });
 });
 
+$('#ldamodeler').selectmenu({ width: 120});
+
+$(function() {
+    $('#ldamodeler').selectmenu({
+        change: function() {
+            let result = $('#ldamodeler').val();
+            setoptions('ldamodeler', String(result));
+        }
+    });
+});
+
+$('#ldaunit').selectmenu({ width: 120});
+
+$(function() {
+    $('#ldaunit').selectmenu({
+        change: function() {
+            let result = $('#ldaunit').val();
+            setoptions('ldaunit', String(result));
+        }
+    });
+});
+
 $('#vtextprep').selectmenu({ width: 120});
 
 $(function() {
@@ -416,6 +497,28 @@ $(function() {
     });
 });
 
+$('#stylograph').selectmenu({ width: 120});
+
+$(function() {
+    $('#stylograph').selectmenu({
+        change: function() {
+            let result = $('#stylograph').val();
+            setoptions('stylograph', String(result));
+        }
+    });
+});
+
+$('#keyref').selectmenu({ width: 180});
+
+$(function() {
+    $('#keyref').selectmenu({
+        change: function() {
+            let result = $('#keyref').val();
+            setoptions('keyref', String(result));
+        }
+    });
+});
+
 //
 // info
 //
@@ -287,6 +287,136 @@ $('#makevocablist').click( function() {
 
 });
 
+$('#storeselection').click( function() {
+    $.getJSON('/selection/store/_', function (returnedtext) {
+        $('#searchsummary').html(returnedtext['searchsummary']);
+    });
+});
+
+$('#findreuse').click( function() {
+    $('#searchsummary').html('');
+    $('#displayresults').html('');
+    $('#vectorgraphing').html('');
+    let searchid = generateId(8);
+    let url = '/text/reuse/' + searchid;
+    checkactivityviawebsocket(searchid);
+    $.getJSON(url, function (returnedtext) {
+        loadintodisplayresults(returnedtext);
+    });
+
+});
+
+$('#stylometry').click( function() {
+    $('#searchsummary').html('');
+    $('#displayresults').html('');
+    $('#vectorgraphing').html('');
+    let searchid = generateId(8);
+    let url = '/text/stylometry/' + searchid;
+    checkactivityviawebsocket(searchid);
+    $.getJSON(url, function (output) {
+        loadgraphingintodisplayresults(output);
+    });
+
+});
+
+$('#comparevocab').click( function() {
+    $('#searchsummary').html('');
+    $('#displayresults').html('');
+    $('#vectorgraphing').html('');
+    let searchid = generateId(8);
+    let url = '/text/vocabcompare/' + searchid;
+    checkactivityviawebsocket(searchid);
+    $.getJSON(url, function (returnedtext) {
+        loadintodisplayresults(returnedtext);
+    });
+
+});
+
+$('#annotatedexport').click( function() {
+    $('#searchsummary').html('');
+    $('#displayresults').html('');
+    $('#vectorgraphing').html('');
+    let searchid = generateId(8);
+    let url = '/text/annotated/' + searchid;
+    $.getJSON(url, function (returnedtext) {
+        loadintodisplayresults(returnedtext);
+    });
+});
+
+$('#readingcoverage').click( function() {
+    $('#searchsummary').html('');
+    $('#displayresults').html('');
+    $('#vectorgraphing').html('');
+    let searchid = generateId(8);
+    let url = '/text/coverage/' + searchid;
+    checkactivityviawebsocket(searchid);
+    // an "already known" list goes up with the request if one was chosen in the options
+    let known = document.getElementById('knownwords').files;
+    if (known.length > 0) {
+        let fd = new FormData();
+        fd.append('known', known[0]);
+        $.ajax({ url: url, type: 'POST', data: fd, processData: false, contentType: false, dataType: 'json',
+            success: function (returnedtext) { loadintodisplayresults(returnedtext); } });
+    } else {
+        $.getJSON(url, function (returnedtext) {
+            loadintodisplayresults(returnedtext);
+        });
+    }
+});
+
+$('#dispersion').click( function() {
+    $('#searchsummary').html('');
+    $('#displayresults').html('');
+    $('#vectorgraphing').html('');
+    let searchid = generateId(8);
+    // a lemma in the lemma box wins; otherwise the form in the word box
+    let lemma = $('#lemmatasearchform').val();
+    let url = '/text/dispersion/' + searchid + '?w=' + encodeURIComponent($('#wordsearchform').val()) + '&lem=no';
+    if (lemma.length > 0) { url = '/text/dispersion/' + searchid + '?w=' + encodeURIComponent(lemma) + '&lem=yes'; }
+    checkactivityviawebsocket(searchid);
+    $.getJSON(url, function (output) {
+        loadgraphingintodisplayresults(output);
+    });
+});
+
+$('#findkeywords').click( function() {
+    $('#searchsummary').html('');
+    $('#displayresults').html('');
+    $('#vectorgraphing').html('');
+    let searchid = generateId(8);
+    let url = '/text/keyness/' + searchid;
+    checkactivityviawebsocket(searchid);
+    $.getJSON(url, function (output) {
+        loadgraphingintodisplayresults(output);
+    });
+
+});
+
+function loadgraphingintodisplayresults(output) {
+    // the text tools that send a SearchOutputJSON: tables in 'found' and a chart in 'image'
+    $('#searchsummary').html(output['searchsummary']);
+    $('#displayresults').html(output['found']);
+    $('#vectorgraphing').html(output['image']);
+    let bcsh = document.getElementById("browserclickscriptholder");
+    if (bcsh.hasChildNodes()) { bcsh.removeChild(bcsh.firstChild); }
+    let browserclickscript = document.createElement('script');
+    browserclickscript.innerHTML = output['js'];
+    bcsh.appendChild(browserclickscript);
+}
+
+$('#makengrams').click( function() {
+    $('#searchsummary').html('');
+    $('#displayresults').html('');
+    $('#vectorgraphing').html('');
+    let searchid = generateId(8);
+    let url = '/text/ngrams/' + searchid;
+    checkactivityviawebsocket(searchid);
+    $.getJSON(url, function (returnedtext) {
+        loadintodisplayresults(returnedtext);
+    });
+
+});
+
 
 //
 // TEXTMAKER
@@ -415,6 +545,80 @@ $( '#ldatopiccount' ).spinner({
     }
 });
 
+$( '#ngramsize' ).spinner({
+    min: 2,
+    max: 6,
+    value: 3,
+    step: 1,
+    stop: function( event, ui ) {
+        let result = $('#ngramsize').spinner('value');
+        setoptions('ngramsize', String(result));
+    },
+    spin: function( event, ui ) {
+        let result = $('#ngramsize').spinner('value');
+        setoptions('ngramsize', String(result));
+    }
+});
+
+$( '#ngramfreq' ).spinner({
+    min: 1,
+    value: 2,
+    step: 1,
+    stop: function( event, ui ) {
+        let result = $('#ngramfreq').spinner('value');
+        setoptions('ngramfreq', String(result));
+    },
+    spin: function( event, ui ) {
+        let result = $('#ngramfreq').spinner('value');
+        setoptions('ngramfreq', String(result));
+    }
+});
+
+$( '#reusesize' ).spinner({
+    min: 2,
+    max: 6,
+    value: 2,
+    step: 1,
+    stop: function( event, ui ) {
+        let result = $('#reusesize').spinner('value');
+        setoptions('reusesize', String(result));
+    },
+    spin: function( event, ui ) {
+        let result = $('#reusesize').spinner('value');
+        setoptions('reusesize', String(result));
+    }
+});
+
+$( '#stylosample' ).spinner({
+    min: 250,
+    max: 20000,
+    value: 2000,
+    step: 250,
+    stop: function( event, ui ) {
+        let result = $('#stylosample').spinner('value');
+        setoptions('stylosample', String(result));
+    },
+    spin: function( event, ui ) {
+        let result = $('#stylosample').spinner('value');
+        setoptions('stylosample', String(result));
+    }
+});
+
+$( '#stylomfw' ).spinner({
+    min: 10,
+    max: 1000,
+    value: 150,
+    step: 10,
+    stop: function( event, ui ) {
+        let result = $('#stylomfw').spinner('value');
+        setoptions('stylomfw', String(result));
+    },
+    spin: function( event, ui ) {
+        let result = $('#stylomfw').spinner('value');
+        setoptions('stylomfw', String(result));
+    }
+});
+
 $( '#latestdate' ).spinner({
     min: -850,
     max: 1500,
@@ -496,3 +700,96 @@ righttmodalheader.ondblclick = function() {
         rmb.classList.add("hide");
     }
 }
+
+$('#importembeddings').change( function() {
+    // the embeddings are stored as the "imported" model for the current selection and text prep
+    let chosen = document.getElementById('importembeddings').files;
+    if (chosen.length === 0) { return; }
+    $('#searchsummary').html('');
+    $('#displayresults').html('');
+    $('#vectorgraphing').html('');
+    let fd = new FormData();
+    fd.append('embeddings', chosen[0]);
+    $.ajax({ url: '/vect/import', type: 'POST', data: fd, processData: false, contentType: false, dataType: 'json',
+        success: function (returnedtext) { loadintodisplayresults(returnedtext); } });
+});
+
+$('#semanticchange').click( function() {
+    $('#searchsummary').html('');
+    $('#displayresults').html('');
+    $('#vectorgraphing').html('');
+    let searchid = generateId(8);
+    // a lemma in the lemma box wins; otherwise the form in the word box; either may be blank
+    let w = $('#lemmatasearchform').val();
+    if (w.length === 0) { w = $('#wordsearchform').val(); }
+    let url = '/vect/diachronic/' + searchid + '?w=' + encodeURIComponent(w) + '&p=' + encodeURIComponent($('#diachronicperiods').val());
+    checkactivityviawebsocket(searchid);
+    $.getJSON(url, function (output) {
+        loadgraphingintodisplayresults(output);
+    });
+});
+
+$('#compareneighbors').click( function() {
+    $('#searchsummary').html('');
+    $('#displayresults').html('');
+    $('#vectorgraphing').html('');
+    let searchid = generateId(8);
+    // a lemma in the lemma box wins; otherwise the form in the word box
+    let w = $('#lemmatasearchform').val();
+    if (w.length === 0) { w = $('#wordsearchform').val(); }
+    let url = '/vect/compare/' + searchid + '?w=' + encodeURIComponent(w);
+    checkactivityviawebsocket(searchid);
+    $.getJSON(url, function (output) {
+        loadgraphingintodisplayresults(output);
+    });
+});
+
+function morelikethis(query) {
+    $('#searchsummary').html('');
+    $('#displayresults').html('');
+    $('#vectorgraphing').html('');
+    let searchid = generateId(8);
+    let url = '/vect/similar/' + searchid + '?' + query;
+    checkactivityviawebsocket(searchid);
+    $.getJSON(url, function (output) {
+        loadgraphingintodisplayresults(output);
+    });
+}
+
+$('#morelikethis').click( function() {
+    // the focus line of the browser: see buildbrowsertable()
+    let focus = document.getElementById('browsertableuid').attributes.focus.value;
+    $('#browserdialog').hide();
+    morelikethis('p=' + encodeURIComponent(focus));
+});
+
+$('#morelikethesewords').keydown( function(e) {
+    if (e.which === 13 && this.value.trim().length > 0) {
+        morelikethis('q=' + encodeURIComponent(this.value));
+    }
+});
+
+function topicquery(route, query) {
+    $('#searchsummary').html('');
+    $('#displayresults').html('');
+    $('#vectorgraphing').html('');
+    let searchid = generateId(8);
+    let url = '/vect/' + route + '/' + searchid + '?' + query;
+    checkactivityviawebsocket(searchid);
+    $.getJSON(url, function (output) {
+        loadgraphingintodisplayresults(output);
+    });
+}
+
+$('#classifytopics').click( function() {
+    // the focus line of the browser: see buildbrowsertable()
+    let focus = document.getElementById('browsertableuid').attributes.focus.value;
+    $('#browserdialog').hide();
+    topicquery('topics', 'p=' + encodeURIComponent(focus));
+});
+
+$('#ldatopicrange').keydown( function(e) {
+    if (e.which === 13) {
+        topicquery('topiccount', 'k=' + encodeURIComponent(this.value.trim()));
+    }
+});
@@ -78,6 +78,12 @@ $('#includevaria').change(function() {
     loadoptions();
     });
 
+$('#docdates').change(function() {
+    if(this.checked) { setoptions('docdates', 'yes'); } else { setoptions('docdates', 'no'); }
+    refreshselections();
+    loadoptions();
+    });
+
 $('#includeincerta').change(function() {
     if(this.checked) { setoptions('incerta', 'yes'); } else { setoptions('incerta', 'no'); }
     refreshselections();
@@ -114,6 +120,21 @@ $('#christiancorpus').change(function() {
     loadoptions();
     });
 
+$('#kwic').change(function() {
+    if(this.checked) { setoptions('kwic', 'yes'); } else { setoptions('kwic', 'no'); }
+    loadoptions();
+    });
+
+$('#collocates').change(function() {
+    if(this.checked) { setoptions('collocates', 'yes'); } else { setoptions('collocates', 'no'); }
+    loadoptions();
+    });
+
+$('#lemmaforms').change(function() {
+    if(this.checked) { setoptions('lemmaforms', 'yes'); } else { setoptions('lemmaforms', 'no'); }
+    loadoptions();
+    });
+
 $('#vocbycount').change(function() {
     if(this.checked) { setoptions('vocbycount', 'yes'); } else { setoptions('vocbycount', 'no'); }
     refreshselections();
@@ -126,6 +147,36 @@ $('#vocscansion').change(function() {
     loadoptions();
 });
 
+$('#ngramlemma').change(function() {
+    if(this.checked) { setoptions('ngramlemma', 'yes'); } else { setoptions('ngramlemma', 'no'); }
+    loadoptions();
+});
+
+$('#ngramstops').change(function() {
+    if(this.checked) { setoptions('ngramstops', 'yes'); } else { setoptions('ngramstops', 'no'); }
+    loadoptions();
+});
+
+$('#ngramlines').change(function() {
+    if(this.checked) { setoptions('ngramlines', 'yes'); } else { setoptions('ngramlines', 'no'); }
+    loadoptions();
+});
+
+$('#ngramsentences').change(function() {
+    if(this.checked) { setoptions('ngramsentences', 'yes'); } else { setoptions('ngramsentences', 'no'); }
+    loadoptions();
+});
+
+$('#reuselemma').change(function() {
+    if(this.checked) { setoptions('reuselemma', 'yes'); } else { setoptions('reuselemma', 'no'); }
+    loadoptions();
+});
+
+$('#keylemma').change(function() {
+    if(this.checked) { setoptions('keylemma', 'yes'); } else { setoptions('keylemma', 'no'); }
+    loadoptions();
+});
+
 // lemmata and vectors
 
 $('#isvectorsearch').change(function() {
@@ -135,15 +186,22 @@ $('#isvectorsearch').change(function() {
         refreshselections();
         loadoptions();
         lsf.attr('placeholder', '(semantic neighbors of...)');
+        lsf.attr('title', 'neighbors: "a"; similarity: "a ~ b"; analogy (a:b :: c:?): "b - a + c"; odd one out: "a | b | c"');
     } else {
         setoptions('isvectorsearch', 'no');
         hidevectornotification();
         refreshselections();
         loadoptions();
         lsf.attr('placeholder', '(all forms of...)');
+        lsf.removeAttr('title');
     }
 });
 
+$('#vecexpand').change(function() {
+    if(this.checked) { setoptions('vecexpand', 'yes'); } else { setoptions('vecexpand', 'no'); }
+    loadoptions();
+    });
+
 $('#isldasearch').change(function() {
     if(this.checked) {
         setoptions('isldasearch', 'yes');
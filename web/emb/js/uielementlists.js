@@ -90,7 +90,7 @@ const endpointnoticesandbuttons = endpointnotices.concat(endpointbuttons);
 // category selection ui
 
 const categoryautofills = Array('#genresautocomplete', '#workgenresautocomplete', '#locationsautocomplete',
-    '#provenanceautocomplete');
+    '#provenanceautocomplete', '#doclocautocomplete');
 
 const nonessentialautofills = Array().concat(categoryautofills, ['#worksautocomplete']);
 
@@ -154,7 +154,7 @@ let vectoroptionarray = Array('cosdistbysentence', 'cosdistbylineorword', 'seman
 
 // collections of elements that have logical connections
 
-const corepickui = ['#worksautocomplete', '#makeanindex', '#textofthis', '#browseto', '#authinfobutton', '#makevocablist'];
+const corepickui = ['#worksautocomplete', '#makeanindex', '#textofthis', '#browseto', '#authinfobutton', '#makevocablist', '#makengrams', '#storeselection', '#findreuse', '#comparevocab', '#annotatedexport', '#readingcoverage', '#dispersion', '#semanticchange', '#compareneighbors', '#findkeywords', '#stylometry'];
 const postauthorpickui = Array().concat(corepickui, coreactionbuttons);
 const postbrowsepickui = Array().concat(corepickui, ['#browserdialog']);
 const extrauichoices = Array().concat(categoryautofills);
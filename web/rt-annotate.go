@@ -0,0 +1,327 @@
+//    HipparchiaGoServer
+//    Copyright: E Gunderson 2022-24
+//    License: GNU GENERAL PUBLIC LICENSE 3
+//        (see LICENSE in the top level directory of the distribution)
+
+package web
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"github.com/e-gun/HipparchiaGoServer/internal/base/gen"
+	"github.com/e-gun/HipparchiaGoServer/internal/base/str"
+	"github.com/e-gun/HipparchiaGoServer/internal/db"
+	"github.com/e-gun/HipparchiaGoServer/internal/lnch"
+	"github.com/e-gun/HipparchiaGoServer/internal/mps"
+	"github.com/e-gun/HipparchiaGoServer/internal/search"
+	"github.com/e-gun/HipparchiaGoServer/internal/vec"
+	"github.com/e-gun/HipparchiaGoServer/internal/vlt"
+	"github.com/e-gun/HipparchiaGoServer/internal/vv"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// annotoken - one word of an annotated export
+type annotoken struct {
+	form   string   // as printed in the text
+	norm   string   // as normalized for the index and vocabulary makers: what the headwords are looked up by
+	lemma  string   // the chosen headword; "" if unparsed
+	cands  []string // all of the possible headwords
+	anal   []string // the analyses that go with the chosen headword(s)
+	winner string   // "contextual" only: the winner that the context overrode; "" if it did not
+}
+
+// annosentence - one sentence of an annotated export and where it is found
+type annosentence struct {
+	work   string
+	cit    string
+	tokens []annotoken
+}
+
+// RtAnnotatedExport - the current selection as a lemmatized and parsed CoNLL-U or TSV file for use by other tools
+func RtAnnotatedExport(c echo.Context) error {
+	c.Response().After(func() { Msg.LogPaths("RtAnnotatedExport()") })
+
+	// "?fmt=conllu" or "?fmt=tsv" sends the file; add "&dis=winner", "&dis=montecarlo", "&dis=yoked", or
+	// "&dis=contextual" to choose how
+	// ambiguous forms are lemmatized; the default is the session's vector text prep (or "winner" if that is "unparsed")
+	// without "fmt" you get a set of links to the various files
+
+	// CoNLL-U: one sentence per sentence of the selection; FORM and "# text" are the words as printed; the lemma is the
+	// chosen headword; XPOS holds the analysis if there is only one; MISC holds "Norm=..." if the form had to be
+	// normalized to be looked up, all of the candidate headwords, all of the analyses for the chosen headword, and
+	// "Ambiguous=Yes" if the form could come from more than one headword; with "contextual" MISC also holds "Winner=..."
+	// if the words around the form overrode the most common headword (and the TSV file gets a "winner" column)
+
+	const (
+		SUMM = `
+		<div id="searchsummary">Annotated export of the current selection<br>
+			every word is exported as printed; it is looked up in the form that the index and vocabulary makers use, lemmatized, and parsed<br>
+			ambiguous forms are marked; they are also resolved via one of the text prep strategies:<br>
+			%s
+			<span class="small">("montecarlo" makes a new set of weighted guesses with every download)</span><br>
+			<span class="small">("contextual" marks the forms where the words around them overrode the most common headword)</span><br>
+		</div>
+		`
+		LNK     = `%s: <a href="%s">CoNLL-U</a> &middot; <a href="%s">TSV</a><br>`
+		CONLLFN = "hipparchia_%s.conllu"
+		TSVFN   = "hipparchia_%s.tsv"
+	)
+
+	type JSFeeder struct {
+		SU string `json:"searchsummary"`
+		HT string `json:"thehtml"`
+		NJ string `json:"newjs"`
+	}
+
+	user := vlt.ReadUUIDCookie(c)
+	if !vlt.AllAuthorized.Check(user) {
+		return c.JSONPretty(http.StatusOK, JSFeeder{NJ: vv.JSVALIDATION}, vv.JSONINDENT)
+	}
+
+	se := vlt.AllSessions.GetSess(user)
+
+	strategies := []string{"winner", "montecarlo", "yoked", "contextual"}
+
+	dis := c.QueryParam("dis")
+	if !slices.Contains(strategies, dis) {
+		dis = se.VecTextPrep
+	}
+	if !slices.Contains(strategies, dis) {
+		dis = "winner"
+	}
+
+	ff := c.QueryParam("fmt")
+	if ff != "conllu" && ff != "tsv" {
+		var lnk strings.Builder
+		for _, s := range strategies {
+			u := c.Request().URL.Path + "?dis=" + s + "&fmt="
+			lnk.WriteString(fmt.Sprintf(LNK, s, u+"conllu", u+"tsv"))
+		}
+		return gen.JSONresponse(c, JSFeeder{SU: fmt.Sprintf(SUMM, lnk.String())})
+	}
+
+	// [a] get the lines
+
+	mx := lnch.Config.MaxText * vv.MAXVOCABLINEGENERATION
+	ansrch := search.SessionIntoBulkSearch(c, mx)
+	vlt.WSInfo.Del <- ansrch.WSID
+
+	if ansrch.Results.Len() == 0 {
+		return c.String(http.StatusOK, "")
+	}
+
+	// [b] annotate them
+
+	sentences := annotatelines(ansrch.Results.Lines, dis)
+
+	// [c] format them
+
+	var out []byte
+	var fn string
+	if ff == "tsv" {
+		out = annotatedtsv(sentences, dis)
+		fn = fmt.Sprintf(TSVFN, dis)
+	} else {
+		out = annotatedconllu(sentences, dis)
+		fn = fmt.Sprintf(CONLLFN, dis)
+	}
+
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%s", fn))
+	return c.Blob(http.StatusOK, "text/plain; charset=utf-8", out)
+}
+
+// annotatelines - split the lines into sentences and lemmatize and parse every word
+func annotatelines(lines []str.DbWorkline, dis string) []annosentence {
+	// [a] tokenize: sentences may cross lines but not works
+
+	// AccentedSlice() is not enough here: the accented line has lost the punctuation that marks the sentences and
+	// it has already been lowercased and had its sigmas and accents normalized; the marked up line has neither
+	// problem; ngramclean() yields the same form that the index and vocabulary makers get out of AccentedSlice()
+	tokens, places := ngramtokenize(lines, true, false)
+
+	var words []string
+	for _, t := range tokens {
+		words = append(words, t.word)
+	}
+
+	// "contextual" needs the words in order
+	var inctx []string
+	if dis == "contextual" {
+		inctx = vec.ContextualHeadwords(words)
+	}
+
+	words = gen.Unique(words)
+
+	// [b] the headwords and the analyses; for "contextual" this is the winner that the context might override
+	base := dis
+	if dis == "contextual" {
+		base = "winner"
+	}
+	choose := vec.HeadwordChooser(words, base)
+	morphmap := db.ArrayToGetRequiredMorphObjects(words)
+
+	// extractmorphpossibilities() has cleaned its headwords: "re-pono" is now "repono"
+	clean := strings.NewReplacer("-", "", "̄", "")
+	norm := func(hw string) string {
+		return strings.ToLower(clean.Replace(hw))
+	}
+
+	possib := make(map[string][]str.MorphPossib, len(morphmap))
+	for w, m := range morphmap {
+		possib[w] = extractmorphpossibilities(m.RawPossib)
+	}
+
+	// [c] assemble the sentences
+	var sentences []annosentence
+	var cur annosentence
+	startline := -1
+
+	for i, t := range tokens {
+		if startline < 0 {
+			startline = t.line
+			cur = annosentence{work: places[t.line].Wk, cit: places[t.line].Cit}
+		}
+
+		at := annotoken{form: t.surface, norm: t.word, lemma: clean.Replace(choose(t.word))}
+		if inctx != nil && inctx[i] != "" && clean.Replace(inctx[i]) != at.lemma {
+			at.winner, at.lemma = at.lemma, clean.Replace(inctx[i])
+		}
+
+		chosen := make(map[string]bool)
+		for _, hw := range strings.Split(at.lemma, "ˣ") {
+			chosen[norm(hw)] = true
+		}
+
+		seen := make(map[string]bool)
+		for _, p := range possib[t.word] {
+			if !seen[p.Headwd] {
+				seen[p.Headwd] = true
+				at.cands = append(at.cands, p.Headwd)
+			}
+			if chosen[norm(p.Headwd)] && !slices.Contains(at.anal, p.Anal) {
+				at.anal = append(at.anal, p.Anal)
+			}
+		}
+		sort.Strings(at.cands)
+		cur.tokens = append(cur.tokens, at)
+
+		if t.brk || i == len(tokens)-1 {
+			if places[t.line].Cit != cur.cit && t.line != startline {
+				cur.cit = cur.cit + "–" + places[t.line].Cit
+			}
+			sentences = append(sentences, cur)
+			startline = -1
+		}
+	}
+	return sentences
+}
+
+// annotatedconllu - the sentences as CoNLL-U
+func annotatedconllu(sentences []annosentence, dis string) []byte {
+	const (
+		HEAD = "# generator = HipparchiaGoServer\n# lemmatization = %s\n"
+		DOC  = "# newdoc id = %s\n"
+		SENT = "# sent_id = %d\n# citation = %s\n# text = %s\n"
+		TOK  = "%d\t%s\t%s\t_\t%s\t_\t_\t_\t_\t%s\n"
+	)
+
+	var buf bytes.Buffer
+	buf.WriteString(fmt.Sprintf(HEAD, dis))
+
+	lastwk := ""
+	for i, s := range sentences {
+		if s.work != lastwk {
+			buf.WriteString(fmt.Sprintf(DOC, s.work))
+			lastwk = s.work
+		}
+
+		var forms []string
+		for _, t := range s.tokens {
+			forms = append(forms, t.form)
+		}
+
+		cit := s.cit
+		if wk, ok := mps.AllWorks[s.work]; ok {
+			cit = fmt.Sprintf("%s, %s %s", mps.DbWkMyAu(wk).Cleaname, wk.Title, s.cit)
+		}
+		buf.WriteString(fmt.Sprintf(SENT, i+1, cit, strings.Join(forms, " ")))
+
+		for j, t := range s.tokens {
+			lem := conllufield(t.lemma)
+			xpos := "_"
+			if len(t.anal) == 1 {
+				xpos = conllufield(t.anal[0])
+			}
+
+			var misc []string
+			if t.norm != t.form {
+				misc = append(misc, "Norm="+conllufield(t.norm))
+			}
+			if len(t.cands) > 0 {
+				misc = append(misc, "Candidates="+conllufield(strings.Join(t.cands, ",")))
+			}
+			if len(t.anal) > 0 {
+				misc = append(misc, "Analyses="+conllufield(strings.Join(t.anal, ";")))
+			}
+			if len(t.cands) > 1 {
+				misc = append(misc, "Ambiguous=Yes")
+			}
+			if len(t.cands) == 0 {
+				misc = append(misc, "Unparsed=Yes")
+			}
+			if t.winner != "" {
+				misc = append(misc, "Winner="+conllufield(t.winner))
+			}
+
+			buf.WriteString(fmt.Sprintf(TOK, j+1, t.form, lem, xpos, strings.Join(misc, "|")))
+		}
+		buf.WriteString("\n")
+	}
+
+	return buf.Bytes()
+}
+
+// annotatedtsv - the sentences as one word per row; "contextual" adds a column for the winners that it overrode
+func annotatedtsv(sentences []annosentence, dis string) []byte {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Comma = '\t'
+
+	head := []string{"sentence", "token", "work", "citation", "form", "normalized", "lemma", "candidates", "analyses", "ambiguous"}
+	if dis == "contextual" {
+		head = append(head, "winner")
+	}
+	_ = w.Write(head)
+	for i, s := range sentences {
+		for j, t := range s.tokens {
+			amb := "no"
+			if len(t.cands) > 1 {
+				amb = "yes"
+			}
+			row := []string{fmt.Sprintf("%d", i+1), fmt.Sprintf("%d", j+1), s.work, s.cit, t.form, t.norm, t.lemma,
+				strings.Join(t.cands, ","), strings.Join(t.anal, "; "), amb}
+			if dis == "contextual" {
+				row = append(row, t.winner)
+			}
+			_ = w.Write(row)
+		}
+	}
+	w.Flush()
+	Msg.EC(w.Error())
+
+	return buf.Bytes()
+}
+
+// conllufield - CoNLL-U fields may not be empty or contain spaces or "|"
+func conllufield(s string) string {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return "_"
+	}
+	return strings.NewReplacer(" ", "_", "|", "/", "\t", "_").Replace(s)
+}
@@ -351,7 +351,7 @@ func basiccitation(l str.DbWorkline) string {
 func buildbrowsertable(focus int, lines []str.DbWorkline) string {
 	const (
 		OBSREGTEMPL = "(^|\\s|\\[|\\>|⟨|‘|“|;)(%s)" + vv.TERMINATIONS
-		UIDDIV      = `<div id="browsertableuid" uid="%s"></div>`
+		UIDDIV      = `<div id="browsertableuid" uid="%s" focus="%s"></div>`
 		TRTMPL      = `
             <tr class="browser">
                 <td class="browserembeddedannotations">%s</td>
@@ -477,7 +477,15 @@ func buildbrowsertable(focus int, lines []str.DbWorkline) string {
 	tab := strings.Join(trr, "")
 
 	// that was the body, now do the head and tail
-	top := fmt.Sprintf(UIDDIV, lines[0].AuID())
+	// the focus is what "more like this" will look for: see RtMoreLikeThis()
+	fl := lines[0].BuildHyperlink()
+	for i := range lines {
+		if lines[i].TbIndex == focus {
+			fl = lines[i].BuildHyperlink()
+		}
+	}
+
+	top := fmt.Sprintf(UIDDIV, lines[0].AuID(), fl)
 	top += `<table><tbody>`
 	// top += `<tr class="spacing">` + strings.Repeat("&nbsp;", MINBROWSERWIDTH) + `</tr>`
 
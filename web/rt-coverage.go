@@ -0,0 +1,323 @@
+//    HipparchiaGoServer
+//    Copyright: E Gunderson 2022-24
+//    License: GNU GENERAL PUBLIC LICENSE 3
+//        (see LICENSE in the top level directory of the distribution)
+
+package web
+
+import (
+	"fmt"
+	"github.com/e-gun/HipparchiaGoServer/internal/base/gen"
+	"github.com/e-gun/HipparchiaGoServer/internal/base/str"
+	"github.com/e-gun/HipparchiaGoServer/internal/db"
+	"github.com/e-gun/HipparchiaGoServer/internal/lnch"
+	"github.com/e-gun/HipparchiaGoServer/internal/search"
+	"github.com/e-gun/HipparchiaGoServer/internal/vec"
+	"github.com/e-gun/HipparchiaGoServer/internal/vlt"
+	"github.com/e-gun/HipparchiaGoServer/internal/vv"
+	"github.com/labstack/echo/v4"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// RtCoverage - how much of the current selection can a reader who knows the N most common headwords understand?
+func RtCoverage(c echo.Context) error {
+	c.Response().After(func() { Msg.LogPaths("RtCoverage()") })
+
+	// [1] coverage by corpus rank: the share of the words of the selection whose headword is among the N most common
+	// headwords of the active corpora (dictionary_headword_wordcounts)
+	// [2] a learning list: the headwords of the selection sorted by their frequency in the selection; learn them in
+	// this order and stop at 90% or 95%; words on the "already known" list count as covered from the start
+
+	// the known list is optional and arrives the way RtSearchWordList() gets its lists:
+	// "curl -F known=@known.txt /text/coverage/1f8f1d22" or as a plain text POST body: one item per line; forms are
+	// converted into their headwords
+
+	const (
+		SUMM = `
+		<div id="searchsummary">Reading coverage for %s,&nbsp;<span class="foundwork">%s</span><br>
+			%s parsed words; %s distinct headwords%s<br>
+			learn %d headwords to reach %d%% coverage and %d headwords to reach %d%%<br>
+			<span class="small">(%ss)</span><br>
+			%s
+		</div>
+		`
+		BANDS = `
+		<table>
+		<tr>
+			<th class="vocabtable">knowing the most common...</th>
+			<th class="vocabtable">covers</th>
+		</tr>
+		%s
+		</table>
+		<hr>`
+		BANDRW = `
+		<tr>
+			<td class="word">%s headwords</td>
+			<td class="count">%.1f%%</td>
+		</tr>`
+		THH = `
+		<table>
+		<tr>
+				<th class="vocabtable">&nbsp;</th>
+				<th class="vocabtable">headword</th>
+				<th class="vocabtable">count</th>
+				<th class="vocabtable">corpus rank</th>
+				<th class="vocabtable">coverage</th>
+				<th class="vocabtable">definitions</th>
+		</tr>
+		%s
+		</table>`
+		TRR = `
+		<tr>
+			<td class="count">%d</td>
+			<td class="word"><vocabobserved id="%s">%s</vocabobserved></td>
+			<td class="count">%d</td>
+			<td class="count">%s</td>
+			<td class="count">%.1f%%</td>
+			<td class="trans">%s</td>
+		</tr>`
+		MARK    = `<tr><td class="count" colspan="6"><span class="emph">%d%% coverage</span></td></tr>`
+		KNOWN   = "; %d of them on the \"already known\" list cover %.1f%% of the text"
+		FIRST   = 90
+		SECOND  = 95
+		MAXREAD = 1 << 20
+		MSG1    = "Grabbing the lines...&nbsp;(part 1 of 3)"
+		MSG2    = "Parsing the vocabulary...&nbsp;(part 2 of 3)"
+		MSG3    = "Building the HTML...&nbsp;(part 3 of 3)"
+		HITCAP  = `<span class="small"><span class="red emph">coverage report incomplete:</span>: hit the cap of %d on allowed lines</span>`
+	)
+
+	type JSFeeder struct {
+		SU string `json:"searchsummary"`
+		HT string `json:"thehtml"`
+		NJ string `json:"newjs"`
+	}
+
+	user := vlt.ReadUUIDCookie(c)
+	if !vlt.AllAuthorized.Check(user) {
+		return c.JSONPretty(http.StatusOK, JSFeeder{NJ: vv.JSVALIDATION}, vv.JSONINDENT)
+	}
+
+	start := time.Now()
+	se := vlt.AllSessions.GetSess(user)
+
+	// [a] the known list, if any
+
+	var known []string
+	if fh, err := c.FormFile("known"); err == nil {
+		f, e := fh.Open()
+		if e == nil {
+			b, _ := io.ReadAll(io.LimitReader(f, MAXREAD))
+			known = strings.Split(string(b), "\n")
+			_ = f.Close()
+		}
+	} else if c.Request().Method == http.MethodPost {
+		b, _ := io.ReadAll(io.LimitReader(c.Request().Body, MAXREAD))
+		known = strings.Split(string(b), "\n")
+	}
+
+	// "si" is a blank search struct used for progress reporting
+	si := search.BuildDefaultSearch(c)
+	si.Type = "coverage"
+
+	vlt.WSInfo.UpdateSummMsg <- vlt.WSSIKVs{si.WSID, MSG1}
+	vlt.WSInfo.UpdateRemain <- vlt.WSSIKVi{si.WSID, 1}
+
+	// [b] the selection
+
+	mx := lnch.Config.MaxText * vv.MAXVOCABLINEGENERATION
+	covsrch := search.SessionIntoBulkSearch(c, mx)
+
+	if covsrch.Results.Len() == 0 {
+		return emptyjsreturn(c)
+	}
+
+	vlt.WSInfo.UpdateSummMsg <- vlt.WSSIKVs{si.WSID, MSG2}
+
+	count, total := keynesscount(&covsrch, true)
+	headwords := gen.StringMapKeysIntoSlice(count)
+
+	ranks := db.FetchHeadwordCorpusRanks(headwords, activecorpora(se))
+	glosses := coverageglosses(&covsrch)
+	knownset := coverageknown(known)
+
+	// [c] coverage by corpus rank
+
+	m := message.NewPrinter(language.English)
+
+	var brr strings.Builder
+	for _, n := range vv.CoverageBands {
+		covered := 0
+		for hw, ct := range count {
+			if r, ok := ranks[hw]; ok && r <= n {
+				covered += ct
+			}
+		}
+		brr.WriteString(fmt.Sprintf(BANDRW, m.Sprintf("%d", n), coveragepct(covered, total)))
+	}
+
+	// [d] the learning list
+
+	vlt.WSInfo.UpdateSummMsg <- vlt.WSSIKVs{si.WSID, MSG3}
+
+	covered := 0
+	knownhere := 0
+	var tolearn []string
+	for _, hw := range headwords {
+		if _, ok := knownset[hw]; ok {
+			covered += count[hw]
+			knownhere++
+		} else {
+			tolearn = append(tolearn, hw)
+		}
+	}
+	knownpct := coveragepct(covered, total)
+
+	sort.Slice(tolearn, func(i, j int) bool {
+		if count[tolearn[i]] == count[tolearn[j]] {
+			ri, oki := ranks[tolearn[i]]
+			rj, okj := ranks[tolearn[j]]
+			if oki && okj && ri != rj {
+				return ri < rj
+			}
+			return tolearn[i] < tolearn[j]
+		}
+		return count[tolearn[i]] > count[tolearn[j]]
+	})
+
+	var trr strings.Builder
+	nfirst, nsecond := 0, 0
+	for i, hw := range tolearn {
+		if coveragepct(covered, total) >= SECOND {
+			break
+		}
+		covered += count[hw]
+		pct := coveragepct(covered, total)
+
+		rk := "—"
+		if r, ok := ranks[hw]; ok {
+			rk = m.Sprintf("%d", r)
+		}
+		trr.WriteString(fmt.Sprintf(TRR, i+1, hw, hw, count[hw], rk, pct, glosses[hw]))
+
+		if nfirst == 0 && pct >= FIRST {
+			nfirst = i + 1
+			trr.WriteString(fmt.Sprintf(MARK, FIRST))
+		}
+		if pct >= SECOND {
+			nsecond = i + 1
+			trr.WriteString(fmt.Sprintf(MARK, SECOND))
+		}
+	}
+
+	htm := fmt.Sprintf(BANDS, brr.String()) + fmt.Sprintf(THH, trr.String())
+
+	// [e] the summary
+
+	an := search.DbWlnMyAu(&covsrch.Results.Lines[0]).Cleaname
+	if covsrch.TableSize > 1 {
+		an = an + fmt.Sprintf(" and %d more author(s)", covsrch.TableSize-1)
+	}
+
+	wn := search.DbWlnMyWk(&covsrch.Results.Lines[0]).Title
+	if covsrch.SearchSize > 1 {
+		wn = wn + fmt.Sprintf(" and %d more works(s)", covsrch.SearchSize-1)
+	}
+
+	kn := ""
+	if len(knownset) > 0 {
+		kn = fmt.Sprintf(KNOWN, knownhere, knownpct)
+	}
+
+	cp := ""
+	if covsrch.Results.Len() == mx {
+		cp = m.Sprintf(HITCAP, mx)
+	}
+
+	el := fmt.Sprintf("%.2f", time.Now().Sub(start).Seconds())
+
+	sum := fmt.Sprintf(SUMM, an, wn, m.Sprintf("%d", total), m.Sprintf("%d", len(headwords)), kn, nfirst, FIRST,
+		nsecond, SECOND, el, cp)
+
+	if lnch.Config.ZapLunates {
+		htm = gen.DeLunate(htm)
+	}
+
+	var jso JSFeeder
+	jso.SU = sum
+	jso.HT = htm
+
+	j := fmt.Sprintf(vv.LEXFINDJS, "vocabobserved")
+	jso.NJ = fmt.Sprintf("<script>%s</script>", j)
+
+	vlt.WSInfo.Del <- si.WSID
+	vlt.WSInfo.Del <- covsrch.WSID
+
+	return gen.JSONresponse(c, jso)
+}
+
+// coveragepct - n as a percentage of t
+func coveragepct(n int, t int) float64 {
+	return float64(n) * 100 / float64(max(t, 1))
+}
+
+// coverageglosses - headword to short definition via the morphology tables (cf. RtVocabMaker())
+func coverageglosses(s *str.SearchStruct) map[string]string {
+	distinct := make(map[string]bool)
+	rr := s.Results.YieldAll()
+	for r := range rr {
+		for _, w := range r.AccentedSlice() {
+			distinct[gen.UVσςϲ(gen.SwapAcuteForGrave(w))] = true
+		}
+	}
+
+	pat := regexp.MustCompile("^(.{1,3}\\.)\\s")
+
+	glosses := make(map[string]string)
+	morphmap := db.ArrayToGetRequiredMorphObjects(gen.StringMapKeysIntoSlice(distinct))
+	for _, v := range morphmap {
+		for _, p := range extractmorphpossibilities(v.RawPossib) {
+			if _, ok := glosses[p.Headwd]; !ok {
+				glosses[p.Headwd] = polishtrans(p.Transl, pat)
+			}
+		}
+	}
+	return glosses
+}
+
+// coverageknown - the "already known" list as a set of headwords: an item that is a form contributes its headword
+func coverageknown(items []string) map[string]struct{} {
+	known := make(map[string]struct{})
+
+	var clean []string
+	for _, i := range items {
+		i = strings.TrimSpace(strings.ToLower(i))
+		if i == "" {
+			continue
+		}
+		i = gen.Purgechars(lnch.Config.BadChars, i)
+		clean = append(clean, i)
+		known[i] = struct{}{}
+	}
+
+	if len(clean) == 0 {
+		return known
+	}
+
+	var forms []string
+	for _, i := range clean {
+		forms = append(forms, gen.UVσςϲ(gen.SwapAcuteForGrave(i)))
+	}
+
+	for _, hw := range vec.WinnerHeadwords(forms) {
+		known[hw] = struct{}{}
+	}
+	return known
+}
@@ -0,0 +1,345 @@
+//    HipparchiaGoServer
+//    Copyright: E Gunderson 2022-24
+//    License: GNU GENERAL PUBLIC LICENSE 3
+//        (see LICENSE in the top level directory of the distribution)
+
+package web
+
+import (
+	"fmt"
+	"github.com/e-gun/HipparchiaGoServer/internal/base/gen"
+	"github.com/e-gun/HipparchiaGoServer/internal/base/str"
+	"github.com/e-gun/HipparchiaGoServer/internal/lnch"
+	"github.com/e-gun/HipparchiaGoServer/internal/mps"
+	"github.com/e-gun/HipparchiaGoServer/internal/search"
+	"github.com/e-gun/HipparchiaGoServer/internal/vec"
+	"github.com/e-gun/HipparchiaGoServer/internal/vlt"
+	"github.com/e-gun/HipparchiaGoServer/internal/vv"
+	"github.com/labstack/echo/v4"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// dispersionpart - one work of the selection: its size, its hits, and where they fall
+type dispersionpart struct {
+	uid   string
+	words int
+	hits  int
+	pos   []float64 // position of each hit along the FirstLine–LastLine span of the work (0-100)
+	cit   []string
+}
+
+// RtDispersion - how evenly is a word or lemma spread across the works of the selection?
+func RtDispersion(c echo.Context) error {
+	c.Response().After(func() { Msg.LogPaths("RtDispersion()") })
+
+	// "/text/dispersion/5e1d3a7f?w=λόγοϲ" for a form; add "&lem=yes" for all the forms of a headword
+
+	// range: the number of works that contain the word at all
+	// Juilland's D: 1 - V/sqrt(n-1) where V is the coefficient of variation of the per-word rate in the n works;
+	//	1 is a perfectly even spread and 0 is everything in one work
+	// Gries' DP: half the sum of |expected share - observed share| over the works where the expected share is the
+	//	size of the work relative to the whole selection; 0 is perfectly even and DPnorm rescales the maximum to 1
+
+	const (
+		SUMM = `
+		<div id="searchsummary">Dispersion of %s »%s« across %s,&nbsp;<span class="foundwork">%s</span><br>
+			%s hits in %s words (%.2f per 10k)<br>
+			range: found in %d of %d works<br>
+			Juilland's D: %s; Gries' DP: %s (DPnorm: %s)<br>
+			%s
+			<span class="small">(%ss)</span><br>
+			%s
+		</div>
+		`
+		TBL = `
+		<table class="vectortable">
+		<tr>
+			<th class="vectortable">work</th>
+			<th class="vectortable">words</th>
+			<th class="vectortable">hits</th>
+			<th class="vectortable">per 10k</th>
+			<th class="vectortable">expected share</th>
+			<th class="vectortable">observed share</th>
+		</tr>
+		%s
+		</table>`
+		TBLRW = `
+		<tr class="%s">
+			<td class="vectorword">%s</td>
+			<td class="vectorscore">%d</td>
+			<td class="vectorscore">%d</td>
+			<td class="vectorscore">%.2f</td>
+			<td class="vectorscore">%.3f</td>
+			<td class="vectorscore">%.3f</td>
+		</tr>`
+		ONEWORK  = `<span class="small">(the dispersion measures need at least two works)</span><br>`
+		NOWORD   = `<div id="searchsummary">Dispersion needs a word: put it in the search box (or a headword in the lemma box)</div>`
+		NOLEMMA  = `<div id="searchsummary">Dispersion: could not find the headword »%s«</div>`
+		CHTTITLE = "Hits of »%s«"
+		CHTSUB   = "%d works with the most hits"
+		SAVEFILE = "dispersion_hitmap"
+		NTH      = 3
+		MSG1     = "Grabbing the lines...&nbsp;(part 1 of 2)"
+		MSG2     = "Counting the hits...&nbsp;(part 2 of 2)"
+		HITCAP   = `<span class="small"><span class="red emph">dispersion incomplete:</span>: hit the cap of %d on allowed lines</span>`
+	)
+
+	user := vlt.ReadUUIDCookie(c)
+	if !vlt.AllAuthorized.Check(user) {
+		return c.JSONPretty(http.StatusOK, str.SearchOutputJSON{JS: vv.JSVALIDATION}, vv.JSONINDENT)
+	}
+
+	start := time.Now()
+
+	// [a] what are we looking for?
+
+	w := strings.TrimSpace(gen.Purgechars(lnch.Config.BadChars, c.QueryParam("w")))
+	islemma := c.QueryParam("lem") == "yes"
+
+	if w == "" {
+		return gen.JSONresponse(c, str.SearchOutputJSON{Searchsummary: NOWORD})
+	}
+
+	dispclean := func(s string) string {
+		return gen.UVσςϲ(gen.SwapAcuteForGrave(strings.ToLower(s)))
+	}
+
+	sought := make(map[string]struct{})
+	what := "the form"
+	if islemma {
+		what = "the headword"
+		lm, ok := mps.AllLemm[w]
+		if !ok {
+			return gen.JSONresponse(c, str.SearchOutputJSON{Searchsummary: fmt.Sprintf(NOLEMMA, w)})
+		}
+		for _, f := range lm.Deriv {
+			sought[dispclean(f)] = struct{}{}
+		}
+	} else {
+		sought[dispclean(w)] = struct{}{}
+	}
+
+	// "si" is a blank search struct used for progress reporting
+	si := search.BuildDefaultSearch(c)
+	si.Type = "dispersion"
+
+	vlt.WSInfo.UpdateSummMsg <- vlt.WSSIKVs{si.WSID, MSG1}
+	vlt.WSInfo.UpdateRemain <- vlt.WSSIKVi{si.WSID, 1}
+
+	// [b] the selection
+
+	mx := lnch.Config.MaxText * vv.MAXVOCABLINEGENERATION
+	dsrch := search.SessionIntoBulkSearch(c, mx)
+
+	if dsrch.Results.Len() == 0 {
+		return gen.JSONresponse(c, str.SearchOutputJSON{})
+	}
+
+	vlt.WSInfo.UpdateSummMsg <- vlt.WSSIKVs{si.WSID, MSG2}
+
+	// [c] count: words and hits per work
+
+	parts := dispersioncount(dsrch.Results.Lines, sought, dispclean)
+
+	hits := make([]int, len(parts))
+	sizes := make([]int, len(parts))
+	th, tw := 0, 0
+	for i, p := range parts {
+		hits[i] = p.hits
+		sizes[i] = p.words
+		th += p.hits
+		tw += p.words
+	}
+
+	rng, jd, dp, dpn := dispersionmeasures(hits, sizes)
+
+	// [d] the table
+
+	m := message.NewPrinter(language.English)
+
+	label := func(uid string) string {
+		wk := mps.AllWorks[uid]
+		return fmt.Sprintf("%s, %s", mps.DbWkMyAu(wk).Cleaname, wk.Title)
+	}
+
+	byhits := make([]dispersionpart, len(parts))
+	copy(byhits, parts)
+	sort.SliceStable(byhits, func(i, j int) bool { return byhits[i].hits > byhits[j].hits })
+
+	var trr strings.Builder
+	for i, p := range byhits {
+		rn := "vectorrow"
+		if i%NTH == 0 {
+			rn = "nthrow"
+		}
+		trr.WriteString(fmt.Sprintf(TBLRW, rn, label(p.uid), p.words, p.hits,
+			float64(p.hits)*10000/float64(max(p.words, 1)), float64(p.words)/float64(max(tw, 1)),
+			float64(p.hits)/float64(max(th, 1))))
+	}
+	htm := fmt.Sprintf(TBL, trr.String())
+
+	// [e] the hit map
+
+	var labels []string
+	var pos [][]float64
+	var names [][]string
+	for _, p := range byhits[0:min(len(byhits), vv.DISPERSIONTOGRAPH)] {
+		if p.hits == 0 {
+			break
+		}
+		labels = append(labels, label(p.uid))
+		pos = append(pos, p.pos)
+		names = append(names, p.cit)
+	}
+
+	var img string
+	if len(labels) > 0 {
+		img = vec.HitMapChart(fmt.Sprintf(CHTTITLE, w), fmt.Sprintf(CHTSUB, len(labels)), SAVEFILE, labels, pos, names)
+	}
+
+	// [f] the summary
+
+	an := search.DbWlnMyAu(&dsrch.Results.Lines[0]).Cleaname
+	if dsrch.TableSize > 1 {
+		an = an + fmt.Sprintf(" and %d more author(s)", dsrch.TableSize-1)
+	}
+
+	wn := search.DbWlnMyWk(&dsrch.Results.Lines[0]).Title
+	if dsrch.SearchSize > 1 {
+		wn = wn + fmt.Sprintf(" and %d more works(s)", dsrch.SearchSize-1)
+	}
+
+	fmtnan := func(f float64) string {
+		if math.IsNaN(f) {
+			return "—"
+		}
+		return fmt.Sprintf("%.3f", f)
+	}
+
+	one := ""
+	if len(parts) < 2 {
+		one = ONEWORK
+	}
+
+	cp := ""
+	if dsrch.Results.Len() == mx {
+		cp = m.Sprintf(HITCAP, mx)
+	}
+
+	el := fmt.Sprintf("%.2f", time.Now().Sub(start).Seconds())
+
+	sum := fmt.Sprintf(SUMM, what, w, an, wn, m.Sprintf("%d", th), m.Sprintf("%d", tw),
+		float64(th)*10000/float64(max(tw, 1)), rng, len(parts), fmtnan(jd), fmtnan(dp), fmtnan(dpn), one, el, cp)
+
+	if lnch.Config.ZapLunates {
+		htm = gen.DeLunate(htm)
+	}
+
+	soj := str.SearchOutputJSON{
+		Title:         "Dispersion",
+		Searchsummary: sum,
+		Found:         htm,
+		Image:         img,
+		JS:            vv.VECTORJS,
+	}
+
+	vlt.WSInfo.Del <- si.WSID
+	vlt.WSInfo.Del <- dsrch.WSID
+
+	return gen.JSONresponse(c, soj)
+}
+
+// dispersioncount - words and hits per work in the order in which the works first appear
+func dispersioncount(lines []str.DbWorkline, sought map[string]struct{}, clean func(string) string) []dispersionpart {
+	var parts []dispersionpart
+	idx := make(map[string]int)
+
+	for _, l := range lines {
+		i, ok := idx[l.WkUID]
+		if !ok {
+			i = len(parts)
+			idx[l.WkUID] = i
+			parts = append(parts, dispersionpart{uid: l.WkUID})
+		}
+
+		for _, wd := range l.AccentedSlice() {
+			if wd == "" {
+				continue
+			}
+			parts[i].words++
+			if _, y := sought[clean(wd)]; !y {
+				continue
+			}
+			parts[i].hits++
+
+			span := 1.0
+			first := l.TbIndex
+			if wk, found := mps.AllWorks[l.WkUID]; found {
+				span = float64(max(wk.LastLine-wk.FirstLine, 1))
+				first = wk.FirstLine
+			}
+			parts[i].pos = append(parts[i].pos, math.Round(float64(l.TbIndex-first)*1000/span)/10)
+			parts[i].cit = append(parts[i].cit, l.Citation())
+		}
+	}
+
+	return parts
+}
+
+// dispersionmeasures - range, Juilland's D, Gries' DP, and DPnorm; NaN if a measure is undefined
+func dispersionmeasures(hits []int, sizes []int) (int, float64, float64, float64) {
+	n := len(hits)
+	th, ts := 0, 0
+	rng := 0
+	minshare := 1.0
+	for i := range hits {
+		th += hits[i]
+		ts += sizes[i]
+		if hits[i] > 0 {
+			rng++
+		}
+	}
+
+	if n < 2 || th == 0 || ts == 0 {
+		return rng, math.NaN(), math.NaN(), math.NaN()
+	}
+
+	// [a] Juilland's D via the rate per word in each work
+	rates := make([]float64, n)
+	mean := 0.0
+	for i := range hits {
+		rates[i] = float64(hits[i]) / float64(max(sizes[i], 1))
+		mean += rates[i]
+	}
+	mean = mean / float64(n)
+
+	variance := 0.0
+	for _, r := range rates {
+		variance += (r - mean) * (r - mean)
+	}
+	sd := math.Sqrt(variance / float64(n))
+	jd := 1 - (sd/mean)/math.Sqrt(float64(n-1))
+
+	// [b] Gries' DP
+	dp := 0.0
+	for i := range hits {
+		expected := float64(sizes[i]) / float64(ts)
+		observed := float64(hits[i]) / float64(th)
+		dp += math.Abs(expected - observed)
+		minshare = min(minshare, expected)
+	}
+	dp = dp / 2
+
+	dpn := math.NaN()
+	if minshare < 1 {
+		dpn = dp / (1 - minshare)
+	}
+
+	return rng, jd, dp, dpn
+}
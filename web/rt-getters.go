@@ -43,6 +43,11 @@ func RtGetJSSession(c echo.Context) error {
 		// what the JS is looking for; note that vector stuff, etc is being skipped vs the python session dump
 		Browsercontext    string `json:"browsercontext"`
 		Christiancorpus   string `json:"christiancorpus"`
+		DocDates          string `json:"docdates"`
+		LemmaForms        string `json:"lemmaforms"`
+		Collocates        string `json:"collocates"`
+		KWIC              string `json:"kwic"`
+		KWICSort          string `json:"kwicsort"`
 		Earliestdate      string `json:"earliestdate"`
 		Greekcorpus       string `json:"greekcorpus"`
 		Headwordindexing  string `json:"headwordindexing"`
@@ -51,6 +56,8 @@ func RtGetJSSession(c echo.Context) error {
 		Inscriptioncorpus string `json:"inscriptioncorpus"`
 		Latestdate        string `json:"latestdate"`
 		LdaGraph          string `json:"ldagraph"`
+		LdaModeler        string `json:"ldamodeler"`
+		LdaUnit           string `json:"ldaunit"`
 		LdaTopicCt        string `json:"ldatopiccount"`
 		LdaSearch         string `json:"isldasearch"`
 		Lda2D             string `json:"ldagraph2dimensions"`
@@ -58,10 +65,23 @@ func RtGetJSSession(c echo.Context) error {
 		Linesofcontext    string `json:"linesofcontext"`
 		Maxresults        string `json:"maxresults"`
 		Nearornot         string `json:"nearornot"`
+		NgramSize         string `json:"ngramsize"`
+		NgramFreq         string `json:"ngramfreq"`
+		NgramLemma        string `json:"ngramlemma"`
+		NgramStops        string `json:"ngramstops"`
+		NgramLines        string `json:"ngramlines"`
+		NgramSents        string `json:"ngramsentences"`
 		Onehit            string `json:"onehit"`
 		Papyruscorpus     string `json:"papyruscorpus"`
 		Proximity         string `json:"proximity"`
 		Rawinputstyle     string `json:"rawinputstyle"`
+		ReuseSize         string `json:"reusesize"`
+		ReuseLemma        string `json:"reuselemma"`
+		StyloSample       string `json:"stylosample"`
+		StyloMFW          string `json:"stylomfw"`
+		StyloGraph        string `json:"stylograph"`
+		KeyRef            string `json:"keyref"`
+		KeyLemma          string `json:"keylemma"`
 		Searchscope       string `json:"searchscope"`
 		Sortorder         string `json:"sortorder"`
 		Spuria            string `json:"spuria"`
@@ -69,6 +89,7 @@ func RtGetJSSession(c echo.Context) error {
 		VocByCount        string `json:"vocbycount"`
 		VocScansion       string `json:"vocscansion"`
 		VecSearch         string `json:"isvectorsearch"`
+		VecExpand         string `json:"vecexpand"`
 		VecGraphExt       string `json:"extendedgraph"`
 		VecModeler        string `json:"vecmodeler"`
 		VecTextPrep       string `json:"vtextprep"`
@@ -88,6 +109,11 @@ func RtGetJSSession(c echo.Context) error {
 	var jso JSO
 	jso.Browsercontext = i2s(s.BrowseCtx)
 	jso.Christiancorpus = t2y(s.ActiveCorp["ch"])
+	jso.DocDates = t2y(s.DocDates)
+	jso.LemmaForms = t2y(s.LemmaForms)
+	jso.Collocates = t2y(s.Collocates)
+	jso.KWIC = t2y(s.KWIC)
+	jso.KWICSort = s.KWICSort
 	jso.Earliestdate = s.Earliest
 	jso.Greekcorpus = t2y(s.ActiveCorp["gr"])
 	jso.Headwordindexing = t2y(s.HeadwordIdx)
@@ -99,13 +125,28 @@ func RtGetJSSession(c echo.Context) error {
 	jso.Linesofcontext = i2s(s.HitContext)
 	jso.Lda2D = t2y(s.LDA2D)
 	jso.LdaGraph = t2y(s.LDAgraph)
+	jso.LdaModeler = s.LDAModeler
+	jso.LdaUnit = s.LDAUnit
 	jso.LdaTopicCt = i2s(s.LDAtopics)
 	jso.LdaSearch = t2y(s.VecLDASearch)
 	jso.Maxresults = i2s(s.HitLimit)
 	jso.Nearornot = s.NearOrNot
+	jso.NgramSize = i2s(s.NgramSize)
+	jso.NgramFreq = i2s(s.NgramFreq)
+	jso.NgramLemma = t2y(s.NgramLemma)
+	jso.NgramStops = t2y(s.NgramStops)
+	jso.NgramLines = t2y(s.NgramLines)
+	jso.NgramSents = t2y(s.NgramSents)
 	jso.Papyruscorpus = t2y(s.ActiveCorp["dp"])
 	jso.Proximity = i2s(s.Proximity)
 	jso.Rawinputstyle = t2y(s.RawInput)
+	jso.ReuseSize = i2s(s.ReuseSize)
+	jso.ReuseLemma = t2y(s.ReuseLemma)
+	jso.StyloSample = i2s(s.StyloSample)
+	jso.StyloMFW = i2s(s.StyloMFW)
+	jso.StyloGraph = s.StyloGraph
+	jso.KeyRef = s.KeyRef
+	jso.KeyLemma = t2y(s.KeyLemma)
 	jso.Searchscope = s.SearchScope
 	jso.Sortorder = s.SortHitsBy
 	jso.Spuria = t2y(s.SpuriaOK)
@@ -114,6 +155,7 @@ func RtGetJSSession(c echo.Context) error {
 	jso.VecModeler = s.VecModeler
 	jso.VecNeighbCt = i2s(s.VecNeighbCt)
 	jso.VecSearch = t2y(s.VecNNSearch)
+	jso.VecExpand = t2y(s.VecExpand)
 	jso.VecTextPrep = s.VecTextPrep
 	jso.VocByCount = t2y(s.VocByCount)
 	jso.VocScansion = t2y(s.VocScansion)
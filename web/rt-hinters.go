@@ -130,6 +130,10 @@ func RtWkLocHints(c echo.Context) error {
 	return basichinter(c, mps.WkLocs)
 }
 
+func RtDocLocHints(c echo.Context) error {
+	return basichinter(c, mps.DocLocs)
+}
+
 // basichinter - which substrings of the request are members of the master map?
 func basichinter(c echo.Context, mastermap map[string]bool) error {
 	skg := c.QueryParam("term")
@@ -46,17 +46,6 @@ func RtIndexMaker(c echo.Context) error {
 	//[HGS] RtSearch() runtime.GC() 240M --> 208M
 
 	const (
-		TBLTMP = `        
-		<table>
-		<tbody><tr>
-			<th class="indextable">headword</th>
-			<th class="indextable">word</th>
-			<th class="indextable">count</th>
-			<th class="indextable">passages</th>
-		</tr>
-		%s
-		</table>`
-
 		SUMM = `
 		<div id="searchsummary">Index to %s,&nbsp;<span class="foundwork">%s</span><br>
 			citation format:&nbsp;%s<br>
@@ -224,33 +213,7 @@ func RtIndexMaker(c echo.Context) error {
 
 	slicedlookups = []str.WordInfo{} // drop after use
 
-	// pseudocode:
-
-	//calculate homonyms: two maps
-	// [a] map ishom: [string]bool
-	// [b] map tester: [string]string: [word]headword
-	// iterate
-	// 	if word not in map: add
-	// 	if word in map: is assoc w/ this headword?
-	// 	if not: w is homonym
-
-	ishom := make(map[string]bool)
-	htest := make(map[string]string)
-	for _, t := range trimslices {
-		if _, ok := htest[t.Word]; !ok {
-			htest[t.Word] = t.HeadWd
-		} else {
-			if htest[t.Word] != t.HeadWd {
-				ishom[t.Word] = true
-			}
-		}
-	}
-
-	for i, t := range trimslices {
-		if ishom[t.Word] {
-			trimslices[i].IsHomonymn = true
-		}
-	}
+	markhomonyms(trimslices)
 
 	// last chance to add in keys for multiple work indices
 	mp := make(map[string]rune)
@@ -263,64 +226,18 @@ func RtIndexMaker(c echo.Context) error {
 		return gen.JSONresponse(c, JSFeeder{})
 	}
 
-	// [d] the final map
-	// [d1] build it
+	// [d] the final map: build it, sort it, format it
 
 	vlt.WSInfo.UpdateSummMsg <- vlt.WSSIKVs{si.ID, MSG3}
 
-	indexmap := make(map[gen.PolytonicSorterStruct][]str.WordInfo, len(trimslices))
-	for _, w := range trimslices {
-		// lunate sigma sorts after omega
-		sigma := strings.Replace(gen.StripaccentsSTR(w.HeadWd), "ϲ", "σ", -1)
-		ss := gen.PolytonicSorterStruct{
-			Sortstring:     sigma + w.HeadWd,
-			Originalstring: w.HeadWd,
-		}
-		indexmap[ss] = append(indexmap[ss], w)
-	}
-
 	m := message.NewPrinter(language.English)
 	wf := m.Sprintf("%d", len(trimslices))
-	trimslices = []str.WordInfo{} // drop after use
-
-	// [d2] sort the keys
-
-	keys := make([]gen.PolytonicSorterStruct, len(indexmap))
-	counter := 0
-	for k, v := range indexmap {
-		k.Count = len(v)
-		keys[counter] = k
-		counter += 1
-	}
-
-	slices.SortFunc(keys, func(a, b gen.PolytonicSorterStruct) int { return cmp.Compare(a.Sortstring, b.Sortstring) })
 
-	// now you have a sorted index...; but a PolytonicSorterStruct does not make for a usable map key...
-	plainkeys := make([]string, len(keys))
-	for i, k := range keys {
-		plainkeys[i] = k.Originalstring
-	}
-
-	// example keys: [ἀβαϲάνιϲτοϲ ἀβουλία ἄβουλοϲ ἁβροδίαιτοϲ ἀγαθόϲ ἀγαθόω ἄγαν ...]
-
-	plainmap := make(map[string][]str.WordInfo, len(indexmap))
-	for k := range indexmap {
-		plainmap[k.Originalstring] = indexmap[k]
-	}
-
-	indexmap = make(map[gen.PolytonicSorterStruct][]str.WordInfo, 1) // drop after use
+	htm := indextable(trimslices)
+	trimslices = []str.WordInfo{} // drop after use
 
 	vlt.WSInfo.UpdateSummMsg <- vlt.WSSIKVs{si.ID, MSG4}
 
-	trr := make([]string, len(plainkeys))
-	for i, k := range plainkeys {
-		// example
-		// k: ἀδικέω; plainmap[k]: []WordInfo -> ἀδικεῖτε, ἀδικηϲάντων, ἀδικούμεθα, ...
-		trr[i] = convertwordinfototablerow(plainmap[k])
-	}
-
-	htm := fmt.Sprintf(TBLTMP, strings.Join(trr, ""))
-
 	// build the summary info: jso.SU
 
 	an := search.DbWlnMyAu(&firstresult).Cleaname
@@ -441,6 +358,100 @@ func multiworkkeymaker(mapper map[string]rune, srch *str.SearchStruct) string {
 	return ky
 }
 
+// markhomonyms - flag the words that are found under more than one headword
+func markhomonyms(trimslices []str.WordInfo) {
+	// pseudocode:
+
+	//calculate homonyms: two maps
+	// [a] map ishom: [string]bool
+	// [b] map tester: [string]string: [word]headword
+	// iterate
+	// 	if word not in map: add
+	// 	if word in map: is assoc w/ this headword?
+	// 	if not: w is homonym
+
+	ishom := make(map[string]bool)
+	htest := make(map[string]string)
+	for _, t := range trimslices {
+		if _, ok := htest[t.Word]; !ok {
+			htest[t.Word] = t.HeadWd
+		} else {
+			if htest[t.Word] != t.HeadWd {
+				ishom[t.Word] = true
+			}
+		}
+	}
+
+	for i, t := range trimslices {
+		if ishom[t.Word] {
+			trimslices[i].IsHomonymn = true
+		}
+	}
+}
+
+// indextable - []WordInfo with headwords attached --> the index table sorted by headword
+func indextable(trimslices []str.WordInfo) string {
+	const (
+		TBLTMP = `        
+		<table>
+		<tbody><tr>
+			<th class="indextable">headword</th>
+			<th class="indextable">word</th>
+			<th class="indextable">count</th>
+			<th class="indextable">passages</th>
+		</tr>
+		%s
+		</table>`
+	)
+
+	indexmap := make(map[gen.PolytonicSorterStruct][]str.WordInfo, len(trimslices))
+	for _, w := range trimslices {
+		// lunate sigma sorts after omega
+		sigma := strings.Replace(gen.StripaccentsSTR(w.HeadWd), "ϲ", "σ", -1)
+		ss := gen.PolytonicSorterStruct{
+			Sortstring:     sigma + w.HeadWd,
+			Originalstring: w.HeadWd,
+		}
+		indexmap[ss] = append(indexmap[ss], w)
+	}
+
+	// sort the keys
+
+	keys := make([]gen.PolytonicSorterStruct, len(indexmap))
+	counter := 0
+	for k, v := range indexmap {
+		k.Count = len(v)
+		keys[counter] = k
+		counter += 1
+	}
+
+	slices.SortFunc(keys, func(a, b gen.PolytonicSorterStruct) int { return cmp.Compare(a.Sortstring, b.Sortstring) })
+
+	// now you have a sorted index...; but a PolytonicSorterStruct does not make for a usable map key...
+	plainkeys := make([]string, len(keys))
+	for i, k := range keys {
+		plainkeys[i] = k.Originalstring
+	}
+
+	// example keys: [ἀβαϲάνιϲτοϲ ἀβουλία ἄβουλοϲ ἁβροδίαιτοϲ ἀγαθόϲ ἀγαθόω ἄγαν ...]
+
+	plainmap := make(map[string][]str.WordInfo, len(indexmap))
+	for k := range indexmap {
+		plainmap[k.Originalstring] = indexmap[k]
+	}
+
+	indexmap = make(map[gen.PolytonicSorterStruct][]str.WordInfo, 1) // drop after use
+
+	trr := make([]string, len(plainkeys))
+	for i, k := range plainkeys {
+		// example
+		// k: ἀδικέω; plainmap[k]: []WordInfo -> ἀδικεῖτε, ἀδικηϲάντων, ἀδικούμεθα, ...
+		trr[i] = convertwordinfototablerow(plainmap[k])
+	}
+
+	return fmt.Sprintf(TBLTMP, strings.Join(trr, ""))
+}
+
 // convertwordinfototablerow - []WordInfo --> "<tr>...</tr>"
 func convertwordinfototablerow(ww []str.WordInfo) string {
 	// every word has the same headword
@@ -507,9 +518,14 @@ func convertwordinfototablerow(ww []str.WordInfo) string {
 		var pp []string
 		dedup := make(map[string]bool) // this is hacky: why duplicates to begin with?
 		for j := 0; j < len(wii); j++ {
-			if _, ok := dedup[wii[j].Loc]; !ok {
-				pp = append(pp, fmt.Sprintf(IDXLOC, wii[j].Loc, wii[j].Cit))
-				dedup[wii[j].Loc] = true
+			if _, ok := dedup[wii[j].Loc+wii[j].Cit]; !ok {
+				if wii[j].Loc == "" {
+					// pasted text has no place in the database to browse to: see RtParsePasted()
+					pp = append(pp, wii[j].Cit)
+				} else {
+					pp = append(pp, fmt.Sprintf(IDXLOC, wii[j].Loc, wii[j].Cit))
+				}
+				dedup[wii[j].Loc+wii[j].Cit] = true
 			}
 		}
 		p := strings.Join(pp, ", ")
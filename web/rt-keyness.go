@@ -0,0 +1,373 @@
+//    HipparchiaGoServer
+//    Copyright: E Gunderson 2022-24
+//    License: GNU GENERAL PUBLIC LICENSE 3
+//        (see LICENSE in the top level directory of the distribution)
+
+package web
+
+import (
+	"fmt"
+	"github.com/e-gun/HipparchiaGoServer/internal/base/gen"
+	"github.com/e-gun/HipparchiaGoServer/internal/base/str"
+	"github.com/e-gun/HipparchiaGoServer/internal/db"
+	"github.com/e-gun/HipparchiaGoServer/internal/lnch"
+	"github.com/e-gun/HipparchiaGoServer/internal/search"
+	"github.com/e-gun/HipparchiaGoServer/internal/vec"
+	"github.com/e-gun/HipparchiaGoServer/internal/vlt"
+	"github.com/e-gun/HipparchiaGoServer/internal/vv"
+	"github.com/labstack/echo/v4"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// keyword - a word, how often it was seen in the target and the reference, and how surprising that is
+type keyword struct {
+	word string
+	a    int     // observed in the target
+	b    int     // observed in the reference
+	ll   float64 // log-likelihood
+	lr   float64 // log ratio: log2 of the ratio of the relative frequencies
+}
+
+// RtKeyness - what is over- and under-represented in the current selection vis-à-vis the corpus or the stored selection
+func RtKeyness(c echo.Context) error {
+	c.Response().After(func() { Msg.LogPaths("RtKeyness()") })
+
+	// log-likelihood (Dunning 1993; Rayson & Garside 2000) says how sure we can be that a difference is real;
+	// log ratio (Hardie 2014) says how big the difference is: +1 is twice as frequent, -1 is half as frequent
+	// the reference "corpus" is every active corpus as counted by the database: the selection is part of it, but the
+	// selection was counted line by line and the database was not, so the one cannot be subtracted from the other
+
+	const (
+		SUMM = `
+		<div id="searchsummary">Keyness of %s in %s,&nbsp;<span class="foundwork">%s</span><br>
+			compared with %s<br>
+			%s words in the selection and %s words in the reference<br>
+			%d over-represented and %d under-represented %s with a log-likelihood of at least %.2f (p &lt; .05)%s<br>
+			<span class="small">(%ss)</span><br>
+			%s
+		</div>
+		`
+		TBL = `
+		<table class="vectortable"><tbody>
+		<tr class="vectorrow">
+			<td class="vectorrank" colspan="8">%s</td>
+		</tr>
+		<tr class="vectorrow">
+			<td class="vectorrank">&nbsp;</td>
+			<td class="vectorrank">%s</td>
+			<td class="vectorrank">selection</td>
+			<td class="vectorrank">per 10k</td>
+			<td class="vectorrank">reference</td>
+			<td class="vectorrank">per 10k</td>
+			<td class="vectorrank">log-likelihood</td>
+			<td class="vectorrank">log ratio</td>
+		</tr>
+		%s
+		</tbody></table>
+		<hr>`
+		TBLRW = `
+		<tr class="%s">
+			<td class="vectorrank">%d</td>
+			<td class="vectorword">%s</td>
+			<td class="vectorscore">%d</td>
+			<td class="vectorscore">%.2f</td>
+			<td class="vectorscore">%d</td>
+			<td class="vectorscore">%.2f</td>
+			<td class="vectorscore">%.2f</td>
+			<td class="vectorscore">%+.2f</td>
+		</tr>`
+		OVER     = "Over-represented in the selection"
+		UNDER    = "Under-represented in the selection"
+		LEMMA    = `<vectorheadword id="%s">%s</vectorheadword>`
+		FORM     = `<lemmaform id="%s">%s</lemmaform>`
+		CORPUS   = "the active corpora (%s)"
+		STORED   = "the stored selection: %s"
+		SHOWN    = ` (showing the top %d of each)`
+		CHTTITLE = "Keyness of %s"
+		CHTSUB   = "log ratio of the top %d in each direction"
+		SAVEFILE = "keyness_barchart"
+		NOSOURCE = `<div id="searchsummary">Keyness against a stored selection needs two selections: store the reference selection first and then select the target</div>`
+		MSG1     = "Grabbing the lines...&nbsp;(part 1 of 4)"
+		MSG2     = "Counting the vocabulary...&nbsp;(part 2 of 4)"
+		MSG3     = "Counting the reference...&nbsp;(part 3 of 4)"
+		MSG4     = "Building the HTML...&nbsp;(part 4 of 4)"
+		HITCAP   = `<span class="small"><span class="red emph">keyness incomplete:</span>: hit the cap of %d on allowed lines</span>`
+		NTH      = 3
+	)
+
+	user := vlt.ReadUUIDCookie(c)
+	if !vlt.AllAuthorized.Check(user) {
+		return c.JSONPretty(http.StatusOK, str.SearchOutputJSON{JS: vv.JSVALIDATION}, vv.JSONINDENT)
+	}
+
+	start := time.Now()
+	se := vlt.AllSessions.GetSess(user)
+	stored := se.KeyRef == "stored"
+
+	if stored && se.StoredIncl.IsEmpty() {
+		return gen.JSONresponse(c, str.SearchOutputJSON{Searchsummary: NOSOURCE})
+	}
+
+	// "si" is a blank search struct used for progress reporting
+	si := search.BuildDefaultSearch(c)
+	si.Type = "keyness"
+
+	vlt.WSInfo.UpdateSummMsg <- vlt.WSSIKVs{si.WSID, MSG1}
+	vlt.WSInfo.UpdateRemain <- vlt.WSSIKVi{si.WSID, 1}
+
+	// [a] the target
+
+	mx := lnch.Config.MaxText * vv.MAXVOCABLINEGENERATION
+	tgtsrch := search.SessionIntoBulkSearch(c, mx)
+
+	if tgtsrch.Results.Len() == 0 {
+		return gen.JSONresponse(c, str.SearchOutputJSON{})
+	}
+
+	vlt.WSInfo.UpdateSummMsg <- vlt.WSSIKVs{si.WSID, MSG2}
+
+	tgtcount, tgttotal := keynesscount(&tgtsrch, se.KeyLemma)
+
+	// [b] the reference
+
+	vlt.WSInfo.UpdateSummMsg <- vlt.WSSIKVs{si.WSID, MSG3}
+
+	var refcount map[string]int
+	var reftotal int
+	var refdesc string
+	refcapped := false
+
+	if stored {
+		refsrch := search.StoredSelectionIntoBulkSearch(c, mx)
+		refcount, reftotal = keynesscount(&refsrch, se.KeyLemma)
+		refdesc = fmt.Sprintf(STORED, storedselectionsummary(se))
+		refcapped = refsrch.Results.Len() == mx
+		vlt.WSInfo.Del <- refsrch.WSID
+	} else {
+		corpora := activecorpora(se)
+
+		// the reference vocabulary is fetched on its own: a word the selection never uses can still be under-represented;
+		// the rarer words of the reference are only needed if the selection uses them
+
+		refcount = db.FetchCorpusVocabulary(corpora, se.KeyLemma, vv.KEYNESSMINFREQ)
+
+		ww := gen.StringMapKeysIntoSlice(tgtcount)
+		var tgtref map[string]int
+		if se.KeyLemma {
+			tgtref = db.FetchCorpusHeadwordCounts(ww, corpora)
+		} else {
+			tgtref = db.FetchCorpusFormCounts(ww, corpora)
+		}
+		for w, n := range tgtref {
+			refcount[w] = n
+		}
+
+		reftotal = max(db.FetchCorpusSize(corpora, se.KeyLemma), 1)
+		refdesc = fmt.Sprintf(CORPUS, strings.Join(corpora, ", "))
+	}
+
+	// [c] score
+
+	over, under := keynessrank(tgtcount, tgttotal, refcount, reftotal)
+
+	// [d] format the output
+
+	vlt.WSInfo.UpdateSummMsg <- vlt.WSSIKVs{si.WSID, MSG4}
+
+	m := message.NewPrinter(language.English)
+
+	shown := ""
+	if len(over) > vv.KEYNESSTOSHOW || len(under) > vv.KEYNESSTOSHOW {
+		shown = m.Sprintf(SHOWN, vv.KEYNESSTOSHOW)
+	}
+
+	by := "forms"
+	wrap := FORM
+	if se.KeyLemma {
+		by = "headwords"
+		wrap = LEMMA
+	}
+
+	pertenk := func(n int, total int) float64 {
+		return float64(n) * 10000 / float64(max(total, 1))
+	}
+
+	table := func(title string, kk []keyword) string {
+		var trr strings.Builder
+		for i, k := range kk[0:min(len(kk), vv.KEYNESSTOSHOW)] {
+			rn := "vectorrow"
+			if i%NTH == 0 {
+				rn = "nthrow"
+			}
+			trr.WriteString(fmt.Sprintf(TBLRW, rn, i+1, fmt.Sprintf(wrap, k.word, k.word), k.a, pertenk(k.a, tgttotal),
+				k.b, pertenk(k.b, reftotal), k.ll, k.lr))
+		}
+		return fmt.Sprintf(TBL, title, by, trr.String())
+	}
+
+	htm := table(OVER, over) + table(UNDER, under)
+
+	// [e] the chart: the biggest effects among the most significant results
+
+	var labels []string
+	var values []float64
+	for _, kk := range [][]keyword{over, under} {
+		top := kk[0:min(len(kk), vv.KEYNESSTOGRAPH)]
+		sort.SliceStable(top, func(i, j int) bool { return math.Abs(top[i].lr) > math.Abs(top[j].lr) })
+		for _, k := range top {
+			labels = append(labels, k.word)
+			values = append(values, k.lr)
+		}
+	}
+
+	an := search.DbWlnMyAu(&tgtsrch.Results.Lines[0]).Cleaname
+	if tgtsrch.TableSize > 1 {
+		an = an + fmt.Sprintf(" and %d more author(s)", tgtsrch.TableSize-1)
+	}
+
+	wn := search.DbWlnMyWk(&tgtsrch.Results.Lines[0]).Title
+	if tgtsrch.SearchSize > 1 {
+		wn = wn + fmt.Sprintf(" and %d more works(s)", tgtsrch.SearchSize-1)
+	}
+
+	var img string
+	if len(labels) > 0 {
+		img = vec.HorizontalBarChart(fmt.Sprintf(CHTTITLE, an), fmt.Sprintf(CHTSUB, vv.KEYNESSTOGRAPH), SAVEFILE, labels, values)
+	}
+
+	// [f] the summary
+
+	cp := ""
+	if tgtsrch.Results.Len() == mx || refcapped {
+		cp = m.Sprintf(HITCAP, mx)
+	}
+
+	el := fmt.Sprintf("%.2f", time.Now().Sub(start).Seconds())
+
+	sum := fmt.Sprintf(SUMM, by, an, wn, refdesc, m.Sprintf("%d", tgttotal), m.Sprintf("%d", reftotal), len(over),
+		len(under), by, vv.KEYNESSLLMIN, shown, el, cp)
+
+	if lnch.Config.ZapLunates {
+		htm = gen.DeLunate(htm)
+	}
+
+	soj := str.SearchOutputJSON{
+		Title:         "Keyness",
+		Searchsummary: sum,
+		Found:         htm,
+		Image:         img,
+		JS:            vv.VECTORJS + vv.LEMMAFORMJS,
+	}
+
+	vlt.WSInfo.Del <- si.WSID
+	vlt.WSInfo.Del <- tgtsrch.WSID
+
+	return gen.JSONresponse(c, soj)
+}
+
+// activecorpora - "gr", "lt", ... for the corpora that are switched on in the session
+func activecorpora(s str.ServerSession) []string {
+	var corpora []string
+	for k, v := range s.ActiveCorp {
+		if v {
+			corpora = append(corpora, k)
+		}
+	}
+	sort.Strings(corpora)
+	return corpora
+}
+
+// keynesscount - count the words of a selection; headwords are "winner takes all" and unparsed words are not counted
+func keynesscount(s *str.SearchStruct, byheadword bool) (map[string]int, int) {
+	var words []string
+	rr := s.Results.YieldAll()
+	for r := range rr {
+		for _, w := range r.AccentedSlice() {
+			words = append(words, gen.UVσςϲ(gen.SwapAcuteForGrave(w)))
+		}
+	}
+
+	count := make(map[string]int)
+	total := 0
+
+	if !byheadword {
+		for _, w := range words {
+			count[w]++
+		}
+		return count, len(words)
+	}
+
+	winners := vec.WinnerHeadwords(words)
+	for _, w := range words {
+		if hw, ok := winners[w]; ok {
+			count[hw]++
+			total++
+		}
+	}
+	return count, total
+}
+
+// keynessrank - log-likelihood and log ratio for every word; the significant ones sorted by log-likelihood
+func keynessrank(tgt map[string]int, c int, ref map[string]int, d int) ([]keyword, []keyword) {
+	// a and b are the observed counts; c and d are the sizes of the target and the reference
+	// E1 = c(a+b)/(c+d); E2 = d(a+b)/(c+d); LL = 2(a ln(a/E1) + b ln(b/E2))
+	// zero counts become 0.5 for the log ratio so that "never seen in the reference" is still a number
+
+	seen := make(map[string]struct{})
+	for w := range tgt {
+		seen[w] = struct{}{}
+	}
+	for w := range ref {
+		seen[w] = struct{}{}
+	}
+
+	xlnx := func(o float64, e float64) float64 {
+		if o == 0 {
+			return 0
+		}
+		return o * math.Log(o/e)
+	}
+
+	var over, under []keyword
+	for w := range seen {
+		a := float64(tgt[w])
+		b := float64(ref[w])
+		e1 := float64(c) * (a + b) / float64(c+d)
+		e2 := float64(d) * (a + b) / float64(c+d)
+
+		k := keyword{word: w, a: tgt[w], b: ref[w]}
+		k.ll = 2 * (xlnx(a, e1) + xlnx(b, e2))
+		k.lr = math.Log2((max(a, 0.5) / float64(c)) / (max(b, 0.5) / float64(d)))
+
+		if k.ll < vv.KEYNESSLLMIN {
+			continue
+		}
+
+		if a/float64(c) > b/float64(d) {
+			if k.a >= vv.KEYNESSMINFREQ {
+				over = append(over, k)
+			}
+		} else if k.b >= vv.KEYNESSMINFREQ {
+			under = append(under, k)
+		}
+	}
+
+	byll := func(kk []keyword) {
+		sort.Slice(kk, func(i, j int) bool {
+			if kk[i].ll == kk[j].ll {
+				return kk[i].word < kk[j].word
+			}
+			return kk[i].ll > kk[j].ll
+		})
+	}
+	byll(over)
+	byll(under)
+
+	return over, under
+}
@@ -0,0 +1,356 @@
+//    HipparchiaGoServer
+//    Copyright: E Gunderson 2022-24
+//    License: GNU GENERAL PUBLIC LICENSE 3
+//        (see LICENSE in the top level directory of the distribution)
+
+package web
+
+import (
+	"fmt"
+	"github.com/e-gun/HipparchiaGoServer/internal/base/gen"
+	"github.com/e-gun/HipparchiaGoServer/internal/base/str"
+	"github.com/e-gun/HipparchiaGoServer/internal/lnch"
+	"github.com/e-gun/HipparchiaGoServer/internal/search"
+	"github.com/e-gun/HipparchiaGoServer/internal/vec"
+	"github.com/e-gun/HipparchiaGoServer/internal/vlt"
+	"github.com/e-gun/HipparchiaGoServer/internal/vv"
+	"github.com/labstack/echo/v4"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+var (
+	notaletter  = regexp.MustCompile(`[^\p{L}]`)
+	sentenceend = regexp.MustCompile(`[.;\x{037E}·\x{0387}?!:][^\p{L}]*$`)
+)
+
+// ngramtoken - one word of the selection and the line it came from
+type ngramtoken struct {
+	word    string
+	surface string // the word as printed: the punctuation is gone but the case, accents, and sigmas are not
+	head    string // the headword that the stop words are checked against
+	line    int
+	brk     bool // an n-gram may not run from this token into the next one
+}
+
+// RtNgramMaker - find the most frequent 2- to 6-grams in whatever collection of lines you would be searching
+func RtNgramMaker(c echo.Context) error {
+	c.Response().After(func() { Msg.LogPaths("RtNgramMaker()") })
+
+	// repeated formulae: "ὣϲ ἔφατ᾽", "πόδαϲ ὠκὺϲ Ἀχιλλεύϲ", "si uales bene est", ...
+	// the session supplies n, the minimum frequency, and the filters: see the "N-grams" options
+
+	const (
+		SUMM = `
+		<div id="searchsummary">%d-grams (by %s) for %s,&nbsp;<span class="foundwork">%s</span><br>
+			%s words found; %s distinct n-grams occur at least %d times%s<br>
+			n-grams %s line breaks and %s sentence breaks%s<br>
+			<span class="small">(%ss)</span><br>
+			%s
+			%s
+		</div>
+		`
+		TBL = `
+		<table>
+		<tbody><tr>
+			<th class="indextable">n-gram</th>
+			<th class="indextable">count</th>
+			<th class="indextable">passages</th>
+		</tr>
+		%s
+		</tbody></table>`
+		TBLRW = `
+		<tr>
+			<td class="word">%s</td>
+			<td class="count">%d</td>
+			<td class="passages">%s</td>
+		</tr>`
+		SRCH   = `<lemmaform id="%s">%s</lemmaform>`
+		NGLOC  = `<ngramlocation id="%s">%s</ngramlocation>`
+		SHOWN  = ` (showing the top %d)`
+		STOPS  = `; n-grams made up entirely of stop words were skipped`
+		MAY    = "may cross"
+		MAYNOT = "may not cross"
+		MSG1   = "Grabbing the lines...&nbsp;(part 1 of 3)"
+		MSG2   = "Counting the n-grams...&nbsp;(part 2 of 3)"
+		MSG3   = "Building the HTML...&nbsp;(part 3 of 3)"
+		HITCAP = `<span class="small"><span class="red emph">n-gram generation incomplete:</span>: hit the cap of %d on allowed lines</span>`
+	)
+
+	type JSFeeder struct {
+		SU string `json:"searchsummary"`
+		HT string `json:"thehtml"`
+		NJ string `json:"newjs"`
+	}
+
+	user := vlt.ReadUUIDCookie(c)
+	if !vlt.AllAuthorized.Check(user) {
+		return c.JSONPretty(http.StatusOK, JSFeeder{NJ: vv.JSVALIDATION}, vv.JSONINDENT)
+	}
+
+	start := time.Now()
+	se := vlt.AllSessions.GetSess(user)
+	n := min(max(se.NgramSize, vv.NGRAMMIN), vv.NGRAMMAX)
+	minfreq := max(se.NgramFreq, 1)
+
+	id := c.Param("id")
+	id = gen.Purgechars(lnch.Config.BadChars, id)
+
+	// "si" is a blank search struct used for progress reporting
+	si := search.BuildDefaultSearch(c)
+	si.Type = "ngrams"
+
+	vlt.WSInfo.UpdateSummMsg <- vlt.WSSIKVs{si.WSID, MSG1}
+	vlt.WSInfo.UpdateRemain <- vlt.WSSIKVi{si.WSID, 1}
+
+	// [a] get all the lines you need and turn them into []ngramtoken
+
+	mx := lnch.Config.MaxText * vv.MAXVOCABLINEGENERATION
+	ngsrch := search.SessionIntoBulkSearch(c, mx)
+
+	if ngsrch.Results.Len() == 0 {
+		return emptyjsreturn(c)
+	}
+
+	tokens, places := ngramtokenize(ngsrch.Results.Lines, se.NgramLines, se.NgramSents)
+
+	// [b] surface forms or headwords? the stop list is a list of headwords: "τοῦ" is only a stop word as "ὁ"
+
+	vlt.WSInfo.UpdateSummMsg <- vlt.WSSIKVs{id, MSG2}
+
+	by := "form"
+	if se.NgramLemma {
+		by = "headword"
+	}
+
+	for i := range tokens {
+		tokens[i].head = tokens[i].word
+	}
+
+	if se.NgramLemma || se.NgramStops {
+		words := make([]string, len(tokens))
+		for i := range tokens {
+			words[i] = tokens[i].word
+		}
+		winners := vec.WinnerHeadwords(words)
+		for i := range tokens {
+			if hw, ok := winners[tokens[i].word]; ok {
+				tokens[i].head = hw
+			}
+		}
+	}
+
+	if se.NgramLemma {
+		for i := range tokens {
+			tokens[i].word = tokens[i].head
+		}
+	}
+
+	// [c] count
+
+	var stops map[string]struct{}
+	if se.NgramStops {
+		stops = vec.StopSet()
+	}
+
+	found := ngramcount(tokens, n, stops)
+
+	var keys []string
+	for k, v := range found {
+		if len(v) >= minfreq {
+			keys = append(keys, k)
+		}
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		if len(found[keys[i]]) == len(found[keys[j]]) {
+			return keys[i] < keys[j]
+		}
+		return len(found[keys[i]]) > len(found[keys[j]])
+	})
+
+	// [d] format the output
+
+	vlt.WSInfo.UpdateSummMsg <- vlt.WSSIKVs{id, MSG3}
+
+	m := message.NewPrinter(language.English)
+
+	shown := ""
+	if len(keys) > vv.NGRAMSTOSHOW {
+		shown = m.Sprintf(SHOWN, vv.NGRAMSTOSHOW)
+		keys = keys[0:vv.NGRAMSTOSHOW]
+	}
+
+	mp := make(map[string]rune)
+	if ngsrch.SearchSize > 1 {
+		places, mp = addkeystowordinfo(places)
+	}
+
+	var trr strings.Builder
+	for _, k := range keys {
+		pp := make([]string, len(found[k]))
+		for i, l := range found[k] {
+			pp[i] = fmt.Sprintf(NGLOC, places[l].Loc, places[l].Cit)
+		}
+		ng := k
+		if !se.NgramLemma {
+			// clicking on a surface form n-gram will search for it as a phrase
+			ng = fmt.Sprintf(SRCH, k, k)
+		}
+		trr.WriteString(fmt.Sprintf(TBLRW, ng, len(found[k]), strings.Join(pp, ", ")))
+	}
+
+	htm := fmt.Sprintf(TBL, trr.String())
+
+	an := search.DbWlnMyAu(&ngsrch.Results.Lines[0]).Cleaname
+	if ngsrch.TableSize > 1 {
+		an = an + fmt.Sprintf(" and %d more author(s)", ngsrch.TableSize-1)
+	}
+
+	wn := search.DbWlnMyWk(&ngsrch.Results.Lines[0]).Title
+	if ngsrch.SearchSize > 1 {
+		wn = wn + fmt.Sprintf(" and %d more works(s)", ngsrch.SearchSize-1)
+	}
+
+	ln := MAYNOT
+	if se.NgramLines {
+		ln = MAY
+	}
+
+	sn := MAYNOT
+	if se.NgramSents {
+		sn = MAY
+	}
+
+	sw := ""
+	if se.NgramStops {
+		sw = STOPS
+	}
+
+	cp := ""
+	if ngsrch.Results.Len() == mx {
+		cp = m.Sprintf(HITCAP, mx)
+	}
+
+	el := fmt.Sprintf("%.2f", time.Now().Sub(start).Seconds())
+	ky := multiworkkeymaker(mp, &ngsrch)
+
+	sum := fmt.Sprintf(SUMM, n, by, an, wn, m.Sprintf("%d", len(tokens)), m.Sprintf("%d", len(keys)), minfreq,
+		shown, ln, sn, sw, el, cp, ky)
+
+	if lnch.Config.ZapLunates {
+		htm = gen.DeLunate(htm)
+	}
+
+	var jso JSFeeder
+	jso.SU = sum
+	jso.HT = htm
+
+	j := fmt.Sprintf(vv.BROWSERJS, "ngramlocation") + vv.LEMMAFORMJS
+	jso.NJ = fmt.Sprintf("<script>%s</script>", j)
+
+	vlt.WSInfo.Del <- si.WSID
+	vlt.WSInfo.Del <- ngsrch.WSID
+
+	return gen.JSONresponse(c, jso)
+}
+
+// ngramtokenize - turn the lines into words; note where an n-gram has to stop; one WordInfo per line for the citations
+func ngramtokenize(lines []str.DbWorkline, crosslines bool, crosssentences bool) ([]ngramtoken, []str.WordInfo) {
+	// the marked up line is used because the accented line has lost its punctuation; line-end hyphenation
+	// is repaired via the "hyphenated_words" column: "ἀν-" + "θρώπων" becomes "ἀνθρώπων" on the first line
+
+	var tokens []ngramtoken
+	places := make([]str.WordInfo, len(lines))
+	dropfirst := false
+
+	for i := 0; i < len(lines); i++ {
+		r := lines[i]
+		places[i] = str.WordInfo{Loc: r.BuildHyperlink(), Cit: r.Citation(), Wk: r.WkUID}
+
+		// [a] a new line: the previous token might now be the end of the road
+		if i > 0 && len(tokens) > 0 {
+			p := lines[i-1]
+			if p.WkUID != r.WkUID || p.TbIndex+1 != r.TbIndex {
+				tokens[len(tokens)-1].brk = true
+				dropfirst = false
+			} else if !crosslines {
+				tokens[len(tokens)-1].brk = true
+			}
+		}
+
+		r.PurgeMetadata()
+		mu := str.NoHTML.ReplaceAllString(strings.ReplaceAll(r.MarkedUp, "&nbsp;", " "), "")
+		wds := strings.Fields(mu)
+
+		for j, w := range wds {
+			// [b] the tail of a word that was hyphenated on the previous line
+			if j == 0 && dropfirst {
+				dropfirst = false
+				continue
+			}
+
+			end := sentenceend.MatchString(w)
+			cw := ngramclean(w)
+			sw := notaletter.ReplaceAllString(w, "")
+
+			// [c] the head of a hyphenated word
+			if j == len(wds)-1 && strings.HasSuffix(w, "-") && r.Hyphenated != "" {
+				cw = ngramclean(r.Hyphenated)
+				sw = notaletter.ReplaceAllString(r.Hyphenated, "")
+				dropfirst = true
+			}
+
+			if cw == "" {
+				if end && len(tokens) > 0 && !crosssentences {
+					tokens[len(tokens)-1].brk = true
+				}
+				continue
+			}
+
+			tokens = append(tokens, ngramtoken{word: cw, surface: sw, line: i, brk: end && !crosssentences})
+		}
+	}
+
+	return tokens, places
+}
+
+// ngramclean - a word from the marked up line into the form used by the index and vocabulary makers
+func ngramclean(w string) string {
+	w = strings.ToLower(notaletter.ReplaceAllString(w, ""))
+	return gen.UVσςϲ(gen.SwapAcuteForGrave(w))
+}
+
+// ngramcount - map every n-gram to the lines where it begins; skip n-grams made up entirely of stop words
+func ngramcount(tokens []ngramtoken, n int, stops map[string]struct{}) map[string][]int {
+	found := make(map[string][]int)
+	gram := make([]string, n)
+
+	for i := 0; i+n <= len(tokens); i++ {
+		ok := true
+		allstops := len(stops) > 0
+		for j := 0; j < n; j++ {
+			t := tokens[i+j]
+			if j < n-1 && t.brk {
+				ok = false
+				break
+			}
+			if _, s := stops[t.head]; !s {
+				allstops = false
+			}
+			gram[j] = t.word
+		}
+		if !ok || allstops {
+			continue
+		}
+		k := strings.Join(gram, " ")
+		found[k] = append(found[k], tokens[i].line)
+	}
+
+	return found
+}
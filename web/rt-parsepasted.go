@@ -0,0 +1,274 @@
+//    HipparchiaGoServer
+//    Copyright: E Gunderson 2022-24
+//    License: GNU GENERAL PUBLIC LICENSE 3
+//        (see LICENSE in the top level directory of the distribution)
+
+package web
+
+import (
+	"fmt"
+	"github.com/e-gun/HipparchiaGoServer/internal/base/gen"
+	"github.com/e-gun/HipparchiaGoServer/internal/base/str"
+	"github.com/e-gun/HipparchiaGoServer/internal/db"
+	"github.com/e-gun/HipparchiaGoServer/internal/lnch"
+	"github.com/e-gun/HipparchiaGoServer/internal/vlt"
+	"github.com/e-gun/HipparchiaGoServer/internal/vv"
+	"github.com/labstack/echo/v4"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"golang.org/x/text/unicode/norm"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// RtParsePasted - lemmatize and parse a text that is not in the database: a new fragment, a composition, ...
+func RtParsePasted(c echo.Context) error {
+	c.Response().After(func() { Msg.LogPaths("RtParsePasted()") })
+
+	// the text arrives as the form field "pasted" or as a plain text POST body:
+	// "curl -F pasted=@fragment.txt /text/parsepasted/1f8f1d22?out=index"
+	// "out" is "parse" (the default), "index", or "vocab": the last two are the tables that RtIndexMaker()
+	// and RtVocabMaker() build; the "passages" of the index are the line numbers of the pasted text
+
+	const (
+		SUMM = `
+		<div id="searchsummary">%s for the pasted text<br>
+			%s lines; %s words; %s distinct forms<br>
+			unparsed forms: %d%s<br>
+			<span class="small">(%ss)</span><br>
+		</div>
+		`
+		THH = `
+		<table>
+		<tr>
+				<th class="vocabtable">form</th>
+				<th class="vocabtable">count</th>
+				<th class="vocabtable">headword</th>
+				<th class="vocabtable">analysis</th>
+				<th class="vocabtable">scansion</th>
+				<th class="vocabtable">definitions</th>
+		</tr>
+		%s
+		</table>`
+		TRR = `
+		<tr>
+			<td class="word">%s</td>
+			<td class="count">%s</td>
+			<td class="word"><vocabobserved id="%s">%s</vocabobserved></td>
+			<td class="word">%s</td>
+			<td class="scansion">%s</td>
+			<td class="trans">%s</td>
+		</tr>`
+		FRM     = `<vocabobserved id="%s">%s</vocabobserved>`
+		UNP     = `<span class="red">unparsed</span>`
+		UPW     = "ϙϙϙϙϙϙϙϙ<br>unparsed words"
+		NOTEXT  = `<div id="searchsummary">Nothing to parse: paste some Greek or Latin into the box first</div>`
+		TOOBIG  = `<div id="searchsummary">Nothing parsed: the text is larger than the %dKB that the server will read</div>`
+		BADREAD = `<div id="searchsummary">Nothing parsed: the text could not be read: %s</div>`
+		MAXREAD = 1 << 20
+	)
+
+	type JSFeeder struct {
+		SU string `json:"searchsummary"`
+		HT string `json:"thehtml"`
+		NJ string `json:"newjs"`
+	}
+
+	user := vlt.ReadUUIDCookie(c)
+	if !vlt.AllAuthorized.Check(user) {
+		return c.JSONPretty(http.StatusOK, JSFeeder{NJ: vv.JSVALIDATION}, vv.JSONINDENT)
+	}
+
+	start := time.Now()
+	se := vlt.AllSessions.GetSess(user)
+
+	// [a] the text
+
+	// read one byte past the limit: a partial text would yield a partial index without any warning
+
+	readall := func(r io.Reader) (string, error) {
+		b, e := io.ReadAll(io.LimitReader(r, MAXREAD+1))
+		return string(b), e
+	}
+
+	var pasted string
+	var err error
+	if fh, e := c.FormFile("pasted"); e == nil {
+		f, oe := fh.Open()
+		if oe != nil {
+			return gen.JSONresponse(c, JSFeeder{SU: fmt.Sprintf(BADREAD, oe.Error())})
+		}
+		pasted, err = readall(f)
+		_ = f.Close()
+	} else if v := c.FormValue("pasted"); v != "" {
+		pasted = v
+	} else {
+		pasted, err = readall(c.Request().Body)
+	}
+
+	if err != nil {
+		return gen.JSONresponse(c, JSFeeder{SU: fmt.Sprintf(BADREAD, err.Error())})
+	}
+	if len(pasted) > MAXREAD {
+		return gen.JSONresponse(c, JSFeeder{SU: fmt.Sprintf(TOOBIG, MAXREAD>>10)})
+	}
+
+	lines := strings.Split(norm.NFC.String(pasted), "\n")
+	slicedwords := pastedtokenize(lines)
+
+	if len(slicedwords) == 0 {
+		return gen.JSONresponse(c, JSFeeder{SU: NOTEXT})
+	}
+
+	// [b] find the headwords for all of the distinct words; γ may really be γ' (i.e. γε): cf. RtIndexMaker()
+
+	var distinct []string
+	seen := make(map[string]bool)
+	for _, w := range slicedwords {
+		if !seen[w.Word] {
+			seen[w.Word] = true
+			distinct = append(distinct, w.Word)
+		}
+	}
+
+	morphmap := db.ArrayToGetRequiredMorphObjects(distinct)
+
+	poss := make(map[string][]str.MorphPossib)
+	var unparsed []string
+	for _, w := range distinct {
+		if m, ok := morphmap[w]; ok {
+			poss[w] = extractmorphpossibilities(m.RawPossib)
+		} else if m, y := morphmap[w+"'"]; y {
+			poss[w] = extractmorphpossibilities(m.RawPossib)
+		} else {
+			unparsed = append(unparsed, w)
+		}
+	}
+
+	localcounts := make(map[string]int)
+	for _, w := range slicedwords {
+		localcounts[w.Word]++
+	}
+
+	// [c] the requested output
+
+	m := message.NewPrinter(language.English)
+	pat := regexp.MustCompile("^(.{1,3}\\.)\\s")
+
+	var htm, what, j string
+	switch c.QueryParam("out") {
+	case "index":
+		what = "Index"
+		var trimslices []str.WordInfo
+		for _, w := range slicedwords {
+			pp, ok := poss[w.Word]
+			if !ok {
+				w.HeadWd = UPW
+				trimslices = append(trimslices, w)
+			}
+			for _, p := range pp {
+				hw := w
+				hw.HeadWd = p.Headwd
+				trimslices = append(trimslices, hw)
+			}
+		}
+		markhomonyms(trimslices)
+		htm = indextable(trimslices)
+		j = fmt.Sprintf(vv.LEXFINDJS, "indexobserved")
+	case "vocab":
+		what = "Vocabulary"
+		var parsedwords []str.WordInfo
+		for _, w := range slicedwords {
+			for _, p := range poss[w.Word] {
+				hw := w
+				hw.HeadWd = p.Headwd
+				hw.Trans = p.Transl
+				parsedwords = append(parsedwords, hw)
+			}
+		}
+		htm = vocabtable(vocabinfomap(parsedwords, se.VocScansion), se.VocByCount, se.VocScansion)
+		j = fmt.Sprintf(vv.LEXFINDJS, "vocabobserved")
+	default:
+		what = "Morphology"
+		var trr strings.Builder
+		for _, w := range distinct {
+			frm := fmt.Sprintf(FRM, w, w)
+			ct := m.Sprintf("%d", localcounts[w])
+			pp, ok := poss[w]
+			if !ok {
+				trr.WriteString(fmt.Sprintf(TRR, frm, ct, "", "", UNP, "", ""))
+				continue
+			}
+			for i, p := range pp {
+				if i > 0 {
+					frm, ct = "", ""
+				}
+				trr.WriteString(fmt.Sprintf(TRR, frm, ct, p.Headwd, p.Headwd, p.Anal, quantityfixer.Replace(p.Scansion),
+					polishtrans(p.Transl, pat)))
+			}
+		}
+		htm = fmt.Sprintf(THH, trr.String())
+		j = fmt.Sprintf(vv.LEXFINDJS, "vocabobserved")
+	}
+
+	// [d] the summary
+
+	up := ""
+	if len(unparsed) > 0 {
+		up = `<p class="indented smallerthannormal">` + strings.Join(gen.PolytonicSort(unparsed), ", ") + `</p>`
+	}
+
+	el := fmt.Sprintf("%.2f", time.Now().Sub(start).Seconds())
+
+	sum := fmt.Sprintf(SUMM, what, m.Sprintf("%d", len(lines)), m.Sprintf("%d", len(slicedwords)),
+		m.Sprintf("%d", len(distinct)), len(unparsed), up, el)
+
+	if lnch.Config.ZapLunates {
+		htm = gen.DeLunate(htm)
+	}
+
+	var jso JSFeeder
+	jso.SU = sum
+	jso.HT = htm
+	jso.NJ = fmt.Sprintf("<script>%s</script>", j)
+
+	return gen.JSONresponse(c, jso)
+}
+
+// pastedtokenize - the lines of a pasted text --> []WordInfo; the citation is the line number
+func pastedtokenize(lines []string) []str.WordInfo {
+	// words are cleaned the way the index and vocabulary makers see them: see ngramclean(); a word hyphenated at
+	// the end of a line is joined to the head of the next line
+
+	var slicedwords []str.WordInfo
+	carry := ""
+	for i, l := range lines {
+		wds := strings.Fields(l)
+		for j, w := range wds {
+			if carry != "" {
+				w = carry + w
+				carry = ""
+			}
+			if j == len(wds)-1 && strings.HasSuffix(w, "-") && i < len(lines)-1 {
+				carry = strings.TrimSuffix(w, "-")
+				continue
+			}
+			cw := ngramclean(w)
+			if cw == "" {
+				continue
+			}
+			slicedwords = append(slicedwords, str.WordInfo{Word: cw, Cit: fmt.Sprintf("%d", i+1)})
+		}
+	}
+
+	if carry != "" {
+		if cw := ngramclean(carry); cw != "" {
+			slicedwords = append(slicedwords, str.WordInfo{Word: cw, Cit: fmt.Sprintf("%d", len(lines))})
+		}
+	}
+
+	return slicedwords
+}
@@ -6,6 +6,7 @@
 package web
 
 import (
+	"encoding/json"
 	"fmt"
 	"github.com/e-gun/HipparchiaGoServer/internal/base/gen"
 	"github.com/e-gun/HipparchiaGoServer/internal/base/str"
@@ -15,7 +16,9 @@ import (
 	"github.com/e-gun/HipparchiaGoServer/internal/vlt"
 	"github.com/e-gun/HipparchiaGoServer/internal/vv"
 	"github.com/labstack/echo/v4"
+	"io"
 	"net/http"
+	"strings"
 )
 
 //
@@ -93,6 +96,16 @@ func RtSearch(c echo.Context) error {
 		return vec.LDASearch(c, srch)
 	}
 
+	if se.Collocates && !srch.Twobox {
+		// not a normal search: jump to "collocates.go"
+		return vec.CollocatesSearch(c, srch)
+	}
+
+	if se.VecExpand && !lnch.Config.VectorsDisabled && srch.LemmaOne != "" && !srch.Twobox {
+		// a lemma search + its semantic neighbors: jump to "vectorqueryexpand.go"
+		return vec.ExpandedLemmaSearch(c, srch)
+	}
+
 	// [D] OK, IT IS A SEARCH FOR A WORD OR PHRASE
 
 	c.Response().After(func() { Msg.LogPaths("RtSearch()") })
@@ -123,7 +136,9 @@ func RtSearch(c echo.Context) error {
 
 	search.SortResults(&completed)
 	soj := str.SearchOutputJSON{}
-	if se.HitContext == 0 {
+	if se.KWIC {
+		soj = search.FormatKWICResults(&completed)
+	} else if se.HitContext == 0 {
 		soj = search.FormatNoContextResults(&completed)
 	} else {
 		soj = search.FormatWithContextResults(&completed)
@@ -132,3 +147,129 @@ func RtSearch(c echo.Context) error {
 	vlt.WSInfo.Del <- srch.WSID
 	return gen.JSONresponse(c, soj)
 }
+
+// RtSearchWordList - find every item on an uploaded word list (or headword list) in Y (derived from the session)
+func RtSearchWordList(c echo.Context) error {
+	// the list can arrive three ways:
+	// [1] a multipart upload: "curl -F wordlist=@words.txt -F headwords=yes /srch/list/1f8f1d22"
+	// [2] a JSON body: {"items": ["dolor", "amor"], "headwords": false}
+	// [3] a plain text body: one item per line
+	// add "?fmt=json" to get the tagged hits and the per-item counts back as JSON instead of HTML
+
+	const (
+		NOLIST    = "<code>Cannot execute this search. No word list was received.</code>"
+		TOOMANYIP = "<code>Cannot execute this search. Your ip address (%s) is already running the maximum number of simultaneous searches allowed: %d.</code>"
+		TOOMANY   = "<code>Cannot execute this search. The server is already running the maximum number of simultaneous searches allowed: %d.</code>"
+		TOOBIG    = "<code>Cannot execute this search. The word list is larger than the %dKB that the server will read.</code>"
+		BADREAD   = "<code>Cannot execute this search. The word list could not be read: %s</code>"
+		CAPPED    = "<code>The list was trimmed to its first %d items: %d more were not sought.</code><br><br>"
+		CAPLOG    = "RtSearchWordList() trimmed a list to %d items: %d dropped"
+		MAXREAD   = 1 << 20
+	)
+
+	type WLRequest struct {
+		Items     []string `json:"items"`
+		Headwords bool     `json:"headwords"`
+	}
+
+	type WLResponse struct {
+		Items   []string               `json:"items"`
+		Dropped int                    `json:"dropped"`
+		Hits    []search.WordListHit   `json:"hits"`
+		Counts  []search.WordListCount `json:"counts"`
+	}
+
+	user := vlt.ReadUUIDCookie(c)
+
+	// [A] ARE WE GOING TO DO THIS AT ALL?
+
+	if !vlt.AllAuthorized.Check(user) {
+		return gen.JSONresponse(c, str.SearchOutputJSON{JS: vv.VALIDATIONBOX})
+	}
+
+	getsrchcount := func(ip string) int {
+		responder := vlt.WSSICount{Key: ip, Response: make(chan int)}
+		vlt.WSInfo.IPSrchCount <- responder
+		return <-responder.Response
+	}
+
+	if getsrchcount(c.RealIP()) >= lnch.Config.MaxSrchIP {
+		m := fmt.Sprintf(TOOMANYIP, c.RealIP(), getsrchcount(c.RealIP()))
+		return gen.JSONresponse(c, str.SearchOutputJSON{Searchsummary: m})
+	}
+
+	if len(vlt.WebsocketPool.ClientMap) >= lnch.Config.MaxSrchTot {
+		m := fmt.Sprintf(TOOMANY, len(vlt.WebsocketPool.ClientMap))
+		return gen.JSONresponse(c, str.SearchOutputJSON{Searchsummary: m})
+	}
+
+	// [B] WHAT IS ON THE LIST?
+
+	var wlr WLRequest
+	wlr.Headwords = c.FormValue("headwords") == "yes" || c.QueryParam("headwords") == "yes"
+
+	// read one byte past the limit: a list cut off in the middle would quietly lose items (or half of an item)
+
+	var src io.Reader
+	uploaded := false
+	if fh, err := c.FormFile("wordlist"); err == nil {
+		f, e := fh.Open()
+		if e != nil {
+			return gen.JSONresponse(c, str.SearchOutputJSON{Searchsummary: fmt.Sprintf(BADREAD, e.Error())})
+		}
+		defer f.Close()
+		src = f
+		uploaded = true
+	} else {
+		src = c.Request().Body
+	}
+
+	b, err := io.ReadAll(io.LimitReader(src, MAXREAD+1))
+	if err != nil {
+		return gen.JSONresponse(c, str.SearchOutputJSON{Searchsummary: fmt.Sprintf(BADREAD, err.Error())})
+	}
+	if len(b) > MAXREAD {
+		return gen.JSONresponse(c, str.SearchOutputJSON{Searchsummary: fmt.Sprintf(TOOBIG, MAXREAD>>10)})
+	}
+
+	if !uploaded && strings.Contains(c.Request().Header.Get(echo.HeaderContentType), echo.MIMEApplicationJSON) {
+		if e := json.Unmarshal(b, &wlr); e != nil {
+			return gen.JSONresponse(c, str.SearchOutputJSON{Searchsummary: fmt.Sprintf(BADREAD, e.Error())})
+		}
+	} else {
+		wlr.Items = strings.Split(string(b), "\n")
+	}
+
+	items, dropped := search.CleanWordList(wlr.Items, wlr.Headwords)
+	if len(items) == 0 {
+		return gen.JSONresponse(c, str.SearchOutputJSON{Searchsummary: NOLIST})
+	}
+
+	// [C] SEARCH
+
+	c.Response().After(func() { Msg.LogPaths("RtSearchWordList()") })
+
+	srch := search.BuildDefaultSearch(c)
+	search.ConfigureWordListSearch(&srch, items, wlr.Headwords)
+	if dropped > 0 {
+		Msg.PEEK(fmt.Sprintf(CAPLOG, len(items), dropped))
+		srch.ExtraMsg = fmt.Sprintf(CAPPED, len(items), dropped)
+	}
+	search.SearchAndInsertResults(&srch)
+
+	// [D] DONE: TAG, TRIM, AND FORMAT
+
+	search.SortResults(&srch)
+	hits, counts := search.TagWordListHits(&srch)
+	if len(hits) > srch.CurrentLimit {
+		hits = hits[0:srch.CurrentLimit]
+		srch.Results.ResizeTo(srch.CurrentLimit)
+	}
+
+	vlt.WSInfo.Del <- srch.WSID
+
+	if c.QueryParam("fmt") == "json" {
+		return gen.JSONresponse(c, WLResponse{Items: items, Dropped: dropped, Hits: hits, Counts: counts})
+	}
+	return gen.JSONresponse(c, search.FormatWordListResults(&srch, hits, counts))
+}
@@ -38,6 +38,7 @@ type SelectionValues struct {
 	WGenre string
 	ALoc   string
 	WLoc   string
+	DLoc   string
 	IsExcl bool
 	IsRaw  bool
 	Start  string
@@ -103,6 +104,7 @@ func RtSelectionMake(c echo.Context) error {
 	sel.WGenre = c.QueryParam("wkgenre")
 	sel.ALoc = c.QueryParam("auloc")
 	sel.WLoc = c.QueryParam("wkprov")
+	sel.DLoc = c.QueryParam("docloc")
 
 	if c.QueryParam("raw") == "t" {
 		sel.IsRaw = true
@@ -211,6 +213,8 @@ func RtSelectionClear(c echo.Context) error {
 		newincl.AuLocations = removemd(newincl.AuLocations, id)
 	case "wlocselections":
 		newincl.WkLocations = removemd(newincl.WkLocations, id)
+	case "dlocselections":
+		newincl.DcLocations = removemd(newincl.DcLocations, id)
 	case "auselections":
 		// auselections + wkselections + psgselections + ...
 		// direction: MappedAuthByName --> Authors
@@ -237,6 +241,8 @@ func RtSelectionClear(c echo.Context) error {
 		newexcl.AuLocations = removemd(newexcl.AuLocations, id)
 	case "wlocexclusions":
 		newexcl.WkLocations = removemd(newexcl.WkLocations, id)
+	case "dlocexclusions":
+		newexcl.DcLocations = removemd(newexcl.DcLocations, id)
 	case "auexclusions":
 		foundkey := kvpairmdkey(id, newexcl.MappedAuthByName)
 		newexcl.Authors = gen.SetSubtraction(newexcl.Authors, []string{foundkey})
@@ -269,6 +275,72 @@ func RtSelectionFetch(c echo.Context) error {
 	return c.JSONPretty(http.StatusOK, sd, vv.JSONINDENT)
 }
 
+// RtSelectionStore - set the current selection aside so that it can be compared with the next one
+func RtSelectionStore(c echo.Context) error {
+	// "GET /selection/store/_ HTTP/1.1" stores; "GET /selection/store/_?clear=t HTTP/1.1" forgets
+	// see StoredSelectionIntoBulkSearch()
+
+	const (
+		STORED = `<div id="searchsummary">Stored for comparison: %s</div>`
+		EMPTY  = `<div id="searchsummary">Nothing is stored for comparison</div>`
+	)
+
+	type JSFeeder struct {
+		SU string `json:"searchsummary"`
+		HT string `json:"thehtml"`
+		NJ string `json:"newjs"`
+	}
+
+	user := vlt.ReadUUIDCookie(c)
+	if !vlt.AllAuthorized.Check(user) {
+		return c.JSONPretty(http.StatusOK, JSFeeder{NJ: vv.JSVALIDATION}, vv.JSONINDENT)
+	}
+
+	s := vlt.AllSessions.GetSess(user)
+
+	if c.QueryParam("clear") == "t" {
+		s.StoredIncl = str.SearchIncExl{}
+		s.StoredExcl = str.SearchIncExl{}
+	} else {
+		s.StoredIncl = s.Inclusions.Clone()
+		s.StoredExcl = s.Exclusions.Clone()
+	}
+	vlt.AllSessions.InsertSess(s)
+
+	var jso JSFeeder
+	jso.SU = EMPTY
+	if !s.StoredIncl.IsEmpty() {
+		jso.SU = fmt.Sprintf(STORED, storedselectionsummary(s))
+	}
+
+	return gen.JSONresponse(c, jso)
+}
+
+// storedselectionsummary - a one-line description of the selection set aside via RtSelectionStore()
+func storedselectionsummary(s str.ServerSession) string {
+	const (
+		EXCL = " (minus %d exclusion(s))"
+	)
+
+	sv := s
+	sv.Inclusions = s.StoredIncl.Clone()
+	sv.Exclusions = s.StoredExcl.Clone()
+	search.BuildSelectionOverview(&sv)
+
+	i := sv.Inclusions
+	var items []string
+	for _, l := range [][]string{i.AuGenres, i.WkGenres, i.AuLocations, i.WkLocations, i.DcLocations, i.ListedABN,
+		i.ListedWBN, i.ListedPBN} {
+		items = append(items, l...)
+	}
+
+	sum := strings.Join(items, "; ")
+	if !sv.Exclusions.IsEmpty() {
+		sum += fmt.Sprintf(EXCL, sv.Exclusions.CountItems())
+	}
+	return sum
+}
+
 // registerselection - do the hard work of parsing a selection
 func registerselection(user string, sv SelectionValues) str.ServerSession {
 	// have to deal with all sorts of possibilities
@@ -282,6 +354,7 @@ func registerselection(user string, sv SelectionValues) str.ServerSession {
 	// [e] work genre: "GET /selection/make/_?wkgenre=Apocalyp. HTTP/1.1"
 	// [f] author location: "GET /selection/make/_?auloc=Abdera HTTP/1.1"
 	// [g] work proven: "GET /selection/make/_?wkprov=Abdera%20(Thrace) HTTP/1.1"
+	// [h] document findspot: "GET /selection/make/_?docloc=Attica HTTP/1.1"
 
 	const (
 		PSGT = `%s_FROM_%d_TO_%d`
@@ -393,6 +466,16 @@ func registerselection(user string, sv SelectionValues) str.ServerSession {
 		}
 	}
 
+	if len(sv.DLoc) != 0 {
+		if _, ok := mps.DocLocs[sv.DLoc]; ok {
+			if !sv.IsExcl {
+				s.Inclusions.DcLocations = gen.Unique(append(s.Inclusions.DcLocations, sv.DLoc))
+			} else {
+				s.Exclusions.DcLocations = gen.Unique(append(s.Exclusions.DcLocations, sv.DLoc))
+			}
+		}
+	}
+
 	s = rationalizeselections(s, sv)
 
 	return s
@@ -837,12 +920,13 @@ func reportcurrentselections(c echo.Context) SelectionData {
 	e := s.Exclusions
 
 	// need to do it in this order: don't walk through the map keys
-	cat := []string{"agn", "wgn", "aloc", "wloc", "au", "wk", "psg"}
+	cat := []string{"agn", "wgn", "aloc", "wloc", "dloc", "au", "wk", "psg"}
 	catmap := map[string][2]string{
 		"agn":  {"Author categories", "AuGenres"},
 		"wgn":  {"Work genres", "WkGenres"},
 		"aloc": {"Author location", "AuLocations"},
 		"wloc": {"Work provenance", "WkLocations"},
+		"dloc": {"Document findspot", "DcLocations"},
 		"au":   {"Authors", "ListedABN"},
 		"wk":   {"Works", "ListedWBN"},
 		"psg":  {"Passages", "ListedPBN"},
@@ -42,7 +42,9 @@ func RtSetOption(c echo.Context) error {
 
 	ynoptionlist := []string{"greekcorpus", "latincorpus", "papyruscorpus", "inscriptioncorpus", "christiancorpus",
 		"rawinputstyle", "onehit", "headwordindexing", "indexbyfrequency", "spuria", "incerta", "varia", "vocbycount",
-		"vocscansion", "isvectorsearch", "extendedgraph", "ldagraph", "isldasearch", "ldagraph2dimensions"}
+		"vocscansion", "isvectorsearch", "extendedgraph", "ldagraph", "isldasearch", "ldagraph2dimensions", "docdates",
+		"lemmaforms", "vecexpand", "collocates", "kwic", "ngramlemma", "ngramstops", "ngramlines", "ngramsentences",
+		"reuselemma", "keylemma"}
 
 	s := vlt.AllSessions.GetSess(user)
 
@@ -55,6 +57,8 @@ func RtSetOption(c echo.Context) error {
 			mps.AllWorks = mps.MapNewWorkCorpus(c, mps.AllWorks)
 			// append to the master author map
 			mps.AllAuthors = mps.MapNewAuthorCorpus(c, mps.AllAuthors)
+			// append to the master document map (only matters for in, dp, and ch)
+			mps.AllDocs = mps.MapNewDocCorpus(c, mps.AllDocs)
 			// re-populateglobalmaps
 			mps.RePopulateGlobalMaps()
 			d := fmt.Sprintf("modifyglobalmapsifneeded(): %.3fs", time.Now().Sub(start).Seconds())
@@ -115,13 +119,35 @@ func RtSetOption(c echo.Context) error {
 				s.LDAgraph = b
 			case "ldagraph2dimensions":
 				s.LDA2D = b
+			case "docdates":
+				s.DocDates = b
+			case "lemmaforms":
+				s.LemmaForms = b
+			case "vecexpand":
+				s.VecExpand = b
+			case "collocates":
+				s.Collocates = b
+			case "kwic":
+				s.KWIC = b
+			case "ngramlemma":
+				s.NgramLemma = b
+			case "ngramstops":
+				s.NgramStops = b
+			case "ngramlines":
+				s.NgramLines = b
+			case "ngramsentences":
+				s.NgramSents = b
+			case "reuselemma":
+				s.ReuseLemma = b
+			case "keylemma":
+				s.KeyLemma = b
 			default:
 				Msg.WARN(FAIL2)
 			}
 		}
 	}
 
-	valoptionlist := []string{"nearornot", "searchscope", "sortorder", "modeler", "vtextprep"}
+	valoptionlist := []string{"nearornot", "searchscope", "sortorder", "modeler", "vtextprep", "kwicsort", "stylograph", "keyref", "ldamodeler", "ldaunit"}
 	if slices.Contains(valoptionlist, opt) {
 		switch opt {
 		case "nearornot":
@@ -135,26 +161,52 @@ func RtSetOption(c echo.Context) error {
 				s.SearchScope = val
 			}
 		case "sortorder":
-			valid := []string{"shortname", "converted_date", "provenance", "universalid"}
+			valid := []string{"shortname", "converted_date", "provenance", "universalid", "document_date", "findspot"}
 			if slices.Contains(valid, val) {
 				s.SortHitsBy = val
 			}
 		case "modeler":
-			valid := []string{"w2v", "glove", "lexvec"}
+			valid := []string{"w2v", "glove", "lexvec", "imported"}
 			if slices.Contains(valid, val) {
 				s.VecModeler = val
 			}
 		case "vtextprep":
-			valid := []string{"winner", "unparsed", "yoked", "montecarlo"}
+			valid := []string{"winner", "unparsed", "yoked", "montecarlo", "contextual"}
 			if slices.Contains(valid, val) {
 				s.VecTextPrep = val
 			}
+		case "kwicsort":
+			valid := []string{"hit", "l1", "l2", "r1", "r2"}
+			if slices.Contains(valid, val) {
+				s.KWICSort = val
+			}
+		case "stylograph":
+			valid := []string{"scatter", "dendrogram"}
+			if slices.Contains(valid, val) {
+				s.StyloGraph = val
+			}
+		case "keyref":
+			valid := []string{"corpus", "stored"}
+			if slices.Contains(valid, val) {
+				s.KeyRef = val
+			}
+		case "ldamodeler":
+			valid := []string{"lda", "nmf", "lsa"}
+			if slices.Contains(valid, val) {
+				s.LDAModeler = val
+			}
+		case "ldaunit":
+			valid := []string{"sentence", "work", "level1", "level2", "level3", "chunk"}
+			if slices.Contains(valid, val) {
+				s.LDAUnit = val
+			}
 		default:
 			Msg.WARN(FAIL2)
 		}
 	}
 
-	spinoptionlist := []string{"maxresults", "linesofcontext", "browsercontext", "proximity", "neighborcount", "ldatopiccount"}
+	spinoptionlist := []string{"maxresults", "linesofcontext", "browsercontext", "proximity", "neighborcount", "ldatopiccount",
+		"ngramsize", "ngramfreq", "reusesize", "stylosample", "stylomfw"}
 	if slices.Contains(spinoptionlist, opt) {
 		intval, e := strconv.Atoi(val)
 		if e == nil {
@@ -201,6 +253,16 @@ func RtSetOption(c echo.Context) error {
 				} else {
 					s.LDAtopics = vv.LDAMAXTOPICS
 				}
+			case "ngramsize":
+				s.NgramSize = min(max(intval, vv.NGRAMMIN), vv.NGRAMMAX)
+			case "ngramfreq":
+				s.NgramFreq = max(intval, 1)
+			case "reusesize":
+				s.ReuseSize = min(max(intval, vv.NGRAMMIN), vv.NGRAMMAX)
+			case "stylosample":
+				s.StyloSample = min(max(intval, vv.STYLOMINSAMPLE), vv.STYLOMAXSAMPLE)
+			case "stylomfw":
+				s.StyloMFW = max(intval, 1)
 			default:
 				Msg.WARN(FAIL2)
 			}
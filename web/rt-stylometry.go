@@ -0,0 +1,24 @@
+//    HipparchiaGoServer
+//    Copyright: E Gunderson 2022-24
+//    License: GNU GENERAL PUBLIC LICENSE 3
+//        (see LICENSE in the top level directory of the distribution)
+
+package web
+
+import (
+	"github.com/e-gun/HipparchiaGoServer/internal/base/str"
+	"github.com/e-gun/HipparchiaGoServer/internal/vec"
+	"github.com/e-gun/HipparchiaGoServer/internal/vlt"
+	"github.com/e-gun/HipparchiaGoServer/internal/vv"
+	"github.com/labstack/echo/v4"
+	"net/http"
+)
+
+// RtStylometry - Delta, Zeta, and a function word PCA for the works in the current selection
+func RtStylometry(c echo.Context) error {
+	user := vlt.ReadUUIDCookie(c)
+	if !vlt.AllAuthorized.Check(user) {
+		return c.JSONPretty(http.StatusOK, str.SearchOutputJSON{JS: vv.JSVALIDATION}, vv.JSONINDENT)
+	}
+	return vec.StylometrySearch(c)
+}
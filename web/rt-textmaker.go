@@ -131,7 +131,7 @@ func RtTextMaker(c echo.Context) error {
 	}
 
 	ti := firstwork.Title
-	if len(sui.Works) > 1 || len(sui.WkGenres) > 0 || len(sui.WkLocations) > 0 {
+	if len(sui.Works) > 1 || len(sui.WkGenres) > 0 || len(sui.WkLocations) > 0 || len(sui.DcLocations) > 0 {
 		ti += " (and others)"
 	}
 
@@ -0,0 +1,345 @@
+//    HipparchiaGoServer
+//    Copyright: E Gunderson 2022-24
+//    License: GNU GENERAL PUBLIC LICENSE 3
+//        (see LICENSE in the top level directory of the distribution)
+
+package web
+
+import (
+	"fmt"
+	"github.com/e-gun/HipparchiaGoServer/internal/base/gen"
+	"github.com/e-gun/HipparchiaGoServer/internal/base/str"
+	"github.com/e-gun/HipparchiaGoServer/internal/db"
+	"github.com/e-gun/HipparchiaGoServer/internal/lnch"
+	"github.com/e-gun/HipparchiaGoServer/internal/search"
+	"github.com/e-gun/HipparchiaGoServer/internal/vec"
+	"github.com/e-gun/HipparchiaGoServer/internal/vlt"
+	"github.com/e-gun/HipparchiaGoServer/internal/vv"
+	"github.com/labstack/echo/v4"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// reusepair - a passage in the target, a passage in the source, and what they share
+type reusepair struct {
+	tgt   int
+	src   int
+	score float64
+	grams []string
+}
+
+// RtTextReuse - rank the passages of the current selection that share rare n-grams with the stored selection
+func RtTextReuse(c echo.Context) error {
+	c.Response().After(func() { Msg.LogPaths("RtTextReuse()") })
+
+	// the "source" was set aside via RtSelectionStore(); the "target" is the current selection
+	// every word is weighted by its rarity in the whole corpus: log(N/F) where F comes from FetchHeadwordCounts(); an
+	// n-gram is worth the sum of its weights; a pair of passages is worth the sum of the n-grams they share
+
+	// stop words are dropped before the n-grams are built: "arma uirumque cano" and "arma et uirum" share "arma uir"
+
+	const (
+		SUMM = `
+		<div id="searchsummary">Passages in %s,&nbsp;<span class="foundwork">%s</span> that share rare %d-grams (by %s)
+			with the stored selection: %s<br>
+			%s source lines and %s target lines; %s shared n-grams produced %s pairs of passages%s<br>
+			n-grams found more than %d times in the source were skipped as formulae; stop words were ignored<br>
+			<span class="small">(%ss)</span><br>
+			%s
+		</div>
+		`
+		TBL = `
+		<table>
+		<tbody><tr>
+			<th class="indextable">&nbsp;</th>
+			<th class="indextable">score</th>
+			<th class="indextable">target</th>
+			<th class="indextable">source</th>
+			<th class="indextable">shared</th>
+		</tr>
+		%s
+		</tbody></table>`
+		TBLRW = `
+		<tr class="%s">
+			<td class="count">%d</td>
+			<td class="count">%.2f</td>
+			<td class="leftpad"><reuselocation id="%s"><span class="foundauthor">%s</span></reuselocation><br>%s</td>
+			<td class="leftpad"><reuselocation id="%s"><span class="foundauthor">%s</span></reuselocation><br>%s</td>
+			<td class="word">%s</td>
+		</tr>`
+		CITE     = "%s, %s %s"
+		SHOWN    = ` (showing the top %d)`
+		NOSOURCE = `<div id="searchsummary">Text reuse needs two selections: store a source selection first and then select the target</div>`
+		MSG1     = "Grabbing the lines...&nbsp;(part 1 of 4)"
+		MSG2     = "Parsing the vocabulary...&nbsp;(part 2 of 4)"
+		MSG3     = "Matching the n-grams...&nbsp;(part 3 of 4)"
+		MSG4     = "Building the HTML...&nbsp;(part 4 of 4)"
+		HITCAP   = `<span class="small"><span class="red emph">text reuse incomplete:</span>: hit the cap of %d on allowed lines</span>`
+	)
+
+	type JSFeeder struct {
+		SU string `json:"searchsummary"`
+		HT string `json:"thehtml"`
+		NJ string `json:"newjs"`
+	}
+
+	user := vlt.ReadUUIDCookie(c)
+	if !vlt.AllAuthorized.Check(user) {
+		return c.JSONPretty(http.StatusOK, JSFeeder{NJ: vv.JSVALIDATION}, vv.JSONINDENT)
+	}
+
+	start := time.Now()
+	se := vlt.AllSessions.GetSess(user)
+	if se.StoredIncl.IsEmpty() {
+		return gen.JSONresponse(c, JSFeeder{SU: NOSOURCE})
+	}
+
+	n := min(max(se.ReuseSize, vv.NGRAMMIN), vv.NGRAMMAX)
+
+	// "si" is a blank search struct used for progress reporting
+	si := search.BuildDefaultSearch(c)
+	si.Type = "reuse"
+
+	vlt.WSInfo.UpdateSummMsg <- vlt.WSSIKVs{si.WSID, MSG1}
+	vlt.WSInfo.UpdateRemain <- vlt.WSSIKVi{si.WSID, 1}
+
+	// [a] get the lines of both selections
+
+	mx := lnch.Config.MaxText * vv.MAXVOCABLINEGENERATION
+	srcsrch := search.StoredSelectionIntoBulkSearch(c, mx)
+	tgtsrch := search.SessionIntoBulkSearch(c, mx)
+
+	if srcsrch.Results.Len() == 0 || tgtsrch.Results.Len() == 0 {
+		return emptyjsreturn(c)
+	}
+
+	srctoks, _ := ngramtokenize(srcsrch.Results.Lines, true, false)
+	tgttoks, _ := ngramtokenize(tgtsrch.Results.Lines, true, false)
+
+	// [b] headwords: for the lemmatized n-grams and for the weights
+
+	vlt.WSInfo.UpdateSummMsg <- vlt.WSSIKVs{si.WSID, MSG2}
+
+	var words []string
+	for _, t := range append(srctoks, tgttoks...) {
+		words = append(words, t.word)
+	}
+	winners := vec.WinnerHeadwords(words)
+
+	asheadword := func(w string) string {
+		if hw, ok := winners[w]; ok {
+			return hw
+		}
+		return w
+	}
+
+	by := "form"
+	if se.ReuseLemma {
+		by = "headword"
+		for i := range srctoks {
+			srctoks[i].word = asheadword(srctoks[i].word)
+		}
+		for i := range tgttoks {
+			tgttoks[i].word = asheadword(tgttoks[i].word)
+		}
+	}
+
+	stops := vec.StopSet()
+	srctoks = reusedropstops(srctoks, stops, asheadword)
+	tgttoks = reusedropstops(tgttoks, stops, asheadword)
+
+	weights := reuseweights(append(srctoks, tgttoks...), asheadword)
+
+	// [c] index the source and then walk the target
+
+	vlt.WSInfo.UpdateSummMsg <- vlt.WSSIKVs{si.WSID, MSG3}
+
+	srcgrams := ngramcount(srctoks, n, nil)
+	tgtgrams := ngramcount(tgttoks, n, nil)
+
+	shared := 0
+	pairs := make(map[[2]int]*reusepair)
+	for g, tl := range tgtgrams {
+		sl, ok := srcgrams[g]
+		if !ok || len(sl) > vv.REUSEMAXSRCHITS {
+			continue
+		}
+		shared++
+
+		gw := 0.0
+		for _, w := range gen.Unique(strings.Split(g, " ")) {
+			gw += weights[w]
+		}
+
+		for _, t := range gen.Unique(tl) {
+			for _, s := range gen.Unique(sl) {
+				k := [2]int{t, s}
+				if _, ok := pairs[k]; !ok {
+					pairs[k] = &reusepair{tgt: t, src: s}
+				}
+				pairs[k].score += gw
+				pairs[k].grams = append(pairs[k].grams, g)
+			}
+		}
+	}
+
+	ranked := make([]*reusepair, 0, len(pairs))
+	for _, p := range pairs {
+		sort.Strings(p.grams)
+		ranked = append(ranked, p)
+	}
+
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].score == ranked[j].score {
+			if ranked[i].tgt == ranked[j].tgt {
+				return ranked[i].src < ranked[j].src
+			}
+			return ranked[i].tgt < ranked[j].tgt
+		}
+		return ranked[i].score > ranked[j].score
+	})
+
+	// [d] format the output: side by side with the shared words highlighted
+
+	vlt.WSInfo.UpdateSummMsg <- vlt.WSSIKVs{si.WSID, MSG4}
+
+	m := message.NewPrinter(language.English)
+
+	npairs := len(ranked)
+	shown := ""
+	if len(ranked) > vv.REUSETOSHOW {
+		shown = m.Sprintf(SHOWN, vv.REUSETOSHOW)
+		ranked = ranked[0:vv.REUSETOSHOW]
+	}
+
+	cite := func(r *str.DbWorkline) string {
+		return fmt.Sprintf(CITE, search.DbWlnMyAu(r).Shortname, search.DbWlnMyWk(r).Title, r.Citation())
+	}
+
+	var trr strings.Builder
+	for i, p := range ranked {
+		sw := make(map[string]bool)
+		for _, g := range p.grams {
+			for _, w := range strings.Split(g, " ") {
+				sw[w] = true
+			}
+		}
+
+		rc := "regular"
+		if i%3 == 2 {
+			rc = "nthrow"
+		}
+
+		t := &tgtsrch.Results.Lines[p.tgt]
+		s := &srcsrch.Results.Lines[p.src]
+		tl := reusehighlight(t, sw, se.ReuseLemma, asheadword)
+		sl := reusehighlight(s, sw, se.ReuseLemma, asheadword)
+
+		trr.WriteString(fmt.Sprintf(TBLRW, rc, i+1, p.score, t.BuildHyperlink(), cite(t), tl, s.BuildHyperlink(), cite(s),
+			sl, strings.Join(p.grams, "<br>")))
+	}
+
+	htm := fmt.Sprintf(TBL, trr.String())
+
+	an := search.DbWlnMyAu(&tgtsrch.Results.Lines[0]).Cleaname
+	if tgtsrch.TableSize > 1 {
+		an = an + fmt.Sprintf(" and %d more author(s)", tgtsrch.TableSize-1)
+	}
+
+	wn := search.DbWlnMyWk(&tgtsrch.Results.Lines[0]).Title
+	if tgtsrch.SearchSize > 1 {
+		wn = wn + fmt.Sprintf(" and %d more works(s)", tgtsrch.SearchSize-1)
+	}
+
+	cp := ""
+	if srcsrch.Results.Len() == mx || tgtsrch.Results.Len() == mx {
+		cp = m.Sprintf(HITCAP, mx)
+	}
+
+	el := fmt.Sprintf("%.2f", time.Now().Sub(start).Seconds())
+
+	sum := fmt.Sprintf(SUMM, an, wn, n, by, storedselectionsummary(se), m.Sprintf("%d", srcsrch.Results.Len()),
+		m.Sprintf("%d", tgtsrch.Results.Len()), m.Sprintf("%d", shared), m.Sprintf("%d", npairs), shown,
+		vv.REUSEMAXSRCHITS, el, cp)
+
+	if lnch.Config.ZapLunates {
+		htm = gen.DeLunate(htm)
+	}
+
+	var jso JSFeeder
+	jso.SU = sum
+	jso.HT = htm
+
+	j := fmt.Sprintf(vv.BROWSERJS, "reuselocation")
+	jso.NJ = fmt.Sprintf("<script>%s</script>", j)
+
+	vlt.WSInfo.Del <- si.WSID
+	vlt.WSInfo.Del <- srcsrch.WSID
+	vlt.WSInfo.Del <- tgtsrch.WSID
+
+	return gen.JSONresponse(c, jso)
+}
+
+// reusedropstops - remove the stop words but keep the n-gram boundaries that they marked
+func reusedropstops(tokens []ngramtoken, stops map[string]struct{}, hw func(string) string) []ngramtoken {
+	kept := make([]ngramtoken, 0, len(tokens))
+	for _, t := range tokens {
+		_, s1 := stops[t.word]
+		_, s2 := stops[hw(t.word)]
+		if s1 || s2 {
+			if t.brk && len(kept) > 0 {
+				kept[len(kept)-1].brk = true
+			}
+			continue
+		}
+		kept = append(kept, t)
+	}
+	return kept
+}
+
+// reuseweights - log(N/F) for every word: rare words count for more; unparsed words are treated as hapax legomena
+func reuseweights(tokens []ngramtoken, hw func(string) string) map[string]float64 {
+	hws := make(map[string]bool)
+	for _, t := range tokens {
+		hws[hw(t.word)] = true
+	}
+
+	counts := db.FetchHeadwordCounts(hws)
+	n := float64(max(db.FetchHeadwordCorpusTotal(), 1))
+
+	weights := make(map[string]float64)
+	for _, t := range tokens {
+		if _, ok := weights[t.word]; ok {
+			continue
+		}
+		f := float64(max(counts[hw(t.word)], 1))
+		weights[t.word] = math.Log(n / f)
+	}
+	return weights
+}
+
+// reusehighlight - the text of a line with the shared words marked
+func reusehighlight(r *str.DbWorkline, shared map[string]bool, lemmatized bool, hw func(string) string) string {
+	const (
+		MATCH = `<span class="match">%s</span>`
+	)
+
+	wl := *r
+	wl.PurgeMetadata()
+	wds := strings.Fields(str.NoHTML.ReplaceAllString(strings.ReplaceAll(wl.MarkedUp, "&nbsp;", " "), ""))
+	for i, w := range wds {
+		cw := ngramclean(w)
+		if lemmatized {
+			cw = hw(cw)
+		}
+		if shared[cw] {
+			wds[i] = fmt.Sprintf(MATCH, w)
+		}
+	}
+	return strings.Join(wds, " ")
+}
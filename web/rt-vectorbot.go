@@ -129,7 +129,7 @@ func RtVectorBot(c echo.Context) error {
 func ldamodelbot(c echo.Context, s str.SearchStruct, a string) {
 	// note that only the selftestsuite suite is calling this right now
 
-	// there is no storage mechanism for lda
+	// the models are stored: see FingerprintLDAVectorSearch() and VectorDBAddLDA()
 
 	// in fact pre-building works makes more sense than authors
 	// and the caps need to be borne in mind
@@ -0,0 +1,230 @@
+//    HipparchiaGoServer
+//    Copyright: E Gunderson 2022-24
+//    License: GNU GENERAL PUBLIC LICENSE 3
+//        (see LICENSE in the top level directory of the distribution)
+
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/e-gun/HipparchiaGoServer/internal/base/gen"
+	"github.com/e-gun/HipparchiaGoServer/internal/lnch"
+	"github.com/e-gun/HipparchiaGoServer/internal/search"
+	"github.com/e-gun/HipparchiaGoServer/internal/vec"
+	"github.com/e-gun/HipparchiaGoServer/internal/vlt"
+	"github.com/e-gun/HipparchiaGoServer/internal/vv"
+	"github.com/labstack/echo/v4"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	isfingerprint = regexp.MustCompile(`^[0-9a-f]{32}$`)
+)
+
+//
+// EMBEDDINGS IN AND OUT
+//
+
+// RtVectorExport - download a stored neighbors model as word2vec text, word2vec binary, or GloVe text; or its settings
+func RtVectorExport(c echo.Context) error {
+	c.Response().After(func() { Msg.LogPaths("RtVectorExport()") })
+
+	// "/vect/export/w2vtxt" is the model for the current selection, modeler, and text prep
+	// "/vect/export/w2vbin?fp=0ba1a79c7e6d4d1b7b4f9b5bd3e0a9c1" is any stored model; the settings of such a model
+	// are unknown unless the model is in the registry: see VectorRegistryList()
+
+	const (
+		NOMODEL = "no stored model: run a neighbors search on this selection first"
+		UNKNOWN = "unknown"
+		FN      = "hipparchia_%s.%s"
+	)
+
+	if lnch.Config.VectorsDisabled {
+		return c.String(http.StatusNotFound, "")
+	}
+
+	user := vlt.ReadUUIDCookie(c)
+	if !vlt.AllAuthorized.Check(user) {
+		return c.String(http.StatusUnauthorized, "")
+	}
+
+	se := vlt.AllSessions.GetSess(user)
+
+	meta := vec.NNModelMeta{
+		Modeler:   UNKNOWN,
+		TextPrep:  UNKNOWN,
+		Selection: UNKNOWN,
+		Settings:  UNKNOWN,
+		Exported:  time.Now().Format(time.RFC3339),
+	}
+
+	// [a] which model?
+
+	fp := c.QueryParam("fp")
+	if fp == "" {
+		s := search.BuildDefaultSearch(c)
+		vlt.WSInfo.Del <- s.WSID
+		fp = vec.FingerprintNNVectorSearch(s)
+		meta.Modeler = se.VecModeler
+		meta.TextPrep = se.VecTextPrep
+		meta.Selection = search.InclusionOverview(&s, se.Inclusions)
+		meta.Settings = vec.NNModelSettings(se.VecModeler)
+	}
+
+	if !isfingerprint.MatchString(fp) || !vec.VectorDBCheckNN(fp) {
+		return c.String(http.StatusNotFound, NOMODEL)
+	}
+
+	if r, ok := vec.VectorRegistryLookup(fp); ok && r.Modeler != "" && meta.Modeler == UNKNOWN {
+		meta.Modeler = r.Modeler
+		meta.TextPrep = r.TextPrep
+		meta.Selection = r.Selection
+		meta.Settings = json.RawMessage(r.Settings)
+	}
+
+	embs := vec.VectorDBFetchNN(fp)
+	meta.Fingerprint = fp
+	meta.Words = len(embs)
+	if !embs.Empty() {
+		meta.Dimensions = embs[0].Dim
+	}
+
+	// [b] send it
+
+	attach := func(ext string) {
+		c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename="+FN, fp, ext))
+	}
+
+	switch c.Param("fmt") {
+	case "w2vtxt":
+		attach("vec")
+		return c.Blob(http.StatusOK, "text/plain; charset=utf-8", vec.EmbeddingsToText(embs, true))
+	case "w2vbin":
+		attach("bin")
+		return c.Blob(http.StatusOK, "application/octet-stream", vec.EmbeddingsToW2VBinary(embs))
+	case "glove":
+		attach("txt")
+		return c.Blob(http.StatusOK, "text/plain; charset=utf-8", vec.EmbeddingsToText(embs, false))
+	default:
+		attach("json")
+		return c.JSONPretty(http.StatusOK, meta, vv.JSONINDENT)
+	}
+}
+
+// RtVectorImport - store externally trained embeddings as the "imported" model for the current selection and text prep
+func RtVectorImport(c echo.Context) error {
+	c.Response().After(func() { Msg.LogPaths("RtVectorImport()") })
+
+	// administrative: only requests from the host itself are honored (cf. RtVectorRegistry())
+	// "curl -F embeddings=@model.vec localhost:8000/vect/import" (or a plain POST body): word2vec text or binary or GloVe text;
+	// after this a neighbors search with the modeler set to "imported" will query these embeddings
+
+	// the words of the model should look like those of the text prep: headwords unless the text prep is "unparsed";
+	// they are lowercased and get the text prep's u/v, sigma, and accent normalization on the way in
+
+	const (
+		SUMM = `
+		<div id="searchsummary">Imported %s words with %d dimensions for %s (text prep: <code>%s</code>)<br>
+			set the neighbors modeler to "imported" to query them<br>
+			<span class="small">(%ss)</span>
+		</div>`
+		FAIL    = `<div id="searchsummary">Could not import the embeddings: %s</div>`
+		REPLACE = `<div id="searchsummary">Replacing the previously imported embeddings for this selection...</div>`
+		MSG     = "RtVectorImport() stored %d embeddings as %s"
+		MSG2    = "RtVectorImport() refused a request from %s"
+		NOTHOST = "imports are only accepted from the host itself"
+		TOOBIG  = "the upload is larger than the %dMB that the server will read"
+	)
+
+	type JSFeeder struct {
+		SU string `json:"searchsummary"`
+		HT string `json:"thehtml"`
+		NJ string `json:"newjs"`
+	}
+
+	if lnch.Config.VectorsDisabled {
+		return gen.JSONresponse(c, JSFeeder{})
+	}
+
+	user := vlt.ReadUUIDCookie(c)
+	if !vlt.AllAuthorized.Check(user) {
+		return c.JSONPretty(http.StatusOK, JSFeeder{NJ: vv.JSVALIDATION}, vv.JSONINDENT)
+	}
+
+	start := time.Now()
+	se := vlt.AllSessions.GetSess(user)
+
+	// [a] read the upload: only from the host itself since the model is shared by everyone who selects the same texts
+
+	if c.RealIP() != lnch.Config.HostIP {
+		Msg.NOTE(fmt.Sprintf(MSG2, c.RealIP()))
+		return gen.JSONresponse(c, JSFeeder{SU: fmt.Sprintf(FAIL, NOTHOST)})
+	}
+
+	// the upload is parsed as it is read; MaxBytesReader() errors out instead of letting a truncated model through
+
+	var src io.ReadCloser
+	if fh, err := c.FormFile("embeddings"); err == nil {
+		f, e := fh.Open()
+		if e != nil {
+			return gen.JSONresponse(c, JSFeeder{SU: fmt.Sprintf(FAIL, e.Error())})
+		}
+		defer f.Close()
+		src = f
+	} else {
+		src = c.Request().Body
+	}
+	src = http.MaxBytesReader(c.Response().Writer, src, vv.VECTORIMPORTMAX)
+
+	// the same normalization that the text prep gives the words it reads: see buildtextblock()
+	clean := func(w string) string {
+		return gen.UVσςϲ(gen.SwapAcuteForGrave(strings.ToLower(w)))
+	}
+
+	embs, err := vec.ParseEmbeddings(src, clean)
+	if err == nil {
+		err = embs.Validate()
+	}
+	var mbe *http.MaxBytesError
+	if errors.As(err, &mbe) {
+		return gen.JSONresponse(c, JSFeeder{SU: fmt.Sprintf(FAIL, fmt.Sprintf(TOOBIG, vv.VECTORIMPORTMAX>>20))})
+	}
+	if err != nil {
+		return gen.JSONresponse(c, JSFeeder{SU: fmt.Sprintf(FAIL, err.Error())})
+	}
+	if embs.Empty() {
+		return gen.JSONresponse(c, JSFeeder{SU: fmt.Sprintf(FAIL, "no embeddings found")})
+	}
+
+	// [b] store it under the fingerprint that a neighbors search with the "imported" modeler will look for
+
+	s := search.BuildDefaultSearch(c)
+	vlt.WSInfo.Del <- s.WSID
+	s.VecModeler = "imported"
+	fp := vec.FingerprintNNVectorSearch(s)
+
+	rep := ""
+	if vec.VectorDBCheckNN(fp) {
+		rep = REPLACE
+		vec.VectorDBDeleteNN(fp)
+	}
+
+	vec.VectorDBAddNN(fp, embs)
+	vec.VectorRegistryNoteBuild(fp, s, s.VecModeler, 0, time.Now().Sub(start))
+	Msg.PEEK(fmt.Sprintf(MSG, len(embs), fp))
+
+	el := fmt.Sprintf("%.2f", time.Now().Sub(start).Seconds())
+	m := message.NewPrinter(language.English)
+	sum := rep + fmt.Sprintf(SUMM, m.Sprintf("%d", len(embs)), embs[0].Dim, search.InclusionOverview(&s, se.Inclusions),
+		s.VecTextPrep, el)
+
+	return gen.JSONresponse(c, JSFeeder{SU: sum})
+}
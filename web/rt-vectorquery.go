@@ -0,0 +1,193 @@
+//    HipparchiaGoServer
+//    Copyright: E Gunderson 2022-24
+//    License: GNU GENERAL PUBLIC LICENSE 3
+//        (see LICENSE in the top level directory of the distribution)
+
+package web
+
+import (
+	"fmt"
+	"github.com/e-gun/HipparchiaGoServer/internal/base/gen"
+	"github.com/e-gun/HipparchiaGoServer/internal/base/str"
+	"github.com/e-gun/HipparchiaGoServer/internal/lnch"
+	"github.com/e-gun/HipparchiaGoServer/internal/search"
+	"github.com/e-gun/HipparchiaGoServer/internal/vec"
+	"github.com/e-gun/HipparchiaGoServer/internal/vlt"
+	"github.com/e-gun/HipparchiaGoServer/internal/vv"
+	"github.com/labstack/echo/v4"
+	"net/http"
+)
+
+// RtVectorQuery - similarity, analogy, and odd-one-out queries against the neighbors model of the current selection
+func RtVectorQuery(c echo.Context) error {
+	c.Response().After(func() { Msg.LogPaths("RtVectorQuery()") })
+
+	// "/vect/query/5e1d3a7f?q=rex ~ imperator"
+	// "/vect/query/5e1d3a7f?q=rex - vir %2B femina" (an unescaped "+" arrives as a space, which is also acceptable)
+	// "/vect/query/5e1d3a7f?q=gladius | hasta | pilum | mensa"
+	// the model is the one that a neighbors search would use: see RelationsSearch()
+
+	if lnch.Config.VectorsDisabled {
+		return c.String(http.StatusNotFound, "")
+	}
+
+	user := vlt.ReadUUIDCookie(c)
+	if !vlt.AllAuthorized.Check(user) {
+		return c.String(http.StatusUnauthorized, "")
+	}
+
+	q := gen.Purgechars(lnch.Config.BadChars, c.QueryParam("q"))
+	srch := search.BuildDefaultSearch(c)
+
+	return vec.RelationsJSON(c, srch, q)
+}
+
+// RtDiachronic - semantic change across the periods of the current selection
+func RtDiachronic(c echo.Context) error {
+	c.Response().After(func() { Msg.LogPaths("RtDiachronic()") })
+
+	// "/vect/diachronic/5e1d3a7f?w=λόγοϲ"
+	// "/vect/diachronic/5e1d3a7f?w=virtus&p=republic:-250:-44,augustan:-43:17,imperial:18:200"
+	// without "p" the periods are vv.DIACHRONICPERIODS; without "w" the trajectory is that of the word that moved most
+
+	const (
+		FAIL = `<div id="searchsummary">Semantic change: %s</div>`
+	)
+
+	if lnch.Config.VectorsDisabled {
+		return gen.JSONresponse(c, str.SearchOutputJSON{})
+	}
+
+	user := vlt.ReadUUIDCookie(c)
+	if !vlt.AllAuthorized.Check(user) {
+		return c.JSONPretty(http.StatusOK, str.SearchOutputJSON{JS: vv.JSVALIDATION}, vv.JSONINDENT)
+	}
+
+	p := c.QueryParam("p")
+	if p == "" {
+		p = vv.DIACHRONICPERIODS
+	}
+
+	periods, err := vec.ParseDiachronicPeriods(p)
+	if err != nil {
+		return gen.JSONresponse(c, str.SearchOutputJSON{Searchsummary: fmt.Sprintf(FAIL, err.Error())})
+	}
+
+	w := gen.UVσςϲ(gen.Purgechars(lnch.Config.BadChars, c.QueryParam("w")))
+	srch := search.BuildDefaultSearch(c)
+	srch.Type = "vector"
+
+	return vec.DiachronicSearch(c, srch, periods, w)
+}
+
+// RtVectorCompare - the neighbors of a word in the stored selection and in the current selection
+func RtVectorCompare(c echo.Context) error {
+	c.Response().After(func() { Msg.LogPaths("RtVectorCompare()") })
+
+	// "/vect/compare/5e1d3a7f?w=virtus": store one selection (e.g., Cicero), select another (e.g., Seneca), and then ask
+
+	if lnch.Config.VectorsDisabled {
+		return gen.JSONresponse(c, str.SearchOutputJSON{})
+	}
+
+	user := vlt.ReadUUIDCookie(c)
+	if !vlt.AllAuthorized.Check(user) {
+		return c.JSONPretty(http.StatusOK, str.SearchOutputJSON{JS: vv.JSVALIDATION}, vv.JSONINDENT)
+	}
+
+	w := gen.UVσςϲ(gen.Purgechars(lnch.Config.BadChars, c.QueryParam("w")))
+	srch := search.BuildDefaultSearch(c)
+	srch.Type = "vector"
+
+	return vec.CompareNeighborsSearch(c, srch, w)
+}
+
+// RtMoreLikeThis - the passages of the current selection that are most like a passage or a bag of words
+func RtMoreLikeThis(c echo.Context) error {
+	c.Response().After(func() { Msg.LogPaths("RtMoreLikeThis()") })
+
+	// "/vect/similar/5e1d3a7f?p=index/gr0012/001/345": the passage around that line (cf. BuildHyperlink())
+	// "/vect/similar/5e1d3a7f?q=ira furor ultio": a bag of words
+	// add "&wt=mean" for plain averages of the word vectors instead of vv.VECTORPASSAGEWEIGHT
+
+	if lnch.Config.VectorsDisabled {
+		return gen.JSONresponse(c, str.SearchOutputJSON{})
+	}
+
+	user := vlt.ReadUUIDCookie(c)
+	if !vlt.AllAuthorized.Check(user) {
+		return c.JSONPretty(http.StatusOK, str.SearchOutputJSON{JS: vv.JSVALIDATION}, vv.JSONINDENT)
+	}
+
+	wt := c.QueryParam("wt")
+	if wt == "" {
+		wt = vv.VECTORPASSAGEWEIGHT
+	}
+
+	p := c.QueryParam("p")
+	q := gen.Purgechars(lnch.Config.BadChars, c.QueryParam("q"))
+	srch := search.BuildDefaultSearch(c)
+	srch.Type = "vector"
+
+	return vec.MoreLikeThisSearch(c, srch, p, q, wt)
+}
+
+// RtTopicClassify - the topic mixture of a passage or a bag of words under the stored topic model of the current selection
+func RtTopicClassify(c echo.Context) error {
+	c.Response().After(func() { Msg.LogPaths("RtTopicClassify()") })
+
+	// "/vect/topics/5e1d3a7f?p=index/gr0012/001/345": the passage around that line (cf. BuildHyperlink())
+	// "/vect/topics/5e1d3a7f?q=ira furor ultio": a bag of words
+	// the model is the one that a topic model search with the current settings stored: see LDASearch()
+
+	if lnch.Config.VectorsDisabled {
+		return gen.JSONresponse(c, str.SearchOutputJSON{})
+	}
+
+	user := vlt.ReadUUIDCookie(c)
+	if !vlt.AllAuthorized.Check(user) {
+		return c.JSONPretty(http.StatusOK, str.SearchOutputJSON{JS: vv.JSVALIDATION}, vv.JSONINDENT)
+	}
+
+	p := c.QueryParam("p")
+	q := gen.Purgechars(lnch.Config.BadChars, c.QueryParam("q"))
+	srch := search.BuildDefaultSearch(c)
+	srch.Type = "vector"
+
+	return vec.TopicClassifySearch(c, srch, p, q)
+}
+
+// RtTopicCount - fit a range of topic counts for the current selection and recommend one
+func RtTopicCount(c echo.Context) error {
+	c.Response().After(func() { Msg.LogPaths("RtTopicCount()") })
+
+	// "/vect/topiccount/5e1d3a7f?k=4-16-2": K = 4, 6, 8, ..., 16; without "k" the range is vv.LDATOPICRANGE
+
+	const (
+		FAIL = `<div id="searchsummary">Choosing the number of topics: %s</div>`
+	)
+
+	if lnch.Config.VectorsDisabled {
+		return gen.JSONresponse(c, str.SearchOutputJSON{})
+	}
+
+	user := vlt.ReadUUIDCookie(c)
+	if !vlt.AllAuthorized.Check(user) {
+		return c.JSONPretty(http.StatusOK, str.SearchOutputJSON{JS: vv.JSVALIDATION}, vv.JSONINDENT)
+	}
+
+	k := c.QueryParam("k")
+	if k == "" {
+		k = vv.LDATOPICRANGE
+	}
+
+	ks, err := vec.ParseTopicRange(k)
+	if err != nil {
+		return gen.JSONresponse(c, str.SearchOutputJSON{Searchsummary: fmt.Sprintf(FAIL, err.Error())})
+	}
+
+	srch := search.BuildDefaultSearch(c)
+	srch.Type = "vector"
+
+	return vec.TopicCountSearch(c, srch, ks)
+}
@@ -0,0 +1,87 @@
+//    HipparchiaGoServer
+//    Copyright: E Gunderson 2022-24
+//    License: GNU GENERAL PUBLIC LICENSE 3
+//        (see LICENSE in the top level directory of the distribution)
+
+package web
+
+import (
+	"fmt"
+	"github.com/e-gun/HipparchiaGoServer/internal/lnch"
+	"github.com/e-gun/HipparchiaGoServer/internal/vec"
+	"github.com/e-gun/HipparchiaGoServer/internal/vv"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"strconv"
+)
+
+// RtVectorRegistry - list, delete, and evict the stored neighbors models
+func RtVectorRegistry(c echo.Context) error {
+	c.Response().After(func() { Msg.LogPaths("RtVectorRegistry()") })
+
+	// administrative: only requests from the host itself are honored (cf. RtVectorBot())
+	// "curl localhost:8000/vect/registry/list"
+	// "curl localhost:8000/vect/registry/delete?fp=0ba1a79c7e6d4d1b7b4f9b5bd3e0a9c1"
+	// "curl localhost:8000/vect/registry/evict?models=50&mb=500" (the defaults are the "-vm" and "-vs" caps)
+
+	const (
+		MSG1 = "RtVectorRegistry() refused a request from %s"
+		MSG2 = "RtVectorRegistry() deleted %s"
+		BAD  = "not a stored model: %s"
+		NOCP = "no caps: set models and/or mb"
+	)
+
+	type RegReport struct {
+		Models  int                   `json:"models"`
+		Bytes   int                   `json:"bytes"`
+		Dropped int                   `json:"dropped"`
+		Entries []vec.NNRegistryEntry `json:"entries"`
+	}
+
+	if lnch.Config.VectorsDisabled {
+		return c.String(http.StatusNotFound, "")
+	}
+
+	if c.RealIP() != lnch.Config.HostIP {
+		Msg.NOTE(fmt.Sprintf(MSG1, c.RealIP()))
+		return c.String(http.StatusForbidden, "")
+	}
+
+	dropped := 0
+
+	switch c.Param("cmd") {
+	case "delete":
+		fp := c.QueryParam("fp")
+		if !isfingerprint.MatchString(fp) || !vec.VectorDBCheckNN(fp) {
+			return c.String(http.StatusNotFound, fmt.Sprintf(BAD, fp))
+		}
+		vec.VectorDBDeleteNN(fp)
+		Msg.NOTE(fmt.Sprintf(MSG2, fp))
+		dropped = 1
+	case "evict":
+		atoi := func(p string, d int) int {
+			if v, err := strconv.Atoi(c.QueryParam(p)); err == nil && v >= 0 {
+				return v
+			}
+			return d
+		}
+		mx := atoi("models", lnch.Config.VectorCapModels)
+		mb := atoi("mb", lnch.Config.VectorCapMB)
+		if mx == 0 && mb == 0 {
+			return c.String(http.StatusBadRequest, NOCP)
+		}
+		dropped = vec.VectorRegistryEvict(mx, mb, "")
+	default:
+		// "list"
+	}
+
+	var rr RegReport
+	rr.Entries = vec.VectorRegistryList()
+	rr.Models = len(rr.Entries)
+	rr.Dropped = dropped
+	for _, e := range rr.Entries {
+		rr.Bytes += e.Bytes
+	}
+
+	return c.JSONPretty(http.StatusOK, rr, vv.JSONINDENT)
+}
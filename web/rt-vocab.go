@@ -38,39 +38,6 @@ func RtVocabMaker(c echo.Context) error {
 			%s
 		</div>
 		`
-		THH = `
-		<table>
-		<tr>
-				<th class="vocabtable">word</th>
-				<th class="vocabtable">count</th>
-				<th class="vocabtable">definitions</th>
-		</tr>`
-
-		TRR = `
-		<tr>
-			<td class="word"><vocabobserved id="%s">%s</vocabobserved></td>
-			<td class="count">%d</td>
-			<td class="trans">%s</td>
-		</tr>`
-
-		THHS = `
-		<table>
-		<tr>
-				<th class="vocabtable">word</th>
-				<th class="vocabtable">scansion</th>
-				<th class="vocabtable">count</th>
-				<th class="vocabtable">definitions</th>
-		</tr>`
-
-		TRRS = `
-		<tr>
-			<td class="word"><vocabobserved id="%s">%s</vocabobserved></td>
-			<td class="scansion">%s</td>
-			<td class="count">%d</td>
-			<td class="trans">%s</td>
-		</tr>`
-
-		TCL    = `</table>`
 		MSG1   = "Grabbing the lines... (part 1 of 4)"
 		MSG2   = "Parsing the vocabulary...(part 2 of 4)"
 		MSG3   = "Sifting the vocabulary...(part 3 of 4)"
@@ -174,6 +141,85 @@ func RtVocabMaker(c echo.Context) error {
 		parsedwords, mp = addkeystowordinfo(parsedwords)
 	}
 
+	// [d] get the counts, translations, and scansion: [f1] consolidate the information
+	vim := vocabinfomap(parsedwords, se.VocScansion)
+
+	// flag words that appear only in this selection
+	var onlyhere []string
+	for i := 0; i < len(parsedwords); i++ {
+		if parsedwords[i].HWdCount > 0 && parsedwords[i].HWdCount == vim[parsedwords[i].Word].C {
+			onlyhere = append(onlyhere, parsedwords[i].HeadWd)
+		}
+	}
+	onlyhere = gen.Unique(onlyhere)
+	onlyhere = gen.PolytonicSort(onlyhere)
+
+	vlt.WSInfo.UpdateSummMsg <- vlt.WSSIKVs{id, MSG3}
+
+	// [f2] sort the results and [g] format the output: [g1] build the core: jso.HT
+
+	htm := vocabtable(vim, se.VocByCount, se.VocScansion)
+
+	vlt.WSInfo.UpdateSummMsg <- vlt.WSSIKVs{id, MSG4}
+
+	// [g2] build the summary: jso.SU
+
+	an := search.DbWlnMyAu(&vocabsrch.Results.Lines[0]).Cleaname
+	if vocabsrch.TableSize > 1 {
+		an = an + fmt.Sprintf(" and %d more author(s)", vocabsrch.TableSize-1)
+	}
+
+	wn := search.DbWlnMyWk(&vocabsrch.Results.Lines[0]).Title
+	if vocabsrch.SearchSize > 1 {
+		wn = wn + fmt.Sprintf(" and %d more works(s)", vocabsrch.SearchSize-1)
+	}
+
+	cf := search.DbWlnMyWk(&vocabsrch.Results.Lines[0]).CitationFormat()
+	var tc []string
+	for _, x := range cf {
+		if len(x) != 0 {
+			tc = append(tc, x)
+		}
+	}
+
+	cit := strings.Join(tc, ", ")
+
+	m := message.NewPrinter(language.English)
+	wf := m.Sprintf("%d", len(parsedwords))
+
+	el := fmt.Sprintf("%.2f", time.Now().Sub(start).Seconds())
+
+	ky := multiworkkeymaker(mp, &vocabsrch)
+
+	cp := ""
+	if vocabsrch.Results.Len() == mx {
+		cp = m.Sprintf(HITCAP, mx)
+	}
+
+	u := len(onlyhere)
+	uw := `<p class="indented smallerthannormal">` + strings.Join(onlyhere, ", ") + `</p>`
+
+	sum := fmt.Sprintf(SUMM, an, wn, cit, wf, u, uw, el, cp, ky)
+
+	if lnch.Config.ZapLunates {
+		htm = gen.DeLunate(htm)
+	}
+
+	var jso JSFeeder
+	jso.SU = sum
+	jso.HT = htm
+
+	j := fmt.Sprintf(vv.LEXFINDJS, "vocabobserved")
+	jso.NJ = fmt.Sprintf("<script>%s</script>", j)
+
+	vlt.WSInfo.Del <- si.WSID
+	vlt.WSInfo.Del <- vocabsrch.WSID
+
+	return gen.JSONresponse(c, jso)
+}
+
+// vocabinfomap - []WordInfo with headwords attached --> a map of headwords to their counts, translations, and scansion
+func vocabinfomap(parsedwords []str.WordInfo, withscansion bool) map[string]str.VocInfo {
 	// [d] get the counts
 	vic := make(map[string]int)
 	for _, p := range parsedwords {
@@ -187,7 +233,7 @@ func RtVocabMaker(c echo.Context) error {
 	}
 
 	scansion := make(map[string]string)
-	if se.VocScansion {
+	if withscansion {
 		scansion = db.ArrayToGetScansion(gen.StringMapKeysIntoSlice(vit))
 	}
 
@@ -213,15 +259,46 @@ func RtVocabMaker(c echo.Context) error {
 		}
 	}
 
-	// flag words that appear only in this selection
-	var onlyhere []string
-	for i := 0; i < len(parsedwords); i++ {
-		if parsedwords[i].HWdCount > 0 && parsedwords[i].HWdCount == vim[parsedwords[i].Word].C {
-			onlyhere = append(onlyhere, parsedwords[i].HeadWd)
-		}
-	}
-	onlyhere = gen.Unique(onlyhere)
-	onlyhere = gen.PolytonicSort(onlyhere)
+	return vim
+}
+
+// vocabtable - the vocabulary table sorted either alphabetically or by count
+func vocabtable(vim map[string]str.VocInfo, bycount bool, withscansion bool) string {
+	const (
+		THH = `
+		<table>
+		<tr>
+				<th class="vocabtable">word</th>
+				<th class="vocabtable">count</th>
+				<th class="vocabtable">definitions</th>
+		</tr>`
+
+		TRR = `
+		<tr>
+			<td class="word"><vocabobserved id="%s">%s</vocabobserved></td>
+			<td class="count">%d</td>
+			<td class="trans">%s</td>
+		</tr>`
+
+		THHS = `
+		<table>
+		<tr>
+				<th class="vocabtable">word</th>
+				<th class="vocabtable">scansion</th>
+				<th class="vocabtable">count</th>
+				<th class="vocabtable">definitions</th>
+		</tr>`
+
+		TRRS = `
+		<tr>
+			<td class="word"><vocabobserved id="%s">%s</vocabobserved></td>
+			<td class="scansion">%s</td>
+			<td class="count">%d</td>
+			<td class="trans">%s</td>
+		</tr>`
+
+		TCL = `</table>`
+	)
 
 	vis := make([]str.VocInfo, len(vim))
 	ct := 0
@@ -230,10 +307,8 @@ func RtVocabMaker(c echo.Context) error {
 		ct += 1
 	}
 
-	vlt.WSInfo.UpdateSummMsg <- vlt.WSSIKVs{id, MSG3}
-
 	// [f2] sort the results
-	if se.VocByCount {
+	if bycount {
 		countDecreasing := func(one, two *str.VocInfo) bool {
 			return one.C > two.C
 		}
@@ -245,12 +320,10 @@ func RtVocabMaker(c echo.Context) error {
 		sort.Slice(vis, func(i, j int) bool { return vis[i].Strip < vis[j].Strip })
 	}
 
-	vlt.WSInfo.UpdateSummMsg <- vlt.WSSIKVs{id, MSG4}
-
 	// [g] format the output
 
 	headtempl := THH
-	if se.VocScansion {
+	if withscansion {
 		headtempl = THHS
 	}
 
@@ -258,7 +331,7 @@ func RtVocabMaker(c echo.Context) error {
 	trr[0] = headtempl
 	for i, v := range vis {
 		var nt string
-		if se.VocScansion {
+		if withscansion {
 			nt = fmt.Sprintf(TRRS, v.Word, v.Word, v.Metr, v.C, v.TR)
 		} else {
 			nt = fmt.Sprintf(TRR, v.Word, v.Word, v.C, v.TR)
@@ -271,58 +344,5 @@ func RtVocabMaker(c echo.Context) error {
 
 	htm := strings.Join(trr, "")
 
-	// [g2] build the summary: jso.SU
-
-	an := search.DbWlnMyAu(&vocabsrch.Results.Lines[0]).Cleaname
-	if vocabsrch.TableSize > 1 {
-		an = an + fmt.Sprintf(" and %d more author(s)", vocabsrch.TableSize-1)
-	}
-
-	wn := search.DbWlnMyWk(&vocabsrch.Results.Lines[0]).Title
-	if vocabsrch.SearchSize > 1 {
-		wn = wn + fmt.Sprintf(" and %d more works(s)", vocabsrch.SearchSize-1)
-	}
-
-	cf := search.DbWlnMyWk(&vocabsrch.Results.Lines[0]).CitationFormat()
-	var tc []string
-	for _, x := range cf {
-		if len(x) != 0 {
-			tc = append(tc, x)
-		}
-	}
-
-	cit := strings.Join(tc, ", ")
-
-	m := message.NewPrinter(language.English)
-	wf := m.Sprintf("%d", len(parsedwords))
-
-	el := fmt.Sprintf("%.2f", time.Now().Sub(start).Seconds())
-
-	ky := multiworkkeymaker(mp, &vocabsrch)
-
-	cp := ""
-	if vocabsrch.Results.Len() == mx {
-		cp = m.Sprintf(HITCAP, mx)
-	}
-
-	u := len(onlyhere)
-	uw := `<p class="indented smallerthannormal">` + strings.Join(onlyhere, ", ") + `</p>`
-
-	sum := fmt.Sprintf(SUMM, an, wn, cit, wf, u, uw, el, cp, ky)
-
-	if lnch.Config.ZapLunates {
-		htm = gen.DeLunate(htm)
-	}
-
-	var jso JSFeeder
-	jso.SU = sum
-	jso.HT = htm
-
-	j := fmt.Sprintf(vv.LEXFINDJS, "vocabobserved")
-	jso.NJ = fmt.Sprintf("<script>%s</script>", j)
-
-	vlt.WSInfo.Del <- si.WSID
-	vlt.WSInfo.Del <- vocabsrch.WSID
-
-	return gen.JSONresponse(c, jso)
+	return htm
 }
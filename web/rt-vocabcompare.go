@@ -0,0 +1,267 @@
+//    HipparchiaGoServer
+//    Copyright: E Gunderson 2022-24
+//    License: GNU GENERAL PUBLIC LICENSE 3
+//        (see LICENSE in the top level directory of the distribution)
+
+package web
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"github.com/e-gun/HipparchiaGoServer/internal/base/gen"
+	"github.com/e-gun/HipparchiaGoServer/internal/lnch"
+	"github.com/e-gun/HipparchiaGoServer/internal/search"
+	"github.com/e-gun/HipparchiaGoServer/internal/vlt"
+	"github.com/e-gun/HipparchiaGoServer/internal/vv"
+	"github.com/labstack/echo/v4"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// VocabComparison - the headwords of two selections sorted into shared, only in A, and only in B
+type VocabComparison struct {
+	A       string        `json:"a"`
+	B       string        `json:"b"`
+	ATokens int           `json:"atokens"`
+	BTokens int           `json:"btokens"`
+	Shared  []VocabCompEl `json:"shared"`
+	OnlyA   []VocabCompEl `json:"onlya"`
+	OnlyB   []VocabCompEl `json:"onlyb"`
+}
+
+// VocabCompEl - one headword of a VocabComparison; frequencies are per 10,000 words
+type VocabCompEl struct {
+	Word  string  `json:"headword"`
+	A     int     `json:"acount"`
+	B     int     `json:"bcount"`
+	AFreq float64 `json:"afreq"`
+	BFreq float64 `json:"bfreq"`
+}
+
+// RtVocabCompare - compare the vocabulary of the stored selection ("A") with that of the current selection ("B")
+func RtVocabCompare(c echo.Context) error {
+	c.Response().After(func() { Msg.LogPaths("RtVocabCompare()") })
+
+	// add "?fmt=json" or "?fmt=csv" to the URL to get the full comparison back as data
+
+	const (
+		SUMM = `
+		<div id="searchsummary">Vocabulary of A (the stored selection: %s)<br>
+			compared with B (%s,&nbsp;<span class="foundwork">%s</span>)<br>
+			%s words in A and %s words in B; headwords counted by "winner takes all"<br>
+			%d shared headwords; %d only in A; %d only in B<br>
+			%s<br>
+			<span class="small">(%ss)</span><br>
+			%s
+		</div>
+		`
+		TBL = `
+		<table>
+		<tr><th class="vocabtable" colspan="5">%s</th></tr>
+		<tr>
+				<th class="vocabtable">headword</th>
+				<th class="vocabtable">A</th>
+				<th class="vocabtable">per 10k</th>
+				<th class="vocabtable">B</th>
+				<th class="vocabtable">per 10k</th>
+		</tr>
+		%s
+		</table>`
+		TRR = `
+		<tr>
+			<td class="word"><vocabobserved id="%s">%s</vocabobserved></td>
+			<td class="count">%d</td>
+			<td class="count">%.2f</td>
+			<td class="count">%d</td>
+			<td class="count">%.2f</td>
+		</tr>`
+		SHARED   = "Shared headwords"
+		ONLYA    = "Only in A"
+		ONLYB    = "Only in B"
+		CSVFN    = "vocabulary_comparison.csv"
+		NOSOURCE = `<div id="searchsummary">A vocabulary comparison needs two selections: store the first selection and then make the second</div>`
+		MSG1     = "Grabbing the lines...&nbsp;(part 1 of 2)"
+		MSG2     = "Parsing the vocabulary...&nbsp;(part 2 of 2)"
+		HITCAP   = `<span class="small"><span class="red emph">vocabulary comparison incomplete:</span>: hit the cap of %d on allowed lines</span>`
+	)
+
+	type JSFeeder struct {
+		SU string `json:"searchsummary"`
+		HT string `json:"thehtml"`
+		NJ string `json:"newjs"`
+	}
+
+	user := vlt.ReadUUIDCookie(c)
+	if !vlt.AllAuthorized.Check(user) {
+		return c.JSONPretty(http.StatusOK, JSFeeder{NJ: vv.JSVALIDATION}, vv.JSONINDENT)
+	}
+
+	start := time.Now()
+	se := vlt.AllSessions.GetSess(user)
+	if se.StoredIncl.IsEmpty() {
+		return gen.JSONresponse(c, JSFeeder{SU: NOSOURCE})
+	}
+
+	// "si" is a blank search struct used for progress reporting
+	si := search.BuildDefaultSearch(c)
+	si.Type = "vocabcompare"
+
+	vlt.WSInfo.UpdateSummMsg <- vlt.WSSIKVs{si.WSID, MSG1}
+	vlt.WSInfo.UpdateRemain <- vlt.WSSIKVi{si.WSID, 1}
+
+	// [a] get the lines of both selections
+
+	mx := lnch.Config.MaxText * vv.MAXVOCABLINEGENERATION
+	asrch := search.StoredSelectionIntoBulkSearch(c, mx)
+	bsrch := search.SessionIntoBulkSearch(c, mx)
+
+	if asrch.Results.Len() == 0 || bsrch.Results.Len() == 0 {
+		return emptyjsreturn(c)
+	}
+
+	// [b] count the headwords
+
+	vlt.WSInfo.UpdateSummMsg <- vlt.WSSIKVs{si.WSID, MSG2}
+
+	acount, atotal := keynesscount(&asrch, true)
+	bcount, btotal := keynesscount(&bsrch, true)
+
+	an := search.DbWlnMyAu(&bsrch.Results.Lines[0]).Cleaname
+	if bsrch.TableSize > 1 {
+		an = an + fmt.Sprintf(" and %d more author(s)", bsrch.TableSize-1)
+	}
+
+	wn := search.DbWlnMyWk(&bsrch.Results.Lines[0]).Title
+	if bsrch.SearchSize > 1 {
+		wn = wn + fmt.Sprintf(" and %d more works(s)", bsrch.SearchSize-1)
+	}
+
+	vc := vocabcomparison(acount, atotal, bcount, btotal)
+	vc.A = storedselectionsummary(se)
+	vc.B = an + ", " + wn
+
+	vlt.WSInfo.Del <- si.WSID
+	vlt.WSInfo.Del <- asrch.WSID
+	vlt.WSInfo.Del <- bsrch.WSID
+
+	switch c.QueryParam("fmt") {
+	case "json":
+		return gen.JSONresponse(c, vc)
+	case "csv":
+		c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%s", CSVFN))
+		return c.Blob(http.StatusOK, "text/csv; charset=utf-8", vocabcomparisoncsv(vc))
+	}
+
+	// [c] format the output
+
+	table := func(title string, els []VocabCompEl) string {
+		var trr strings.Builder
+		for _, v := range els {
+			trr.WriteString(fmt.Sprintf(TRR, v.Word, v.Word, v.A, v.AFreq, v.B, v.BFreq))
+		}
+		return fmt.Sprintf(TBL, title, trr.String())
+	}
+
+	htm := table(SHARED, vc.Shared) + table(ONLYA, vc.OnlyA) + table(ONLYB, vc.OnlyB)
+
+	m := message.NewPrinter(language.English)
+
+	cp := ""
+	if asrch.Results.Len() == mx || bsrch.Results.Len() == mx {
+		cp = m.Sprintf(HITCAP, mx)
+	}
+
+	jsurl := c.Request().URL.Path + "?fmt=json"
+	csvurl := c.Request().URL.Path + "?fmt=csv"
+
+	el := fmt.Sprintf("%.2f", time.Now().Sub(start).Seconds())
+
+	sum := fmt.Sprintf(SUMM, vc.A, an, wn, m.Sprintf("%d", atotal), m.Sprintf("%d", btotal), len(vc.Shared),
+		len(vc.OnlyA), len(vc.OnlyB), fmt.Sprintf(vv.COLLOCEXPORT, jsurl, csvurl), el, cp)
+
+	if lnch.Config.ZapLunates {
+		htm = gen.DeLunate(htm)
+	}
+
+	var jso JSFeeder
+	jso.SU = sum
+	jso.HT = htm
+
+	j := fmt.Sprintf(vv.LEXFINDJS, "vocabobserved")
+	jso.NJ = fmt.Sprintf("<script>%s</script>", j)
+
+	return gen.JSONresponse(c, jso)
+}
+
+// vocabcomparison - sort the headwords into shared/only A/only B; shared by combined frequency, the others by count
+func vocabcomparison(acount map[string]int, atotal int, bcount map[string]int, btotal int) VocabComparison {
+	pertenk := func(n int, total int) float64 {
+		return float64(n) * 10000 / float64(max(total, 1))
+	}
+
+	vc := VocabComparison{ATokens: atotal, BTokens: btotal}
+
+	for w, a := range acount {
+		el := VocabCompEl{Word: w, A: a, B: bcount[w], AFreq: pertenk(a, atotal), BFreq: pertenk(bcount[w], btotal)}
+		if el.B > 0 {
+			vc.Shared = append(vc.Shared, el)
+		} else {
+			vc.OnlyA = append(vc.OnlyA, el)
+		}
+	}
+
+	for w, b := range bcount {
+		if _, ok := acount[w]; !ok {
+			vc.OnlyB = append(vc.OnlyB, VocabCompEl{Word: w, B: b, BFreq: pertenk(b, btotal)})
+		}
+	}
+
+	sort.Slice(vc.Shared, func(i, j int) bool {
+		x := vc.Shared[i].AFreq + vc.Shared[i].BFreq
+		y := vc.Shared[j].AFreq + vc.Shared[j].BFreq
+		if x == y {
+			return vc.Shared[i].Word < vc.Shared[j].Word
+		}
+		return x > y
+	})
+
+	bycount := func(els []VocabCompEl, cnt func(VocabCompEl) int) {
+		sort.Slice(els, func(i, j int) bool {
+			if cnt(els[i]) == cnt(els[j]) {
+				return els[i].Word < els[j].Word
+			}
+			return cnt(els[i]) > cnt(els[j])
+		})
+	}
+
+	bycount(vc.OnlyA, func(e VocabCompEl) int { return e.A })
+	bycount(vc.OnlyB, func(e VocabCompEl) int { return e.B })
+
+	return vc
+}
+
+// vocabcomparisoncsv - the whole comparison as CSV
+func vocabcomparisoncsv(vc VocabComparison) []byte {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	_ = w.Write([]string{"headword", "where", "acount", "aper10k", "bcount", "bper10k"})
+	for _, set := range []struct {
+		n   string
+		els []VocabCompEl
+	}{{"shared", vc.Shared}, {"onlya", vc.OnlyA}, {"onlyb", vc.OnlyB}} {
+		for _, e := range set.els {
+			_ = w.Write([]string{e.Word, set.n, fmt.Sprintf("%d", e.A), fmt.Sprintf("%.4f", e.AFreq),
+				fmt.Sprintf("%d", e.B), fmt.Sprintf("%.4f", e.BFreq)})
+		}
+	}
+	w.Flush()
+	Msg.EC(w.Error())
+
+	return buf.Bytes()
+}